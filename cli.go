@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+//cliOption describes a command line option accepted as --long (or) -s anywhere in the args
+type cliOption struct {
+	Long     string
+	Short    string
+	Arg      string //name of the option argument, empty for boolean switches
+	Desc     string
+	Repeat   bool   //option can be given multiple times
+	Hidden   bool   //option is accepted but not shown in help and completions
+	NoConfig bool   //option can't be set from config files (or) environment variables
+	Complete string //shell completion of the argument: "file", "dir" (or) space separated words
+}
+
+//command describes a sub command of the cli
+type command struct {
+	Name    string
+	Summary string
+	Usage   string
+	Options []*cliOption
+	Run     func(parsed *parsedArgs) error
+}
+
+//parsedArgs holds the option values and the positional args of a command line
+type parsedArgs struct {
+	command *command
+	values  map[string][]string
+	args    []string
+}
+
+func (parsed *parsedArgs) isSet(name string) bool {
+	_, isSet := parsed.values[name]
+	return isSet
+}
+
+func (parsed *parsedArgs) str(name string) string {
+	values := parsed.values[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+func (parsed *parsedArgs) strs(name string) []string {
+	return parsed.values[name]
+}
+
+func (parsed *parsedArgs) boolean(name string) bool {
+	value, _ := strconv.ParseBool(parsed.str(name))
+	return value
+}
+
+func (parsed *parsedArgs) integer(name string) int {
+	value, _ := strconv.Atoi(parsed.str(name))
+	return value
+}
+
+func findOption(options []*cliOption, name string, isShort bool) *cliOption {
+	for _, option := range options {
+		if (isShort && option.Short == name) || (!isShort && option.Long == name) {
+			return option
+		}
+	}
+	return nil
+}
+
+func (parsed *parsedArgs) set(option *cliOption, value string) error {
+	if option.Arg == "" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("Invalid value '%s' for option --%s", value, option.Long)
+		}
+	}
+	if option.Repeat {
+		parsed.values[option.Long] = append(parsed.values[option.Long], value)
+	} else {
+		parsed.values[option.Long] = []string{value}
+	}
+	return nil
+}
+
+//parseArgs parses GNU style options given anywhere in the args. Everything after "--" is positional.
+func parseArgs(cmd *command, args []string) (*parsedArgs, error) {
+	parsed := &parsedArgs{command: cmd, values: make(map[string][]string)}
+	options := cmd.Options
+
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+
+		switch {
+		case arg == "--":
+			parsed.args = append(parsed.args, args[index+1:]...)
+			return parsed, nil
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			name := strings.TrimLeft(arg, "-")
+			value, hasValue := "", false
+			if equalIndex := strings.Index(name, "="); equalIndex != -1 {
+				name, value, hasValue = name[:equalIndex], name[equalIndex+1:], true
+			}
+
+			//long option (or) a legacy single dash long option like -format
+			if option := findOption(options, name, false); option != nil && (strings.HasPrefix(arg, "--") || len(name) > 1) {
+				if !hasValue {
+					if option.Arg == "" {
+						value = "true"
+					} else if index+1 < len(args) {
+						index++
+						value = args[index]
+					} else {
+						return nil, fmt.Errorf("Option --%s requires an argument %s", option.Long, option.Arg)
+					}
+				}
+				if err := parsed.set(option, value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			if strings.HasPrefix(arg, "--") {
+				return nil, fmt.Errorf("Unknown option %s for %s", arg, cmd.Name)
+			}
+
+			//bundled short options like -lm (or) -fhls-1472
+			shorts := arg[1:]
+			for shortIndex := 0; shortIndex < len(shorts); shortIndex++ {
+				option := findOption(options, shorts[shortIndex:shortIndex+1], true)
+				if option == nil {
+					return nil, fmt.Errorf("Unknown option -%c for %s", shorts[shortIndex], cmd.Name)
+				}
+				if option.Arg == "" {
+					if err := parsed.set(option, "true"); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value := strings.TrimPrefix(shorts[shortIndex+1:], "=")
+				if value == "" {
+					if index+1 >= len(args) {
+						return nil, fmt.Errorf("Option -%s requires an argument %s", option.Short, option.Arg)
+					}
+					index++
+					value = args[index]
+				}
+				if err := parsed.set(option, value); err != nil {
+					return nil, err
+				}
+				break
+			}
+
+		default:
+			parsed.args = append(parsed.args, arg)
+		}
+	}
+
+	return parsed, nil
+}
+
+//setDefaults sets the given values for the options of the command not set already.
+//Options of other commands are skipped while unknown options are reported with the source of the values.
+func (parsed *parsedArgs) setDefaults(values map[string][]string, source string) error {
+	for _, name := range sortedValueKeys(values) {
+		option := findConfigurableOption(parsed.command.Options, name)
+		if option == nil {
+			if !isConfigurableOption(name) {
+				return fmt.Errorf("%s: unknown option %s", source, name)
+			}
+			continue
+		}
+		if parsed.isSet(name) {
+			continue
+		}
+		for _, value := range values[name] {
+			if err := parsed.set(option, value); err != nil {
+				return fmt.Errorf("%s: %s", source, err)
+			}
+		}
+	}
+	return nil
+}
+
+func findConfigurableOption(options []*cliOption, name string) *cliOption {
+	if option := findOption(options, name, false); option != nil && !option.NoConfig {
+		return option
+	}
+	return nil
+}
+
+func isConfigurableOption(name string) bool {
+	for _, cmd := range commands {
+		if findConfigurableOption(cmd.Options, name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedValueKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getProgramName() string {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+}
+
+func (option *cliOption) usage() string {
+	var usage strings.Builder
+	if option.Short != "" {
+		usage.WriteString(fmt.Sprintf("-%s, ", option.Short))
+	} else {
+		usage.WriteString("    ")
+	}
+	usage.WriteString(fmt.Sprintf("--%s", option.Long))
+	if option.Arg != "" {
+		usage.WriteString(fmt.Sprintf(" %s", option.Arg))
+	}
+	return usage.String()
+}
+
+//printCommandHelp prints the help generated from the command and its options
+func printCommandHelp(writer io.Writer, cmd *command) {
+	fmt.Fprintf(writer, "Usage: %s %s [OPTIONS] %s\n\n%s\n\nOptions:\n", getProgramName(), cmd.Name, cmd.Usage, cmd.Summary)
+	tw := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	for _, option := range cmd.Options {
+		if !option.Hidden {
+			fmt.Fprintf(tw, "  %s\t%s\n", option.usage(), option.Desc)
+		}
+	}
+	tw.Flush()
+}
+
+//printHelp prints the help generated from the commands
+func printHelp(writer io.Writer) {
+	fmt.Fprintf(writer, "Usage: %s [COMMAND] [OPTIONS] URL [URL...]\n\n", getProgramName())
+	fmt.Fprintf(writer, "Commands (%s is assumed when omitted):\n", defaultCommandName)
+	tw := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(writer, "\nRun '%s help COMMAND' for the options of a command.\n", getProgramName())
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+const defaultCommandName = "download"
+
+//options shared by the commands
+var helpOption = &cliOption{Long: "help", Short: "h", Desc: "Prints this help and exit", NoConfig: true}
+var versionOption = &cliOption{Long: "version", Short: "v", Desc: "Prints version info and exits", NoConfig: true}
+var listExtractorsOption = &cliOption{Long: "list-extractors", Desc: "Lists supported url families and exit", NoConfig: true}
+var configOption = &cliOption{Long: "config", Arg: "PATH", Desc: "Config file to load instead of the one in the user config directory", NoConfig: true, Complete: "file"}
+var ignoreConfigOption = &cliOption{Long: "ignore-config", Desc: "Don't load any config file", NoConfig: true}
+var profileOption = &cliOption{Long: "profile", Arg: "NAME", Desc: "Config file section to load on top of the global options", NoConfig: true}
+var batchFileOption = &cliOption{Long: "batch-file", Short: "a", Arg: "FILE", Desc: "File containing urls to process, one per line ('-' for stdin)", Complete: "file"}
+var playlistOption = &cliOption{Long: "playlist", Short: "p", Arg: "RANGE", Desc: "Video range to download from playlist"}
+var skipErrorsOption = &cliOption{Long: "skip-errors", Arg: "KINDS", Desc: "Skip the videos refused with these kinds of errors instead of failing, comma separated: " + strings.Join(utils.GetErrorKindNames(), ", "), Complete: strings.Join(utils.GetErrorKindNames(), " ")}
+var formatOption = &cliOption{Long: "format", Short: "f", Arg: "FORMAT", Desc: "Video format to download video in specified resolution"}
+var ffmpegPathOption = &cliOption{Long: "ffmpeg-location", Arg: "PATH", Desc: "Location of the ffmpeg binary(absolute path)", Complete: "file"}
+var ffmpegVerboseOption = &cliOption{Long: "ffmpeg-verbose", Desc: "Show the raw output of ffmpeg instead of the progress bar"}
+var metadataOption = &cliOption{Long: "add-metadata", Short: "m", Desc: "Add metadata to the video file"}
+var outputFileNameOption = &cliOption{Long: "output", Short: "o", Arg: "FILE", Desc: "Output file name, with the video id added before the extension when there are several videos", Complete: "file"}
+var concurrentFragmentsOption = &cliOption{Long: "concurrent-fragments", Short: "N", Arg: "N", Desc: "Number of DASH chunks (or) encrypted HLS segments to download at once (default 1)"}
+var quietOption = &cliOption{Long: "quiet", Short: "q", Desc: "Don't show the progress bars (or) any messages other than the errors"}
+var verboseOption = &cliOption{Long: "verbose", Desc: "Log the retries, fallbacks, format decisions and ffmpeg command lines to stderr. Same as --log-level verbose"}
+var logLevelOption = &cliOption{Long: "log-level", Arg: "LEVEL", Desc: "Level of detail of the messages logged: " + strings.Join(utils.GetLogLevelNames(), ", ") + " (default normal)", Complete: strings.Join(utils.GetLogLevelNames(), " ")}
+var progressJSONOption = &cliOption{Long: "progress-json", Arg: "FILE", Desc: "Write the progress events as newline delimited JSON to the file ('-' for stdout, moving the other output to stderr)", Complete: "file"}
+var pathsOption = &cliOption{Long: "paths", Short: "P", Arg: "[TYPE:]PATH", Desc: "Directory to write the files in, by type: home (the default type), temp (or) output. Can be given multiple times", Repeat: true, Complete: "dir"}
+var downloadSectionsOption = &cliOption{Long: "download-sections", Arg: "*START-END", Desc: "Download only the time range of the videos, like *00:10:00-00:15:30 (or) *600-930. The end can be left out for the end of the video"}
+var liveFromStartOption = &cliOption{Long: "live-from-start", Desc: "Record the live streams from the earliest part still available instead of the live edge"}
+var waitForVideoOption = &cliOption{Long: "wait-for-video", Arg: "SECONDS", Desc: "Retry every SECONDS while the video (or) live stream isn't available yet, until it starts"}
+var verifyOption = &cliOption{Long: "verify", Desc: "Probe the downloaded videos with ffprobe (or) natively for MP4, failing those whose duration, streams, codecs (or) resolution don't match the format"}
+var limitRateOption = &cliOption{Long: "limit-rate", Short: "r", Arg: "RATE", Desc: "Most bytes per second to download across all the chunks and streams, like 500K (or) 4.2M"}
+var sleepIntervalOption = &cliOption{Long: "sleep-interval", Arg: "SECONDS", Desc: "Seconds to wait before each video of a playlist after the first"}
+var maxSleepIntervalOption = &cliOption{Long: "max-sleep-interval", Arg: "SECONDS", Desc: "Upper bound of a random wait between the videos of a playlist, from --sleep-interval"}
+var sleepRequestsOption = &cliOption{Long: "sleep-requests", Arg: "SECONDS", Desc: "Seconds to wait between the requests to the Hotstar website and apis"}
+var keepFragmentsOption = &cliOption{Long: "keep-fragments", Desc: "Keep the DASH chunks in their own files in the temp path after merging, for debugging"}
+var titleOption = &cliOption{Long: "get-title", Short: "t", Desc: "Prints video title and exit"}
+var descriptionOption = &cliOption{Long: "get-description", Short: "i", Desc: "Prints video description and exit"}
+var listFormatsOption = &cliOption{Long: "list", Short: "l", Desc: "List available video formats for given url"}
+var tokenOption = &cliOption{Long: "token", Arg: "TOKEN", Desc: "Hotstar user token (JWT) of a signed in account"}
+var cookiesOption = &cliOption{Long: "cookies", Arg: "FILE", Desc: "Netscape cookies file exported from a browser signed in to hotstar.com", Complete: "file"}
+var cacheDirOption = &cliOption{Long: "cache-dir", Arg: "DIR", Desc: "Directory to cache the user tokens in (default $XDG_CACHE_HOME/hotstar-dl)", Complete: "dir"}
+var noTokenCacheOption = &cliOption{Long: "no-token-cache", Desc: "Don't reuse (or) cache the user tokens across runs"}
+var clientOption = &cliOption{Long: "client", Arg: "NAME", Desc: "Client profile to request the playback as: " + strings.Join(utils.GetClientProfileNames(), ", ") + " (default " + utils.DefaultClientName + ")", Complete: strings.Join(utils.GetClientProfileNames(), " ")}
+var userAgentOption = &cliOption{Long: "user-agent", Arg: "UA", Desc: "User agent to send instead of the one of the client profile"}
+var addHeaderOption = &cliOption{Long: "add-header", Arg: "NAME:VALUE", Desc: "Header to send along with the requests, can be given multiple times", Repeat: true}
+var videoCodecOption = newDesiredConfigOption("video-codec", "CODECS", utils.DimensionVideoCodec, "Video codecs to request")
+var dynamicRangeOption = newDesiredConfigOption("dynamic-range", "RANGES", utils.DimensionDynamicRange, "Dynamic ranges to request")
+var audioCodecOption = newDesiredConfigOption("audio-codec", "CODECS", utils.DimensionAudioCodec, "Audio codecs to request")
+var ladderOption = newDesiredConfigOption("ladder", "LADDERS", utils.DimensionLadder, "Resolution ladders to request")
+var resolutionOption = newDesiredConfigOption("resolution", "RESOLUTIONS", utils.DimensionResolution, "Resolutions to request")
+var containerOption = newDesiredConfigOption("container", "CONTAINERS", utils.DimensionContainer, "Containers to request")
+var packageOption = newDesiredConfigOption("package", "PACKAGES", utils.DimensionPackage, "Packages to request")
+var regionOption = &cliOption{Long: "region", Arg: "COUNTRY", Desc: "Hotstar region to request the content for, as a two letter country code (default " + utils.DefaultRegion + ")"}
+var geoBypassCountryOption = &cliOption{Long: "geo-bypass-country", Arg: "COUNTRY", Desc: "Same as --" + regionOption.Long}
+var geoVerificationProxyOption = &cliOption{Long: "geo-verification-proxy", Arg: "URL", Desc: "Proxy for the metadata and api requests only, the video downloads go direct"}
+var listenOption = &cliOption{Long: "listen", Arg: "ADDRESS", Desc: "Address the server listens on (default 127.0.0.1:8080)"}
+
+var globalOptions = []*cliOption{helpOption, versionOption, listExtractorsOption, configOption, ignoreConfigOption, profileOption}
+var downloadOptions = []*cliOption{formatOption, ffmpegPathOption, ffmpegVerboseOption, metadataOption, outputFileNameOption, concurrentFragmentsOption, downloadSectionsOption, liveFromStartOption, waitForVideoOption, verifyOption, limitRateOption, pathsOption, keepFragmentsOption, quietOption, progressJSONOption}
+var logOptions = []*cliOption{verboseOption, logLevelOption}
+var inputOptions = []*cliOption{batchFileOption, playlistOption, skipErrorsOption, sleepIntervalOption, maxSleepIntervalOption, sleepRequestsOption}
+var authOptions = []*cliOption{tokenOption, cookiesOption, cacheDirOption, noTokenCacheOption}
+var clientOptions = []*cliOption{clientOption, userAgentOption, addHeaderOption, videoCodecOption, dynamicRangeOption, audioCodecOption, ladderOption, resolutionOption, containerOption, packageOption}
+var geoOptions = []*cliOption{regionOption, geoBypassCountryOption, geoVerificationProxyOption}
+
+//desiredConfigDimensions maps the options overriding the desired config of the client profile to their dimension
+var desiredConfigDimensions = map[*cliOption]string{}
+
+func newDesiredConfigOption(long string, arg string, dimension string, desc string) *cliOption {
+	values := utils.GetDesiredConfigValues(dimension)
+	option := &cliOption{
+		Long:     long,
+		Arg:      arg,
+		Desc:     fmt.Sprintf("%s, comma separated: %s", desc, strings.Join(values, ", ")),
+		Complete: strings.Join(values, " "),
+	}
+	desiredConfigDimensions[option] = dimension
+	return option
+}
+
+//legacy mode switches of the flag based cli still accepted by the download command
+var legacyOptions = []*cliOption{
+	{Long: listFormatsOption.Long, Short: listFormatsOption.Short, Desc: listFormatsOption.Desc, Hidden: true, NoConfig: true},
+	{Long: titleOption.Long, Short: titleOption.Short, Desc: titleOption.Desc, Hidden: true, NoConfig: true},
+	{Long: descriptionOption.Long, Short: descriptionOption.Short, Desc: descriptionOption.Desc, Hidden: true, NoConfig: true},
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			Name:    "download",
+			Summary: "Downloads the videos (or) playlists in the given urls",
+			Usage:   "URL [URL...]",
+			Options: concatOptions(globalOptions, logOptions, inputOptions, authOptions, clientOptions, geoOptions, downloadOptions, legacyOptions),
+			Run:     runDownload,
+		},
+		{
+			Name:    "list-formats",
+			Summary: "Lists the available video formats for the given urls",
+			Usage:   "URL [URL...]",
+			Options: concatOptions(globalOptions, logOptions, inputOptions, authOptions, clientOptions, geoOptions),
+			Run:     runListFormats,
+		},
+		{
+			Name:    "info",
+			Summary: "Prints the title and description of the videos in the given urls",
+			Usage:   "URL [URL...]",
+			Options: concatOptions(globalOptions, logOptions, inputOptions, authOptions, clientOptions, geoOptions, []*cliOption{titleOption, descriptionOption}),
+			Run:     runInfo,
+		},
+		{
+			Name:    "playlist",
+			Summary: "Downloads (or) lists the videos in the given playlist urls",
+			Usage:   "PLAYLIST-URL [PLAYLIST-URL...]",
+			Options: concatOptions(globalOptions, logOptions, inputOptions, authOptions, clientOptions, geoOptions, downloadOptions, []*cliOption{listFormatsOption}),
+			Run:     runPlaylist,
+		},
+		{
+			Name:    "serve",
+			Summary: "Starts a HTTP server accepting download jobs",
+			Options: concatOptions(globalOptions, logOptions, authOptions, clientOptions, geoOptions, downloadOptions, []*cliOption{skipErrorsOption, listenOption}),
+			Run:     runServe,
+		},
+		{
+			Name:    "doctor",
+			Summary: "Checks the environment for the tools and network access needed",
+			Options: concatOptions(globalOptions, []*cliOption{ffmpegPathOption}),
+			Run:     runDoctor,
+		},
+		{
+			Name:    "completion",
+			Summary: "Generates the shell completion script for bash, zsh (or) fish",
+			Usage:   "SHELL",
+			Options: globalOptions,
+			Run:     runCompletion,
+		},
+		{
+			Name:    "help",
+			Summary: "Prints the help of the given command",
+			Usage:   "[COMMAND]",
+			Options: globalOptions,
+			Run:     runHelp,
+		},
+	}
+}
+
+func concatOptions(optionGroups ...[]*cliOption) []*cliOption {
+	options := make([]*cliOption, 0)
+	for _, optionGroup := range optionGroups {
+		options = append(options, optionGroup...)
+	}
+	return options
+}
+
+func isValidPlaylistFormat(playlistFormat string) (string, string, bool) {
+	var playlistFormatRegex = regexp.MustCompile(`^(?P<startRange>\d*)-(?P<endRange>\d*)$`)
+	if playlistFormatRegex.MatchString(playlistFormat) {
+		match := utils.ReSubMatchMap(playlistFormatRegex, playlistFormat)
+		return match["startRange"], match["endRange"], true
+	}
+	return "", "", false
+}
+
+func getURLs(parsed *parsedArgs) ([]string, error) {
+	urls := parsed.args
+	if parsed.isSet(batchFileOption.Long) {
+		batchURLs, err := utils.ReadBatchFile(parsed.str(batchFileOption.Long))
+		if err != nil {
+			return nil, fmt.Errorf("Error in reading batch file %s: %s", parsed.str(batchFileOption.Long), err)
+		}
+		urls = append(batchURLs, urls...)
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("Must provide atleast one url (or) a batch file")
+	}
+	return urls, nil
+}
+
+func getOptions(parsed *parsedArgs) (*utils.Options, error) {
+	options := &utils.Options{
+		Format:         parsed.str(formatOption.Long),
+		FfmpegPath:     parsed.str(ffmpegPathOption.Long),
+		OutputFileName: parsed.str(outputFileNameOption.Long),
+		Metadata:       parsed.boolean(metadataOption.Long),
+		KeepFragments:  parsed.boolean(keepFragmentsOption.Long),
+	}
+	options.Live.FromStart = parsed.boolean(liveFromStartOption.Long)
+	options.Verify = parsed.boolean(verifyOption.Long)
+
+	paths, err := utils.ParsePaths(parsed.strs(pathsOption.Long))
+	if err != nil {
+		return nil, err
+	}
+	options.Paths = paths
+
+	if playlistRange := parsed.str(playlistOption.Long); playlistRange != "" {
+		var isValidPlaylist bool
+		options.PlaylistStartRange, options.PlaylistEndRange, isValidPlaylist = isValidPlaylistFormat(playlistRange)
+		if !isValidPlaylist {
+			return nil, fmt.Errorf("Invalid playlist format '%s' specified. Should be of form <number>-<number>. Eg like 3-7 (or) 8- (or) -5 (or) -", playlistRange)
+		}
+	}
+
+	if skipErrors := parsed.str(skipErrorsOption.Long); skipErrors != "" {
+		skipErrorKinds, err := utils.ParseErrorKinds(skipErrors)
+		if err != nil {
+			return nil, err
+		}
+		options.SkipErrorKinds = skipErrorKinds
+	}
+
+	if downloadSections := parsed.str(downloadSectionsOption.Long); downloadSections != "" {
+		if options.Section, err = utils.ParseSection(downloadSections); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Live.WaitForVideo, err = getSeconds(parsed, waitForVideoOption); err != nil {
+		return nil, err
+	}
+
+	if options.Format != "" && !utils.HasValidFormatPrefix(options.Format) {
+		return nil, fmt.Errorf("Invalid format %s specified", options.Format)
+	}
+
+	concurrentFragments := 1
+	if parsed.isSet(concurrentFragmentsOption.Long) {
+		if concurrentFragments = parsed.integer(concurrentFragmentsOption.Long); concurrentFragments < 1 {
+			return nil, fmt.Errorf("Invalid --%s %s. Should be a positive number", concurrentFragmentsOption.Long, parsed.str(concurrentFragmentsOption.Long))
+		}
+	}
+	utils.SetConcurrentFragments(concurrentFragments)
+
+	var limitRate int64
+	if limitRateValue := parsed.str(limitRateOption.Long); limitRateValue != "" {
+		if limitRate, err = utils.ParseRate(limitRateValue); err != nil {
+			return nil, err
+		}
+	}
+	utils.SetLimitRate(limitRate)
+
+	if options.SleepInterval, err = getSeconds(parsed, sleepIntervalOption); err != nil {
+		return nil, err
+	}
+	if options.MaxSleepInterval, err = getSeconds(parsed, maxSleepIntervalOption); err != nil {
+		return nil, err
+	}
+	if parsed.isSet(maxSleepIntervalOption.Long) && options.MaxSleepInterval < options.SleepInterval {
+		return nil, fmt.Errorf("Invalid --%s %s. Should not be less than --%s", maxSleepIntervalOption.Long, parsed.str(maxSleepIntervalOption.Long), sleepIntervalOption.Long)
+	}
+	sleepRequests, err := getSeconds(parsed, sleepRequestsOption)
+	if err != nil {
+		return nil, err
+	}
+	utils.SetSleepRequests(sleepRequests)
+	utils.SetFfmpegVerbose(parsed.boolean(ffmpegVerboseOption.Long))
+
+	logLevel, err := getLogLevel(parsed)
+	if err != nil {
+		return nil, err
+	}
+	utils.SetLogLevel(logLevel)
+
+	if err := setProgressReporter(parsed); err != nil {
+		return nil, err
+	}
+
+	clientProfile, err := getClientProfile(parsed)
+	if err != nil {
+		return nil, err
+	}
+	utils.SetClientProfile(clientProfile)
+
+	region := parsed.str(regionOption.Long)
+	if geoBypassCountry := parsed.str(geoBypassCountryOption.Long); region == "" {
+		region = geoBypassCountry
+	} else if geoBypassCountry != "" && !strings.EqualFold(region, geoBypassCountry) {
+		return nil, fmt.Errorf("Conflicting --%s %s and --%s %s", regionOption.Long, region, geoBypassCountryOption.Long, geoBypassCountry)
+	}
+	if err := utils.SetRegion(region); err != nil {
+		return nil, err
+	}
+
+	if err := utils.SetGeoVerificationProxy(parsed.str(geoVerificationProxyOption.Long)); err != nil {
+		return nil, err
+	}
+
+	credentials, err := utils.LoadCredentials(parsed.str(tokenOption.Long), parsed.str(cookiesOption.Long))
+	if err != nil {
+		return nil, err
+	}
+	utils.SetCredentials(credentials)
+
+	if parsed.boolean(noTokenCacheOption.Long) {
+		utils.SetTokenCache(nil)
+	} else {
+		cacheDir := parsed.str(cacheDirOption.Long)
+		if cacheDir == "" {
+			if cacheDir, err = utils.GetCacheDir(); err != nil {
+				return nil, fmt.Errorf("Unable to find the cache directory, use --%s (or) --%s: %s", cacheDirOption.Long, noTokenCacheOption.Long, err)
+			}
+		}
+		utils.SetTokenCache(utils.NewTokenCache(cacheDir))
+	}
+
+	return options, nil
+}
+
+//getSeconds returns the duration given in seconds to the option, 0 when not given
+func getSeconds(parsed *parsedArgs, option *cliOption) (time.Duration, error) {
+	value := parsed.str(option.Long)
+	if value == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("Invalid --%s %s. Should be a number of seconds", option.Long, value)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+//getLogLevel returns the level given with --log-level, else verbose with --verbose (or) quiet with -q
+func getLogLevel(parsed *parsedArgs) (utils.LogLevel, error) {
+	if logLevel := parsed.str(logLevelOption.Long); logLevel != "" {
+		return utils.ParseLogLevel(logLevel)
+	}
+	if parsed.boolean(verboseOption.Long) {
+		return utils.LogVerbose, nil
+	}
+	if parsed.boolean(quietOption.Long) {
+		return utils.LogQuiet, nil
+	}
+	return utils.LogNormal, nil
+}
+
+//progressOutput is the file the JSON progress events are written to (if any), closed once the jobs are run
+var progressOutput *os.File
+
+//setProgressReporter reports the progress on the terminal bars unless quiet, along with the JSON events (if asked for)
+func setProgressReporter(parsed *parsedArgs) error {
+	reporters := make(utils.MultiProgress, 0, 2)
+	if !parsed.boolean(quietOption.Long) {
+		reporters = append(reporters, utils.NewTerminalProgress())
+	}
+
+	switch progressJSON := parsed.str(progressJSONOption.Long); progressJSON {
+	case "":
+	case "-":
+		//stdout carries only the events, the messages printed along go to stderr
+		reporters = append(reporters, utils.NewJSONProgress(os.Stdout))
+		utils.SetMessageOutput(os.Stderr)
+	default:
+		progressFile, err := os.OpenFile(progressJSON, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("Error in opening the progress file %s: %s", progressJSON, err)
+		}
+		reporters = append(reporters, utils.NewJSONProgress(progressFile))
+		progressOutput = progressFile
+	}
+
+	utils.SetProgressReporter(reporters)
+	return nil
+}
+
+func getClientProfile(parsed *parsedArgs) (*utils.ClientProfile, error) {
+	clientName := parsed.str(clientOption.Long)
+	if clientName == "" {
+		clientName = utils.DefaultClientName
+	}
+
+	clientProfile, err := utils.GetClientProfile(clientName)
+	if err != nil {
+		return nil, err
+	}
+
+	if userAgent := parsed.str(userAgentOption.Long); userAgent != "" {
+		clientProfile.SetHeader("User-Agent", userAgent)
+	}
+
+	desiredConfig, err := utils.ParseDesiredConfig(clientProfile.DesiredConfig)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range clientOptions {
+		if dimension, isDimension := desiredConfigDimensions[option]; isDimension && parsed.isSet(option.Long) {
+			if err := desiredConfig.Set(dimension, strings.Split(parsed.str(option.Long), ",")); err != nil {
+				return nil, err
+			}
+		}
+	}
+	clientProfile.DesiredConfig = desiredConfig.String()
+
+	for _, header := range parsed.strs(addHeaderOption.Long) {
+		headerName, headerValue, err := utils.ParseHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		clientProfile.SetHeader(headerName, headerValue)
+	}
+
+	return clientProfile, nil
+}
+
+func runJobs(urls []string, options *utils.Options) error {
+	summary := utils.RunJobs(urls, options)
+	if progressOutput != nil {
+		progressOutput.Close()
+	}
+
+	if len(summary.Jobs) > 1 || len(summary.Skipped()) != 0 {
+		fmt.Fprint(utils.GetMessageOutput(), summary)
+	}
+
+	if failedJobs := summary.Failed(); len(failedJobs) == 1 && len(summary.Jobs) == 1 {
+		return failedJobs[0].Err
+	} else if len(failedJobs) != 0 {
+		return fmt.Errorf("%d of %d job(s) failed", len(failedJobs), len(summary.Jobs))
+	}
+
+	return nil
+}
+
+func runDownload(parsed *parsedArgs) error {
+	urls, err := getURLs(parsed)
+	if err != nil {
+		return err
+	}
+
+	options, err := getOptions(parsed)
+	if err != nil {
+		return err
+	}
+
+	//legacy mode switches
+	options.ListFormats = parsed.boolean(listFormatsOption.Long)
+	options.Title = parsed.boolean(titleOption.Long)
+	options.Description = parsed.boolean(descriptionOption.Long)
+
+	return runJobs(urls, options)
+}
+
+func runListFormats(parsed *parsedArgs) error {
+	urls, err := getURLs(parsed)
+	if err != nil {
+		return err
+	}
+
+	options, err := getOptions(parsed)
+	if err != nil {
+		return err
+	}
+	options.ListFormats = true
+
+	return runJobs(urls, options)
+}
+
+func runInfo(parsed *parsedArgs) error {
+	urls, err := getURLs(parsed)
+	if err != nil {
+		return err
+	}
+
+	options, err := getOptions(parsed)
+	if err != nil {
+		return err
+	}
+	options.Title = parsed.boolean(titleOption.Long)
+	options.Description = parsed.boolean(descriptionOption.Long)
+	if !options.Title && !options.Description {
+		options.Title, options.Description = true, true
+	}
+
+	return runJobs(urls, options)
+}
+
+func runPlaylist(parsed *parsedArgs) error {
+	urls, err := getURLs(parsed)
+	if err != nil {
+		return err
+	}
+
+	for _, playlistURL := range urls {
+		if contentRef, err := utils.ExtractContentRef(playlistURL); err != nil || !contentRef.IsPlaylist() {
+			return fmt.Errorf("%s is not a playlist url", playlistURL)
+		}
+	}
+
+	options, err := getOptions(parsed)
+	if err != nil {
+		return err
+	}
+	options.ListFormats = parsed.boolean(listFormatsOption.Long)
+
+	return runJobs(urls, options)
+}
+
+func runServe(parsed *parsedArgs) error {
+	options, err := getOptions(parsed)
+	if err != nil {
+		return err
+	}
+
+	listenAddress := parsed.str(listenOption.Long)
+	if listenAddress == "" {
+		listenAddress = "127.0.0.1:8080"
+	}
+
+	fmt.Printf("Listening on http://%s\n", listenAddress)
+	return http.ListenAndServe(listenAddress, utils.NewServer(options))
+}
+
+func runDoctor(parsed *parsedArgs) error {
+	checks := utils.RunDoctorChecks(parsed.str(ffmpegPathOption.Long))
+	failedChecks := 0
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, check := range checks {
+		fmt.Fprintf(tw, "[%s]\t%s\t%s\n", check.Status, check.Name, check.Message)
+		if check.Status == utils.DoctorCheckFailed {
+			failedChecks++
+		}
+	}
+	tw.Flush()
+
+	if failedChecks != 0 {
+		return fmt.Errorf("%d check(s) failed", failedChecks)
+	}
+	return nil
+}
+
+func runCompletion(parsed *parsedArgs) error {
+	if len(parsed.args) != 1 {
+		return errors.New("Must provide the shell to generate the completion for: bash, zsh (or) fish")
+	}
+
+	switch strings.ToLower(parsed.args[0]) {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		writeZshCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	default:
+		return fmt.Errorf("Unsupported shell %s. Should be bash, zsh (or) fish", parsed.args[0])
+	}
+	return nil
+}
+
+func runHelp(parsed *parsedArgs) error {
+	if len(parsed.args) == 0 {
+		printHelp(os.Stdout)
+		return nil
+	}
+
+	cmd := findCommand(parsed.args[0])
+	if cmd == nil {
+		return fmt.Errorf("Unknown command %s", parsed.args[0])
+	}
+	printCommandHelp(os.Stdout, cmd)
+	return nil
+}
+
+func listExtractors() {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, extractor := range utils.GetExtractors() {
+		fmt.Fprintf(tw, "%s\t%s\n", extractor.Name(), extractor.Description())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+func getCommandNames() []string {
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+func getVisibleOptions(cmd *command) []*cliOption {
+	options := make([]*cliOption, 0)
+	for _, option := range cmd.Options {
+		if !option.Hidden {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+func getCompletionFunctionName() string {
+	return "_" + strings.Replace(getProgramName(), "-", "_", -1)
+}
+
+func getCommandArgWords(cmd *command) string {
+	switch cmd.Name {
+	case "help":
+		return strings.Join(getCommandNames(), " ")
+	case "completion":
+		return "bash zsh fish"
+	default:
+		return ""
+	}
+}
+
+//writeBashCompletion writes the bash completion script generated from the commands
+func writeBashCompletion(writer io.Writer) {
+	programName := getProgramName()
+	functionName := getCompletionFunctionName()
+
+	//options with arguments, grouped by the way their argument is completed
+	fileOptions, wordOptions, argOptions := make(map[string]bool), make(map[string]string), make(map[string]bool)
+	for _, cmd := range commands {
+		for _, option := range getVisibleOptions(cmd) {
+			if option.Arg == "" {
+				continue
+			}
+			names := []string{"--" + option.Long}
+			if option.Short != "" {
+				names = append(names, "-"+option.Short)
+			}
+			for _, name := range names {
+				switch option.Complete {
+				case "file", "dir":
+					fileOptions[name] = true
+				case "":
+					argOptions[name] = true
+				default:
+					wordOptions[name] = option.Complete
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(writer, "# bash completion for %s\n", programName)
+	fmt.Fprintf(writer, "# source it (or) save it under /etc/bash_completion.d/%s\n\n", programName)
+	fmt.Fprintf(writer, "%s() {\n", functionName)
+	fmt.Fprintln(writer, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintf(writer, "    local commands=\"%s\"\n", strings.Join(getCommandNames(), " "))
+	fmt.Fprintf(writer, "    local cmd=\"%s\" opts=\"\"\n", defaultCommandName)
+	fmt.Fprintln(writer, `    if [[ ${COMP_CWORD} -gt 1 && " ${commands} " == *" ${COMP_WORDS[1]} "* ]]; then`)
+	fmt.Fprintln(writer, `        cmd="${COMP_WORDS[1]}"`)
+	fmt.Fprintln(writer, `    fi`)
+	fmt.Fprintln(writer, `    case "${prev}" in`)
+	if len(fileOptions) != 0 {
+		fmt.Fprintf(writer, "        %s)\n            COMPREPLY=( $(compgen -f -- \"${cur}\") )\n            return ;;\n", strings.Join(sortedKeys(fileOptions), "|"))
+	}
+	for _, name := range sortedWordKeys(wordOptions) {
+		fmt.Fprintf(writer, "        %s)\n            COMPREPLY=( $(compgen -W \"%s\" -- \"${cur}\") )\n            return ;;\n", name, wordOptions[name])
+	}
+	if len(argOptions) != 0 {
+		fmt.Fprintf(writer, "        %s)\n            return ;;\n", strings.Join(sortedKeys(argOptions), "|"))
+	}
+	fmt.Fprintln(writer, `    esac`)
+	fmt.Fprintln(writer, `    case "${cmd}" in`)
+	for _, cmd := range commands {
+		names := make([]string, 0)
+		for _, option := range getVisibleOptions(cmd) {
+			names = append(names, "--"+option.Long)
+			if option.Short != "" {
+				names = append(names, "-"+option.Short)
+			}
+		}
+		fmt.Fprintf(writer, "        %s) opts=\"%s\" ;;\n", cmd.Name, strings.Join(names, " "))
+	}
+	fmt.Fprintln(writer, `    esac`)
+	fmt.Fprintln(writer, `    if [[ "${cur}" == -* ]]; then`)
+	fmt.Fprintln(writer, `        COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )`)
+	fmt.Fprintln(writer, `    elif [[ ${COMP_CWORD} -eq 1 ]]; then`)
+	fmt.Fprintln(writer, `        COMPREPLY=( $(compgen -W "${commands}" -- "${cur}") )`)
+	for _, cmd := range commands {
+		if words := getCommandArgWords(cmd); words != "" {
+			fmt.Fprintf(writer, "    elif [[ \"${cmd}\" == %s ]]; then\n", cmd.Name)
+			fmt.Fprintf(writer, "        COMPREPLY=( $(compgen -W \"%s\" -- \"${cur}\") )\n", words)
+		}
+	}
+	fmt.Fprintln(writer, `    fi`)
+	fmt.Fprintln(writer, `}`)
+	fmt.Fprintf(writer, "complete -o default -F %s %s\n", functionName, programName)
+}
+
+func escapeZsh(text string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(text)
+}
+
+func getZshOptionSpec(option *cliOption) string {
+	var spec strings.Builder
+	repeat := ""
+	if option.Repeat {
+		repeat = "*"
+	}
+
+	if option.Short != "" {
+		if option.Repeat {
+			spec.WriteString(fmt.Sprintf("'*'{-%s,--%s}'", option.Short, option.Long))
+		} else {
+			spec.WriteString(fmt.Sprintf("'(-%s --%s)'{-%s,--%s}'", option.Short, option.Long, option.Short, option.Long))
+		}
+	} else {
+		spec.WriteString(fmt.Sprintf("'%s--%s", repeat, option.Long))
+	}
+
+	spec.WriteString(fmt.Sprintf("[%s]", escapeZsh(option.Desc)))
+
+	if option.Arg != "" {
+		switch option.Complete {
+		case "file":
+			spec.WriteString(fmt.Sprintf(":%s:_files", option.Arg))
+		case "dir":
+			spec.WriteString(fmt.Sprintf(":%s:_files -/", option.Arg))
+		case "":
+			spec.WriteString(fmt.Sprintf(":%s: ", option.Arg))
+		default:
+			spec.WriteString(fmt.Sprintf(":%s:(%s)", option.Arg, escapeZsh(option.Complete)))
+		}
+	}
+	spec.WriteString("'")
+	return spec.String()
+}
+
+//writeZshCompletion writes the zsh completion script generated from the commands
+func writeZshCompletion(writer io.Writer) {
+	programName := getProgramName()
+	functionName := getCompletionFunctionName()
+
+	fmt.Fprintf(writer, "#compdef %s\n", programName)
+	fmt.Fprintf(writer, "# save it as %s in a directory of $fpath\n\n", functionName)
+	fmt.Fprintf(writer, "%s() {\n", functionName)
+	fmt.Fprintln(writer, `    local -a commands`)
+	fmt.Fprintln(writer, `    commands=(`)
+	for _, cmd := range commands {
+		fmt.Fprintf(writer, "        '%s:%s'\n", cmd.Name, escapeZsh(cmd.Summary))
+	}
+	fmt.Fprintln(writer, `    )`)
+	fmt.Fprintln(writer, `    if (( CURRENT == 2 )) && [[ ${words[2]} != -* ]]; then`)
+	fmt.Fprintln(writer, `        _describe -t commands 'command' commands`)
+	fmt.Fprintln(writer, `        return`)
+	fmt.Fprintln(writer, `    fi`)
+	fmt.Fprintf(writer, "    local cmd=%s\n", defaultCommandName)
+	fmt.Fprintln(writer, `    if (( ${commands[(I)${words[2]}:*]} )); then`)
+	fmt.Fprintln(writer, `        cmd=${words[2]}`)
+	fmt.Fprintln(writer, `        shift words`)
+	fmt.Fprintln(writer, `        (( CURRENT-- ))`)
+	fmt.Fprintln(writer, `    fi`)
+	fmt.Fprintln(writer, `    case ${cmd} in`)
+	for _, cmd := range commands {
+		fmt.Fprintf(writer, "        %s)\n            _arguments -s \\\n", cmd.Name)
+		for _, option := range getVisibleOptions(cmd) {
+			fmt.Fprintf(writer, "                %s \\\n", getZshOptionSpec(option))
+		}
+		if words := getCommandArgWords(cmd); words != "" {
+			fmt.Fprintf(writer, "                '1:%s:(%s)'\n", strings.ToLower(cmd.Usage), words)
+		} else if cmd.Usage != "" {
+			fmt.Fprintln(writer, `                '*:url: '`)
+		} else {
+			fmt.Fprintln(writer, `                ''`)
+		}
+		fmt.Fprintln(writer, `            ;;`)
+	}
+	fmt.Fprintln(writer, `    esac`)
+	fmt.Fprintln(writer, `}`)
+	fmt.Fprintf(writer, "\n%s \"$@\"\n", functionName)
+}
+
+func escapeFish(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(text)
+}
+
+//writeFishCompletion writes the fish completion script generated from the commands
+func writeFishCompletion(writer io.Writer) {
+	programName := getProgramName()
+
+	fmt.Fprintf(writer, "# fish completion for %s\n", programName)
+	fmt.Fprintf(writer, "# save it as ~/.config/fish/completions/%s.fish\n\n", programName)
+	fmt.Fprintf(writer, "complete -c %s -f\n", programName)
+	for _, cmd := range commands {
+		fmt.Fprintf(writer, "complete -c %s -n __fish_use_subcommand -a %s -d '%s'\n", programName, cmd.Name, escapeFish(cmd.Summary))
+	}
+
+	for _, cmd := range commands {
+		condition := fmt.Sprintf("__fish_seen_subcommand_from %s", cmd.Name)
+		if cmd.Name == defaultCommandName {
+			condition = fmt.Sprintf("__fish_use_subcommand; or %s", condition)
+		}
+
+		for _, option := range getVisibleOptions(cmd) {
+			var spec strings.Builder
+			spec.WriteString(fmt.Sprintf("complete -c %s -n '%s'", programName, condition))
+			if option.Short != "" {
+				spec.WriteString(fmt.Sprintf(" -s %s", option.Short))
+			}
+			spec.WriteString(fmt.Sprintf(" -l %s", option.Long))
+			if option.Arg != "" {
+				switch option.Complete {
+				case "file", "dir":
+					spec.WriteString(" -r -F")
+				case "":
+					spec.WriteString(" -x")
+				default:
+					spec.WriteString(fmt.Sprintf(" -x -a '%s'", escapeFish(option.Complete)))
+				}
+			}
+			spec.WriteString(fmt.Sprintf(" -d '%s'", escapeFish(option.Desc)))
+			fmt.Fprintln(writer, spec.String())
+		}
+
+		if words := getCommandArgWords(cmd); words != "" {
+			fmt.Fprintf(writer, "complete -c %s -n '%s' -a '%s'\n", programName, condition, words)
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedWordKeys(words map[string]string) []string {
+	keys := make([]string, 0, len(words))
+	for key := range words {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+//loadDefaults sets the options not given on the command line from the environment variables and then from the config file.
+//The precedence is command line > environment variables > config profile section > config global section.
+func loadDefaults(parsed *parsedArgs) error {
+	configurableOptions := make([]string, 0)
+	for _, option := range parsed.command.Options {
+		if !option.NoConfig {
+			configurableOptions = append(configurableOptions, option.Long)
+		}
+	}
+
+	//options selecting the config file can only come from the command line (or) environment variables
+	for _, option := range []*cliOption{configOption, ignoreConfigOption, profileOption} {
+		if value, isSet := os.LookupEnv(utils.GetEnvName(option.Long)); isSet && !parsed.isSet(option.Long) {
+			if err := parsed.set(option, value); err != nil {
+				return fmt.Errorf("%s: %s", utils.GetEnvName(option.Long), err)
+			}
+		}
+	}
+
+	if err := parsed.setDefaults(utils.GetEnvOptions(configurableOptions), "environment"); err != nil {
+		return err
+	}
+
+	if parsed.boolean(ignoreConfigOption.Long) {
+		return nil
+	}
+
+	configPath := parsed.str(configOption.Long)
+	if configPath == "" {
+		defaultConfigPath, err := utils.GetDefaultConfigPath()
+		if err != nil || !isFileExists(defaultConfigPath) {
+			if parsed.str(profileOption.Long) != "" {
+				return fmt.Errorf("profile %s given but no config file found", parsed.str(profileOption.Long))
+			}
+			return nil
+		}
+		configPath = defaultConfigPath
+	}
+
+	config, err := utils.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("Error in loading config file: %s", err)
+	}
+
+	configValues, err := config.Options(parsed.str(profileOption.Long))
+	if err != nil {
+		return err
+	}
+
+	return parsed.setDefaults(configValues, configPath)
+}
+
+func isFileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
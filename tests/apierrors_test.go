@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestGetPlaybackSets_ClassifiedErrors(t *testing.T) {
+	testCases := map[string]utils.ErrorKind{
+		`{"message":"Content is not available in your region"}`:                        utils.ErrorKindGeoRestricted,
+		`{"message":"Please login to watch this content"}`:                             utils.ErrorKindLoginRequired,
+		`{"message":"Upgrade to Premium to watch","errorCode":"ERR_PB_1412"}`:          utils.ErrorKindSubscriptionRequired,
+		`{"message":"Playback failed","errorCode":"ERR_DRM_LICENSE"}`:                  utils.ErrorKindDRMProtected,
+		`{"message":"This content is no longer available"}`:                            utils.ErrorKindContentRemoved,
+		`{"message":"This live stream has not started yet","errorCode":"ERR_PB_1409"}`: utils.ErrorKindNotStarted,
+		`{"message":"Too many requests, slow down","errorCode":"ERR_PB_RATE"}`:         utils.ErrorKindRateLimited,
+		`{"message":"Something went wrong on our side","errorCode":"ERR_PB_INTERNAL"}`: "",
+	}
+
+	for playbackURIContent, expectedKind := range testCases {
+		_, err := utils.GetPlaybackSets([]byte(playbackURIContent))
+
+		if err == nil || utils.GetErrorKind(err) != expectedKind {
+			t.Error("Expected error of kind", expectedKind, "for", playbackURIContent, "but got", err)
+		}
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+	_, err := utils.GetPlaybackSets([]byte(`{"message":"Content is not available in your country"}`))
+	wrappedErr := fmt.Errorf("Error in retrieving formats: %w", err)
+
+	if !errors.Is(wrappedErr, utils.ErrGeoRestricted) || errors.Is(wrappedErr, utils.ErrLoginRequired) {
+		t.Error("Expected a geo-restricted error but got", wrappedErr)
+	}
+
+	if kind := utils.GetErrorKind(wrappedErr); kind != utils.ErrorKindGeoRestricted {
+		t.Error("Expected", utils.ErrorKindGeoRestricted, "but got", kind)
+	}
+}
+
+func TestParseErrorKinds(t *testing.T) {
+	expectedKinds := []utils.ErrorKind{utils.ErrorKindGeoRestricted, utils.ErrorKindDRMProtected}
+
+	actualKinds, err := utils.ParseErrorKinds(" geo-restricted, DRM-Protected,")
+
+	if err != nil || !reflect.DeepEqual(expectedKinds, actualKinds) {
+		t.Error("Expected", expectedKinds, "but got", actualKinds, err)
+	}
+
+	if actualKinds, err := utils.ParseErrorKinds("geo-restricted,unknown"); err == nil {
+		t.Error("Expected error but got", actualKinds)
+	}
+}
+
+func TestJobSummary_Skipped(t *testing.T) {
+	summary := &utils.JobSummary{
+		Jobs: []*utils.Job{
+			{URL: "https://www.hotstar.com/in/movies/kabir-singh/1260009870"},
+			{URL: "https://www.hotstar.com/in/movies/frozen/1260001234", Err: utils.ErrDRMProtected, Skipped: true},
+			{URL: "https://www.hotstar.com/in/movies/avengers-endgame/1260012345", Err: fmt.Errorf("Error: %w", utils.ErrSubscriptionRequired)},
+		},
+	}
+
+	expectedSummary := "\nSummary: 3 job(s), 1 succeeded, 1 failed, 1 skipped\n" +
+		"  FAILED https://www.hotstar.com/in/movies/avengers-endgame/1260012345 [subscription-required]\n" +
+		"    Error: The content requires a Hotstar subscription (or) a higher plan\n" +
+		"  SKIPPED https://www.hotstar.com/in/movies/frozen/1260001234 [drm-protected]\n"
+
+	if actualSummary := summary.String(); expectedSummary != actualSummary {
+		t.Error("Expected", expectedSummary, "but got", actualSummary)
+	}
+}
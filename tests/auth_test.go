@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+const testUserToken = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJ1bV9hY2Nlc3MiLCJleHAiOjE1OTAzMDE5OTYsImlhdCI6MTU4OTY5NzE5NiwiaXNzIjoiVFMiLCJzdWIiOiJ7XCJoSWRcIjpcIjU5YjkxMTBkMDM2ZjQ3M2U5OTBhNzFiNDAwMDM5MzRkXCIsXCJwSWRcIjpcImVjNmRmY2Y1ZTJhYzRhYWJhZjNjOTBlY2I0YTY5MTVlXCIsXCJuYW1lXCI6XCJHdWVzdCBVc2VyXCIsXCJpcFwiOlwiMjIzLjIyNi4yOS4yMjdcIixcImNvdW50cnlDb2RlXCI6XCJpblwiLFwiY3VzdG9tZXJUeXBlXCI6XCJudVwiLFwidHlwZVwiOlwiZGV2aWNlXCIsXCJpc0VtYWlsVmVyaWZpZWRcIjpmYWxzZSxcImlzUGhvbmVWZXJpZmllZFwiOmZhbHNlLFwiZGV2aWNlSWRcIjpcImExMTg1MTFhLTJmYjktNDhmOS04MGM5LWY1OTlkMjdlYTZmNlwiLFwicHJvZmlsZVwiOlwiQURVTFRcIixcInZlcnNpb25cIjpcInYyXCIsXCJzdWJzY3JpcHRpb25zXCI6e1wiaW5cIjp7fX0sXCJpc3N1ZWRBdFwiOjE1ODk2OTcxOTY2NzJ9IiwidmVyc2lvbiI6IjFfMCJ9.bkx7DodQSFohwmzqf8RmKOr3tuORgVFEh_qbtdqzeVA"
+
+func TestGetJWTExpiry(t *testing.T) {
+	expectedExpiry := time.Unix(1590301996, 0)
+	actualExpiry, err := utils.GetJWTExpiry(testUserToken)
+
+	if err != nil || !expectedExpiry.Equal(actualExpiry) {
+		t.Error("Expected", expectedExpiry, "but got", actualExpiry, err)
+	}
+}
+
+func TestGetJWTExpiry_InvalidToken(t *testing.T) {
+	for _, token := range []string{"", "not-a-jwt", "a.!!!.c", "eyJhbGciOiJIUzI1NiJ9.eyJhdWQiOiJ1bV9hY2Nlc3MifQ.sig"} {
+		if expiry, err := utils.GetJWTExpiry(token); err == nil {
+			t.Error("Expected error for", token, "but got", expiry)
+		}
+	}
+}
+
+func TestCredentials_IsExpiring(t *testing.T) {
+	credentials, err := utils.NewCredentials(testUserToken, "a118511a-2fb9-48f9-80c9-f599d27ea6f6")
+
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	if !credentials.IsExpiring(0) {
+		t.Error("Expected token expired on", credentials.Expiry, "to be expiring")
+	}
+
+	if credentials.DeviceID != "a118511a-2fb9-48f9-80c9-f599d27ea6f6" {
+		t.Error("Expected a118511a-2fb9-48f9-80c9-f599d27ea6f6 but got", credentials.DeviceID)
+	}
+}
+
+func TestGetCredentialsFromCookies(t *testing.T) {
+	cookiesContents := "# Netscape HTTP Cookie File\n" +
+		".google.com\tTRUE\t/\tTRUE\t1924905600\tuserUP\tnot-this-one\n" +
+		"#HttpOnly_.hotstar.com\tTRUE\t/\tTRUE\t1924905600\tuserUP\t" + testUserToken + "\n" +
+		"www.hotstar.com\tFALSE\t/\tFALSE\t1924905600\tdeviceId\ta118511a-2fb9-48f9-80c9-f599d27ea6f6\n"
+
+	cookies, err := utils.ParseNetscapeCookies(strings.NewReader(cookiesContents))
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	if len(cookies) != 3 {
+		t.Fatal("Expected 3 cookies but got", len(cookies))
+	}
+
+	credentials, err := utils.GetCredentialsFromCookies(cookies)
+
+	if err != nil || credentials.UserToken != testUserToken || credentials.DeviceID != "a118511a-2fb9-48f9-80c9-f599d27ea6f6" {
+		t.Error("Expected credentials from hotstar.com cookies but got", credentials, err)
+	}
+}
+
+func TestGetCredentialsFromCookies_NoUserToken(t *testing.T) {
+	cookies, _ := utils.ParseNetscapeCookies(strings.NewReader(".hotstar.com\tTRUE\t/\tTRUE\t1924905600\tdeviceId\tabc\n"))
+
+	if credentials, err := utils.GetCredentialsFromCookies(cookies); err == nil {
+		t.Error("Expected error but got", credentials)
+	}
+}
+
+func TestParseNetscapeCookies_InvalidLine(t *testing.T) {
+	if cookies, err := utils.ParseNetscapeCookies(strings.NewReader(".hotstar.com TRUE / TRUE 0 userUP abc\n")); err == nil {
+		t.Error("Expected error but got", cookies)
+	}
+}
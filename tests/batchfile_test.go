@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestReadBatchURLs(t *testing.T) {
+	batchContents := "\ufeff# season 1\n" +
+		"https://www.hotstar.com/tv/chinnathambi/15301/chinnathambi-yearns-for-nandini/1100003795\n" +
+		"\n" +
+		"; skipped for now\n" +
+		"  https://www.hotstar.com/in/movies/kabir-singh/1260009870  \r\n" +
+		"] another comment\n"
+
+	expectedURLs := []string{
+		"https://www.hotstar.com/tv/chinnathambi/15301/chinnathambi-yearns-for-nandini/1100003795",
+		"https://www.hotstar.com/in/movies/kabir-singh/1260009870",
+	}
+
+	actualURLs, err := utils.ReadBatchURLs(strings.NewReader(batchContents))
+
+	if err != nil || !reflect.DeepEqual(expectedURLs, actualURLs) {
+		t.Error("Expected", expectedURLs, "but got", actualURLs, err)
+	}
+}
+
+func TestSelectPlaylistItems(t *testing.T) {
+	playlistItems := []map[string]string{{"id": "4"}, {"id": "3"}, {"id": "2"}, {"id": "1"}}
+	expectedItems := []map[string]string{{"id": "2"}, {"id": "3"}}
+
+	actualItems, err := utils.SelectPlaylistItems(playlistItems, "2", "3")
+
+	if err != nil || !reflect.DeepEqual(expectedItems, actualItems) {
+		t.Error("Expected", expectedItems, "but got", actualItems, err)
+	}
+}
+
+func TestSelectPlaylistItems_InvalidRange(t *testing.T) {
+	playlistItems := []map[string]string{{"id": "2"}, {"id": "1"}}
+
+	if actualItems, err := utils.SelectPlaylistItems(playlistItems, "0", "5"); err == nil {
+		t.Error("Expected error but got", actualItems)
+	}
+}
+
+func TestJobSummary(t *testing.T) {
+	summary := &utils.JobSummary{
+		Jobs: []*utils.Job{
+			{URL: "https://www.hotstar.com/in/movies/kabir-singh/1260009870"},
+			{URL: "https://www.hotstar.com/in/movies/frozen/1260001234", Err: errors.New("The video is DRM Protected")},
+		},
+	}
+
+	expectedSummary := "\nSummary: 2 job(s), 1 succeeded, 1 failed\n" +
+		"  FAILED https://www.hotstar.com/in/movies/frozen/1260001234\n" +
+		"    The video is DRM Protected\n"
+
+	if actualSummary := summary.String(); expectedSummary != actualSummary {
+		t.Error("Expected", expectedSummary, "but got", actualSummary)
+	}
+}
+
+func TestGetJobOutputFileName(t *testing.T) {
+	tests := []struct {
+		outputFileName string
+		want           string
+	}{
+		{outputFileName: "movie.mp4", want: "movie-1000012345.mp4"},
+		{outputFileName: "videos/movie.mkv", want: "videos/movie-1000012345.mkv"},
+		{outputFileName: "movie", want: "movie-1000012345"},
+		{outputFileName: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := utils.GetJobOutputFileName(tt.outputFileName, "1000012345"); got != tt.want {
+			t.Errorf("GetJobOutputFileName(%q) = %q, want %q", tt.outputFileName, got, tt.want)
+		}
+	}
+}
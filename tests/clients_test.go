@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestGetPlaybackURI2_DefaultClient(t *testing.T) {
+	utils.SetClientProfile(nil)
+
+	expectedPlaybackURI := "https://api.hotstar.com/h/v2/play/in/contents/1100025368?desiredConfig=encryption:plain;ladder:phone,tv;package:hls,dash&client=mweb&clientVersion=6.18.0&deviceId=79272307-fa98-4b08-8f5c-5afdde2687ff&osName=Windows&osVersion=10"
+	actualPlaybackURI := utils.GetPlaybackURI2("1100025368", "79272307-fa98-4b08-8f5c-5afdde2687ff")
+
+	if expectedPlaybackURI != actualPlaybackURI {
+		t.Error("Expected", expectedPlaybackURI, "but got", actualPlaybackURI)
+	}
+}
+
+func TestGetPlaybackURI3_TVClient(t *testing.T) {
+	clientProfile, err := utils.GetClientProfile("TV")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	utils.SetClientProfile(clientProfile)
+	defer utils.SetClientProfile(nil)
+
+	expectedPlaybackURI := "https://api.hotstar.com/play/v1/playback/content/1100025368?device-id=79272307-fa98-4b08-8f5c-5afdde2687ff&desired-config=encryption:plain;ladder:tv;package:hls,dash&os-name=Android&os-version=9"
+	actualPlaybackURI := utils.GetPlaybackURI3("1100025368", "79272307-fa98-4b08-8f5c-5afdde2687ff")
+
+	if expectedPlaybackURI != actualPlaybackURI {
+		t.Error("Expected", expectedPlaybackURI, "but got", actualPlaybackURI)
+	}
+}
+
+func TestGetClientProfile_CopyIsIndependent(t *testing.T) {
+	clientProfile, _ := utils.GetClientProfile("android")
+	clientProfile.Headers["User-Agent"] = "custom"
+
+	if otherProfile, _ := utils.GetClientProfile("android"); otherProfile.Headers["User-Agent"] == "custom" {
+		t.Error("Expected changes to the copy not to affect the registered profile")
+	}
+}
+
+func TestGetClientProfile_Invalid(t *testing.T) {
+	if clientProfile, err := utils.GetClientProfile("smarttv"); err == nil || !strings.Contains(err.Error(), "web") {
+		t.Error("Expected error listing the valid clients but got", clientProfile, err)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	if name, value, err := utils.ParseHeader("X-Forwarded-For: 1.2.3.4"); err != nil || name != "X-Forwarded-For" || value != "1.2.3.4" {
+		t.Error("Expected X-Forwarded-For 1.2.3.4 but got", name, value, err)
+	}
+
+	if name, _, err := utils.ParseHeader("x-country-code: US"); err != nil || name != "X-Country-Code" {
+		t.Error("Expected the canonical name X-Country-Code but got", name, err)
+	}
+
+	for _, header := range []string{"no-separator", ": value"} {
+		if _, _, err := utils.ParseHeader(header); err == nil {
+			t.Error("Expected error for", header)
+		}
+	}
+}
+
+func TestClientProfile_SetHeader(t *testing.T) {
+	clientProfile, _ := utils.GetClientProfile("web")
+	clientProfile.SetHeader("x-hs-platform", "mweb")
+	clientProfile.SetHeader("x-country-code", "US")
+
+	platformHeaders := 0
+	for headerName, headerValue := range clientProfile.Headers {
+		if strings.EqualFold(headerName, "X-HS-Platform") {
+			platformHeaders++
+			if headerValue != "mweb" {
+				t.Error("Expected the header set to replace the one of the profile but got", headerValue)
+			}
+		}
+	}
+	if platformHeaders != 1 || clientProfile.Headers["X-Country-Code"] != "US" {
+		t.Error("Expected a single header of each name but got", clientProfile.Headers)
+	}
+}
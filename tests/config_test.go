@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+const testConfigContents = `# defaults for every run
+ffmpeg-location = /usr/local/bin/ffmpeg
+format = hls-1472
+add-metadata
+
+[archive]
+--format dash-video-1500
+output = "Season 1.mp4"
+
+; quick checks
+[preview]
+format = hls-167
+`
+
+func TestParseConfig_GlobalOptions(t *testing.T) {
+	config, err := utils.ParseConfig(strings.NewReader(testConfigContents))
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	expectedOptions := map[string][]string{
+		"ffmpeg-location": {"/usr/local/bin/ffmpeg"},
+		"format":          {"hls-1472"},
+		"add-metadata":    {"true"},
+	}
+
+	actualOptions, err := config.Options("")
+
+	if err != nil || !reflect.DeepEqual(expectedOptions, actualOptions) {
+		t.Error("Expected", expectedOptions, "but got", actualOptions, err)
+	}
+}
+
+func TestParseConfig_ProfileOptions(t *testing.T) {
+	config, err := utils.ParseConfig(strings.NewReader(testConfigContents))
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	expectedOptions := map[string][]string{
+		"ffmpeg-location": {"/usr/local/bin/ffmpeg"},
+		"format":          {"dash-video-1500"},
+		"add-metadata":    {"true"},
+		"output":          {"Season 1.mp4"},
+	}
+
+	actualOptions, err := config.Options("archive")
+
+	if err != nil || !reflect.DeepEqual(expectedOptions, actualOptions) {
+		t.Error("Expected", expectedOptions, "but got", actualOptions, err)
+	}
+
+	if expectedProfiles, actualProfiles := []string{"archive", "preview"}, config.Profiles(); !reflect.DeepEqual(expectedProfiles, actualProfiles) {
+		t.Error("Expected", expectedProfiles, "but got", actualProfiles)
+	}
+
+	if _, err := config.Options("missing"); err == nil {
+		t.Error("Expected error for missing profile but got none")
+	}
+}
+
+func TestParseConfig_InvalidSection(t *testing.T) {
+	if _, err := utils.ParseConfig(strings.NewReader("[archive\nformat = hls-167\n")); err == nil {
+		t.Error("Expected error for invalid section but got none")
+	}
+}
+
+func TestGetEnvOptions(t *testing.T) {
+	os.Setenv("HOTSTAR_DL_ADD_METADATA", "1")
+	defer os.Unsetenv("HOTSTAR_DL_ADD_METADATA")
+
+	expectedOptions := map[string][]string{"add-metadata": {"1"}}
+	actualOptions := utils.GetEnvOptions([]string{"add-metadata", "format"})
+
+	if !reflect.DeepEqual(expectedOptions, actualOptions) {
+		t.Error("Expected", expectedOptions, "but got", actualOptions)
+	}
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestParseDesiredConfig(t *testing.T) {
+	config, err := utils.ParseDesiredConfig("encryption:plain;ladder:phone,tv;package:hls,dash")
+	expectedConfig := utils.DesiredConfig{
+		"encryption": {"plain"},
+		"ladder":     {"phone", "tv"},
+		"package":    {"hls", "dash"},
+	}
+
+	if err != nil || !reflect.DeepEqual(expectedConfig, config) {
+		t.Error("Expected", expectedConfig, "but got", config, err)
+	}
+
+	if _, err := utils.ParseDesiredConfig("encryption:plain;ladder"); err == nil {
+		t.Error("Expected error for dimension without values")
+	}
+}
+
+func TestDesiredConfig_Set(t *testing.T) {
+	config, _ := utils.ParseDesiredConfig("encryption:plain;ladder:phone,tv;package:hls,dash")
+
+	if err := config.Set(utils.DimensionVideoCodec, []string{"H265", "h264", "h265"}); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	expectedConfig := "encryption:plain;ladder:phone,tv;package:hls,dash;video_codec:h265,h264"
+	if actualConfig := config.String(); expectedConfig != actualConfig {
+		t.Error("Expected", expectedConfig, "but got", actualConfig)
+	}
+
+	if err := config.Set(utils.DimensionAudioCodec, []string{"mp3"}); err == nil {
+		t.Error("Expected error for unknown audio codec")
+	}
+}
+
+func TestDesiredConfig_Split(t *testing.T) {
+	config, _ := utils.ParseDesiredConfig("audio_codec:aac,ec3;dynamic_range:sdr,hdr10;encryption:plain;video_codec:h264,h265")
+
+	expectedConfigs := []string{
+		"audio_codec:aac,ec3;dynamic_range:sdr;encryption:plain;video_codec:h264",
+		"audio_codec:aac,ec3;dynamic_range:hdr10;encryption:plain;video_codec:h264",
+		"audio_codec:aac,ec3;dynamic_range:sdr;encryption:plain;video_codec:h265",
+		"audio_codec:aac,ec3;dynamic_range:hdr10;encryption:plain;video_codec:h265",
+	}
+
+	actualConfigs := make([]string, 0)
+	for _, splitConfig := range config.Split() {
+		actualConfigs = append(actualConfigs, splitConfig.String())
+	}
+
+	if !reflect.DeepEqual(expectedConfigs, actualConfigs) {
+		t.Error("Expected", expectedConfigs, "but got", actualConfigs)
+	}
+}
@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+//dashSegment wraps the payload in the boxes of a DASH segment, those of an init segment when the payload names one
+func dashSegment(payload string) string {
+	if strings.Contains(payload, "init") {
+		return string(mp4Box("ftyp", []byte(payload))) + string(mp4Box("moov"))
+	}
+	return string(mp4Box("moof")) + string(mp4Box("mdat", []byte(payload)))
+}
+
+func TestGetSignedURLExpiry(t *testing.T) {
+	expiry, isSigned := utils.GetSignedURLExpiry("https://hses4.hotstar.com/videos/a/master.mpd?hdnea=st=1592120257~exp=1592120857~acl=/videos/a/*~hmac=f575")
+
+	if !isSigned || !expiry.Equal(time.Unix(1592120857, 0)) {
+		t.Error("Expected", time.Unix(1592120857, 0), "but got", expiry, isSigned)
+	}
+
+	if expiry, isSigned := utils.GetSignedURLExpiry("https://hses4.hotstar.com/videos/a/master.mpd"); isSigned {
+		t.Error("Expected unsigned url but got expiry", expiry)
+	}
+}
+
+func TestDownloadDashFilesBatch_RefreshesExpiredURLs(t *testing.T) {
+	//the old token is rejected from the third chunk on
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") == "old" && !strings.HasSuffix(r.URL.Path, "init.mp4") && !strings.HasSuffix(r.URL.Path, "/1.m4s") && !strings.HasSuffix(r.URL.Path, "/2.m4s") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, dashSegment(filepath.Base(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	newFormat := func(token string) map[string]string {
+		return map[string]string{
+			"INIT-URL":       "video/init.mp4",
+			"STREAM-URL":     "video/$Number$.m4s",
+			"TOTAL-SEGMENTS": "4",
+			"PLAYBACK-URL":   server.URL + "/content/master.mpd?token=" + token,
+		}
+	}
+
+	refreshCount := 0
+	refreshFormats := func() (map[string]map[string]string, error) {
+		refreshCount++
+		return map[string]map[string]string{"dash-video-1500": newFormat("new")}, nil
+	}
+
+	workingDir, err := ioutil.TempDir("", "hotstar-dl-dash")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	previousDir, _ := os.Getwd()
+	os.Chdir(workingDir)
+	defer os.Chdir(previousDir)
+
+	dashFiles, _, err := utils.DownloadDashFilesBatch(workingDir, "1100036989", "dash-video-1500", []map[string]string{newFormat("old")}, map[string]string{}, refreshFormats)
+
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	if refreshCount != 1 {
+		t.Error("Expected 1 refresh but got", refreshCount)
+	}
+
+	if len(dashFiles) != 5 {
+		t.Fatal("Expected 5 chunks but got", dashFiles)
+	}
+
+	for _, dashFile := range dashFiles {
+		if contents, err := ioutil.ReadFile(dashFile); err != nil || string(contents) != dashSegment(filepath.Base(dashFile)) {
+			t.Error("Expected chunk", filepath.Base(dashFile), "but got", string(contents), err)
+		}
+	}
+}
+
+func TestDownloadDashStream_InOrder(t *testing.T) {
+	//the earlier chunks take longer, so the concurrent downloads complete out of order and the old token is rejected from the sixth chunk on
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var segmentNum int
+		fmt.Sscanf(filepath.Base(r.URL.Path), "%d.m4s", &segmentNum)
+		if r.URL.Query().Get("token") == "old" && segmentNum > 5 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		time.Sleep(time.Duration(20-segmentNum) * time.Millisecond)
+		fmt.Fprint(w, dashSegment(fmt.Sprintf("[%s]", filepath.Base(r.URL.Path))))
+	}))
+	defer server.Close()
+
+	newFormat := func(token string) map[string]string {
+		return map[string]string{
+			"INIT-URL":       "video/init.mp4",
+			"STREAM-URL":     "video/$Number$.m4s",
+			"TOTAL-SEGMENTS": "12",
+			"PLAYBACK-URL":   server.URL + "/content/master.mpd?token=" + token,
+		}
+	}
+
+	var refreshMutex sync.Mutex
+	refreshCount := 0
+	refreshFormats := func() (map[string]map[string]string, error) {
+		refreshMutex.Lock()
+		defer refreshMutex.Unlock()
+		refreshCount++
+		return map[string]map[string]string{"dash-video-1500": newFormat("new")}, nil
+	}
+
+	workingDir, err := ioutil.TempDir("", "hotstar-dl-dash")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	utils.SetConcurrentFragments(4)
+	defer utils.SetConcurrentFragments(1)
+
+	filePath := filepath.Join(workingDir, "video.mp4.dash-video-1500.part")
+	if err := utils.DownloadDashStream(filePath, "dash-video-1500", []map[string]string{newFormat("old")}, map[string]string{}, refreshFormats); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	if refreshCount != 1 {
+		t.Error("Expected 1 refresh but got", refreshCount)
+	}
+
+	expectedContents := dashSegment("[init.mp4]")
+	for segmentNum := 1; segmentNum <= 12; segmentNum++ {
+		expectedContents += dashSegment(fmt.Sprintf("[%d.m4s]", segmentNum))
+	}
+	if contents, err := ioutil.ReadFile(filePath); err != nil || string(contents) != expectedContents {
+		t.Error("Expected", expectedContents, "but got", string(contents), err)
+	}
+
+	if files, _ := ioutil.ReadDir(workingDir); len(files) != 1 {
+		t.Error("Expected only the streamed file but got", len(files), "files")
+	}
+}
+
+func TestDownloadDashStream_FailedChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/3.m4s") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, dashSegment(fmt.Sprintf("[%s]", filepath.Base(r.URL.Path))))
+	}))
+	defer server.Close()
+
+	format := map[string]string{
+		"INIT-URL":       "video/init.mp4",
+		"STREAM-URL":     "video/$Number$.m4s",
+		"TOTAL-SEGMENTS": "6",
+		"PLAYBACK-URL":   server.URL + "/content/master.mpd",
+	}
+
+	workingDir, err := ioutil.TempDir("", "hotstar-dl-dash")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	utils.SetConcurrentFragments(3)
+	defer utils.SetConcurrentFragments(1)
+
+	filePath := filepath.Join(workingDir, "video.part")
+	err = utils.DownloadDashStream(filePath, "dash-video-1500", []map[string]string{format}, map[string]string{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "segment 3") {
+		t.Fatal("Expected error in downloading segment 3 but got", err)
+	}
+
+	//the chunks before the failed one are kept in order
+	if contents, _ := ioutil.ReadFile(filePath); string(contents) != dashSegment("[init.mp4]")+dashSegment("[1.m4s]")+dashSegment("[2.m4s]") {
+		t.Error("Expected the chunks before the failed one but got", string(contents))
+	}
+}
+
+func TestDownloadDashStream_RetriesCorruptChunk(t *testing.T) {
+	//the second chunk is cut short once, then served whole
+	var mutex sync.Mutex
+	isCutShort := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segment := dashSegment(fmt.Sprintf("[%s]", filepath.Base(r.URL.Path)))
+		mutex.Lock()
+		defer mutex.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/2.m4s") && !isCutShort {
+			isCutShort = true
+			segment = segment[:len(segment)-3]
+		}
+		fmt.Fprint(w, segment)
+	}))
+	defer server.Close()
+
+	format := map[string]string{
+		"INIT-URL":       "video/init.mp4",
+		"STREAM-URL":     "video/$Number$.m4s",
+		"TOTAL-SEGMENTS": "3",
+		"PLAYBACK-URL":   server.URL + "/content/master.mpd",
+	}
+
+	workingDir, err := ioutil.TempDir("", "hotstar-dl-dash")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	filePath := filepath.Join(workingDir, "video.part")
+	if err := utils.DownloadDashStream(filePath, "dash-video-1500", []map[string]string{format}, map[string]string{}, nil); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	expectedContents := dashSegment("[init.mp4]") + dashSegment("[1.m4s]") + dashSegment("[2.m4s]") + dashSegment("[3.m4s]")
+	if contents, _ := ioutil.ReadFile(filePath); string(contents) != expectedContents || !isCutShort {
+		t.Error("Expected the chunk cut short to be downloaded again but got", string(contents))
+	}
+}
+
+func TestDownloadDashFilesBatch_CorruptChunk(t *testing.T) {
+	//the CDN keeps answering the second chunk with an error page
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/2.m4s") {
+			fmt.Fprint(w, "<html><body>Access Denied</body></html>")
+			return
+		}
+		fmt.Fprint(w, dashSegment(filepath.Base(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	format := map[string]string{
+		"INIT-URL":       "video/init.mp4",
+		"STREAM-URL":     "video/$Number$.m4s",
+		"TOTAL-SEGMENTS": "3",
+		"PLAYBACK-URL":   server.URL + "/content/master.mpd",
+	}
+
+	workingDir, err := ioutil.TempDir("", "hotstar-dl-dash")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	_, tempDir, err := utils.DownloadDashFilesBatch(workingDir, "1100036989", "dash-video-1500", []map[string]string{format}, map[string]string{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "segment 2") || !strings.Contains(err.Error(), utils.ErrCorruptSegment.Error()) {
+		t.Fatal("Expected the corrupt segment 2 but got", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "2.m4s")); !os.IsNotExist(err) {
+		t.Error("Expected the error page not to be saved as the chunk but got", err)
+	}
+}
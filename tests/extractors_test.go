@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func assertContentRef(t *testing.T, url string, expectedExtractor string, expectedContentType string, expectedContentID string, expectedPlaylistID string) {
+	contentRef, err := utils.ExtractContentRef(url)
+
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	if expectedExtractor != contentRef.Extractor {
+		t.Error("Expected", expectedExtractor, "but got", contentRef.Extractor)
+	}
+
+	if expectedContentType != contentRef.ContentType {
+		t.Error("Expected", expectedContentType, "but got", contentRef.ContentType)
+	}
+
+	if expectedContentID != contentRef.ContentID {
+		t.Error("Expected", expectedContentID, "but got", contentRef.ContentID)
+	}
+
+	if expectedPlaylistID != contentRef.PlaylistID {
+		t.Error("Expected", expectedPlaylistID, "but got", contentRef.PlaylistID)
+	}
+}
+
+func TestExtractContentRef_TVEpisode(t *testing.T) {
+	assertContentRef(t, "https://www.hotstar.com/tv/chinnathambi/15301/chinnathambi-yearns-for-nandini/1100003795", "hotstar:tv", utils.ContentTypeEpisode, "1100003795", "")
+}
+
+func TestExtractContentRef_RegionalTVEpisode(t *testing.T) {
+	assertContentRef(t, "https://www.hotstar.com/in/tv/anupamaa/1260022017/anupamaa-vows-revenge/1100123456", "hotstar:tv", utils.ContentTypeEpisode, "1100123456", "")
+}
+
+func TestExtractContentRef_Movie(t *testing.T) {
+	assertContentRef(t, "https://www.hotstar.com/in/movies/kabir-singh/1260009870", "hotstar:movie", utils.ContentTypeMovie, "1260009870", "")
+}
+
+func TestExtractContentRef_Sports(t *testing.T) {
+	assertContentRef(t, "https://www.hotstar.com/sports/cricket/india-tour-of-australia/highlights/1540004201?tab=watch", "hotstar:sports", utils.ContentTypeSports, "1540004201", "")
+}
+
+func TestExtractContentRef_News(t *testing.T) {
+	assertContentRef(t, "hotstar.com/in/news/headlines/1260031234", "hotstar:news", utils.ContentTypeNews, "1260031234", "")
+}
+
+func TestExtractContentRef_Clip(t *testing.T) {
+	assertContentRef(t, "https://www.hotstar.com/in/clips/bigg-boss-promo/1100098765", "hotstar:clip", utils.ContentTypeClip, "1100098765", "")
+}
+
+func TestExtractContentRef_DisneyPlusHotstarDomain(t *testing.T) {
+	assertContentRef(t, "https://www.disneyplushotstar.com/id/movies/frozen-ii/1660012345", "hotstar:movie", utils.ContentTypeMovie, "1660012345", "")
+}
+
+func TestExtractContentRef_Playlist(t *testing.T) {
+	assertContentRef(t, "https://www.hotstar.com/tv/ayudha-ezhuthu/s-2213/list/episodes/t-1_2_2213", "hotstar:playlist", utils.ContentTypePlaylist, "", "1_2_2213")
+}
+
+func TestExtractContentRef_InvalidURLs(t *testing.T) {
+	invalidURLs := []string{
+		"http://www.google.com",
+		"http://www.hotstar.com/tv/chinnathambi/15301/chinnathambi-yearns-for-nandini/123",
+		"http://www.hotstar.com/tv/chinnathambi/15301/chinnathambi-yearns-for-nandini/110000379512",
+		"https://www.nothotstar.org/movies/kabir-singh/1260009870",
+	}
+
+	for _, invalidURL := range invalidURLs {
+		if contentRef, err := utils.ExtractContentRef(invalidURL); err == nil {
+			t.Error("Expected error for", invalidURL, "but got", contentRef)
+		}
+	}
+}
+
+func TestGetExtractors_GenericIsLast(t *testing.T) {
+	extractors := utils.GetExtractors()
+
+	if len(extractors) == 0 {
+		t.Fatal("Expected registered extractors but got none")
+	}
+
+	if lastExtractor := extractors[len(extractors)-1].Name(); lastExtractor != "hotstar:generic" {
+		t.Error("Expected hotstar:generic but got", lastExtractor)
+	}
+}
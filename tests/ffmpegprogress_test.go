@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestParseFfmpegProgress(t *testing.T) {
+	output := strings.Join([]string{
+		"frame=0", "total_size=N/A", "out_time_us=N/A", "out_time=-577014:32:22.775808", "speed=N/A", "progress=continue",
+		"frame=120", "total_size=1048576", "out_time_us=4000000", "out_time_ms=4000000", "out_time=00:00:04.000000", "speed=2.01x", "progress=continue",
+		"total_size=2097152", "out_time=00:01:02.500000", "speed=1.5x", "progress=end",
+	}, "\n")
+
+	progresses := make([]utils.FfmpegProgress, 0)
+	if err := utils.ParseFfmpegProgress(strings.NewReader(output), func(progress utils.FfmpegProgress) {
+		progresses = append(progresses, progress)
+	}); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	expectedProgresses := []utils.FfmpegProgress{
+		{OutTime: 0, TotalSize: -1, Speed: 0},
+		{OutTime: 4 * time.Second, TotalSize: 1048576, Speed: 2.01},
+		{OutTime: 62500 * time.Millisecond, TotalSize: 2097152, Speed: 1.5, Done: true},
+	}
+	if !reflect.DeepEqual(expectedProgresses, progresses) {
+		t.Error("Expected", expectedProgresses, "but got", progresses)
+	}
+}
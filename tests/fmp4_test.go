@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+//mp4Box builds a box of the given type holding the given payloads (or) child boxes
+func mp4Box(boxType string, payloads ...[]byte) []byte {
+	payload := bytes.Join(payloads, nil)
+	box := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(box[0:4], uint32(8+len(payload)))
+	copy(box[4:8], boxType)
+	return append(box, payload...)
+}
+
+func uint32Bytes(values ...uint32) []byte {
+	data := make([]byte, 4*len(values))
+	for index, value := range values {
+		binary.BigEndian.PutUint32(data[4*index:], value)
+	}
+	return data
+}
+
+//fullBoxPayload builds the version 0 payload of a full box with the given field at the given offset
+func fullBoxPayload(size int, offset int, value uint32) []byte {
+	payload := make([]byte, size)
+	binary.BigEndian.PutUint32(payload[offset:], value)
+	return payload
+}
+
+func writeInitSegment(t *testing.T, filePath string, trackID uint32, timescale uint32) {
+	mvhd := fullBoxPayload(100, 96, trackID+1)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	init := append(mp4Box("ftyp", []byte("iso6"), uint32Bytes(0), []byte("iso6dash")),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Box("trak",
+				mp4Box("tkhd", fullBoxPayload(80, 12, trackID)),
+				mp4Box("mdia", mp4Box("mdhd", fullBoxPayload(20, 12, timescale)))),
+			mp4Box("mvex", mp4Box("trex", uint32Bytes(0, trackID, 1, 0, 0, 0))))...)
+	if err := ioutil.WriteFile(filePath, init, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeMediaSegment(t *testing.T, filePath string, trackID uint32, sequenceNumber uint32, decodeTime uint32, data string) {
+	segment := append(mp4Box("styp", []byte("msdh"), uint32Bytes(0)),
+		mp4Box("sidx", uint32Bytes(0, trackID, 1000))...)
+	segment = append(segment, mp4Box("moof",
+		mp4Box("mfhd", uint32Bytes(0, sequenceNumber)),
+		mp4Box("traf",
+			mp4Box("tfhd", uint32Bytes(0x020000, trackID)),
+			mp4Box("tfdt", uint32Bytes(0, decodeTime))))...)
+	segment = append(segment, mp4Box("mdat", []byte(data))...)
+	if err := ioutil.WriteFile(filePath, segment, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//readBoxes returns the types and the payloads of the top level boxes in the data
+func readBoxes(t *testing.T, data []byte) ([]string, [][]byte) {
+	boxTypes := make([]string, 0)
+	payloads := make([][]byte, 0)
+	for len(data) != 0 {
+		size := binary.BigEndian.Uint32(data[0:4])
+		if size < 8 || int(size) > len(data) {
+			t.Fatal("Invalid box size", size)
+		}
+		boxTypes = append(boxTypes, string(data[4:8]))
+		payloads = append(payloads, data[8:size])
+		data = data[size:]
+	}
+	return boxTypes, payloads
+}
+
+func findBox(t *testing.T, data []byte, path ...string) []byte {
+	for _, boxType := range path {
+		boxTypes, payloads := readBoxes(t, data)
+		found := false
+		for index := range boxTypes {
+			if boxTypes[index] == boxType {
+				data, found = payloads[index], true
+				break
+			}
+		}
+		if !found {
+			t.Fatal("Expected box", boxType, "of", path)
+		}
+	}
+	return data
+}
+
+func TestMuxFragmentedMP4_AudioAndVideo(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "fmp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	//both tracks are numbered 1 in their own init segments
+	videoFiles := []string{filepath.Join(tempDir, "video-init.mp4"), filepath.Join(tempDir, "video-1.m4s"), filepath.Join(tempDir, "video-2.m4s")}
+	writeInitSegment(t, videoFiles[0], 1, 90000)
+	writeMediaSegment(t, videoFiles[1], 1, 1, 0, "video1")
+	writeMediaSegment(t, videoFiles[2], 1, 2, 180000, "video2")
+
+	audioFiles := []string{filepath.Join(tempDir, "audio-init.mp4"), filepath.Join(tempDir, "audio-1.m4s"), filepath.Join(tempDir, "audio-2.m4s")}
+	writeInitSegment(t, audioFiles[0], 1, 48000)
+	writeMediaSegment(t, audioFiles[1], 1, 1, 0, "audio1")
+	writeMediaSegment(t, audioFiles[2], 1, 2, 48000, "audio2")
+
+	outputFilePath := filepath.Join(tempDir, "output.mp4")
+	if err := utils.MuxFragmentedMP4(outputFilePath, [][]string{videoFiles, audioFiles}, map[string]string{"title": "Kabir Singh", "id": "1260009870"}); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	output, err := ioutil.ReadFile(outputFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boxTypes, payloads := readBoxes(t, output)
+	expectedBoxTypes := []string{"ftyp", "moov", "moof", "mdat", "moof", "mdat", "moof", "mdat", "moof", "mdat"}
+	if !reflect.DeepEqual(expectedBoxTypes, boxTypes) {
+		t.Fatal("Expected", expectedBoxTypes, "but got", boxTypes)
+	}
+
+	moovTypes, moovPayloads := readBoxes(t, payloads[1])
+	expectedMoovTypes := []string{"mvhd", "trak", "trak", "mvex", "udta"}
+	if !reflect.DeepEqual(expectedMoovTypes, moovTypes) {
+		t.Error("Expected", expectedMoovTypes, "but got", moovTypes)
+	}
+	if nextTrackID := binary.BigEndian.Uint32(moovPayloads[0][96:]); nextTrackID != 3 {
+		t.Error("Expected next track id 3 but got", nextTrackID)
+	}
+	for index, expectedTrackID := range []uint32{1, 2} {
+		if trackID := binary.BigEndian.Uint32(findBox(t, moovPayloads[1+index], "tkhd")[12:]); trackID != expectedTrackID {
+			t.Error("Expected track id", expectedTrackID, "but got", trackID)
+		}
+	}
+	if !bytes.Contains(moovPayloads[4], []byte("Kabir Singh")) {
+		t.Error("Expected the title in the metadata")
+	}
+
+	//the fragments are interleaved by their decode time and renumbered
+	expectedFragments := []struct {
+		trackID uint32
+		data    string
+	}{{1, "video1"}, {2, "audio1"}, {2, "audio2"}, {1, "video2"}}
+	for index, expectedFragment := range expectedFragments {
+		moof := payloads[2+2*index]
+		if sequenceNumber := binary.BigEndian.Uint32(findBox(t, moof, "mfhd")[4:]); sequenceNumber != uint32(index+1) {
+			t.Error("Expected sequence number", index+1, "but got", sequenceNumber)
+		}
+		if trackID := binary.BigEndian.Uint32(findBox(t, moof, "traf", "tfhd")[4:]); trackID != expectedFragment.trackID {
+			t.Error("Expected track id", expectedFragment.trackID, "for fragment", index, "but got", trackID)
+		}
+		if data := string(payloads[3+2*index]); data != expectedFragment.data {
+			t.Error("Expected", expectedFragment.data, "but got", data)
+		}
+	}
+}
+
+func TestMuxFragmentedMP4_SingleTrack(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "fmp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := []string{filepath.Join(tempDir, "init.mp4"), filepath.Join(tempDir, "seg-1.m4s"), filepath.Join(tempDir, "seg-2.m4s")}
+	writeInitSegment(t, files[0], 1, 90000)
+	writeMediaSegment(t, files[1], 1, 1, 0, "first")
+	writeMediaSegment(t, files[2], 1, 2, 180000, "second")
+
+	outputFilePath := filepath.Join(tempDir, "output.mp4")
+	if err := utils.MuxFragmentedMP4(outputFilePath, [][]string{files}, nil); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	output, _ := ioutil.ReadFile(outputFilePath)
+	boxTypes, payloads := readBoxes(t, output)
+	expectedBoxTypes := []string{"ftyp", "moov", "moof", "mdat", "moof", "mdat"}
+	if !reflect.DeepEqual(expectedBoxTypes, boxTypes) || string(payloads[3]) != "first" || string(payloads[5]) != "second" {
+		t.Error("Expected", expectedBoxTypes, "but got", boxTypes)
+	}
+}
+
+func TestMuxFragmentedMP4_Unsupported(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "fmp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	//a progressive MP4 without movie extends isn't fragmented
+	initFilePath := filepath.Join(tempDir, "init.mp4")
+	ioutil.WriteFile(initFilePath, mp4Box("moov", mp4Box("mvhd", make([]byte, 100)), mp4Box("trak", mp4Box("tkhd", make([]byte, 80)))), 0644)
+
+	outputFilePath := filepath.Join(tempDir, "output.mp4")
+	if err := utils.MuxFragmentedMP4(outputFilePath, [][]string{{initFilePath}}, nil); !errors.Is(err, utils.ErrUnsupportedFragments) {
+		t.Error("Expected", utils.ErrUnsupportedFragments, "but got", err)
+	}
+	if _, err := os.Stat(outputFilePath); !os.IsNotExist(err) {
+		t.Error("Expected no output file but got", err)
+	}
+}
+
+func TestMuxFragmentedMP4_StreamedTrack(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "fmp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	//the init and media segments streamed one after another into a single file
+	segmentFiles := []string{filepath.Join(tempDir, "init.mp4"), filepath.Join(tempDir, "seg-1.m4s"), filepath.Join(tempDir, "seg-2.m4s")}
+	writeInitSegment(t, segmentFiles[0], 1, 90000)
+	writeMediaSegment(t, segmentFiles[1], 1, 1, 0, "first")
+	writeMediaSegment(t, segmentFiles[2], 1, 2, 180000, "second")
+
+	streamed := make([]byte, 0)
+	for _, segmentFile := range segmentFiles {
+		data, _ := ioutil.ReadFile(segmentFile)
+		streamed = append(streamed, data...)
+	}
+	streamedFilePath := filepath.Join(tempDir, "video.part")
+	ioutil.WriteFile(streamedFilePath, streamed, 0644)
+
+	outputFilePath := filepath.Join(tempDir, "output.mp4")
+	if err := utils.MuxFragmentedMP4(outputFilePath, [][]string{{streamedFilePath}}, nil); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	output, _ := ioutil.ReadFile(outputFilePath)
+	boxTypes, payloads := readBoxes(t, output)
+	expectedBoxTypes := []string{"ftyp", "moov", "moof", "mdat", "moof", "mdat"}
+	if !reflect.DeepEqual(expectedBoxTypes, boxTypes) || string(payloads[3]) != "first" || string(payloads[5]) != "second" {
+		t.Error("Expected", expectedBoxTypes, "but got", boxTypes)
+	}
+	for index := 0; index < 2; index++ {
+		if sequenceNumber := binary.BigEndian.Uint32(findBox(t, payloads[2+2*index], "mfhd")[4:]); sequenceNumber != uint32(index+1) {
+			t.Error("Expected sequence number", index+1, "but got", sequenceNumber)
+		}
+	}
+}
+
+func TestVerifyMP4Segment(t *testing.T) {
+	mediaSegment := bytes.Join([][]byte{mp4Box("styp"), mp4Box("moof", mp4Box("mfhd")), mp4Box("mdat", []byte("data"))}, nil)
+	tests := []struct {
+		name    string
+		data    []byte
+		isInit  bool
+		wantErr bool
+	}{
+		{name: "init segment", data: append(mp4Box("ftyp", []byte("iso6")), mp4Box("moov", mp4Box("mvhd"))...), isInit: true},
+		{name: "media segment", data: mediaSegment},
+		{name: "init segment without moov", data: mp4Box("ftyp", []byte("iso6")), isInit: true, wantErr: true},
+		{name: "media segment without mdat", data: mp4Box("moof"), wantErr: true},
+		{name: "media segment as init", data: mediaSegment, isInit: true, wantErr: true},
+		{name: "truncated", data: mediaSegment[:len(mediaSegment)-2], wantErr: true},
+		{name: "error page", data: []byte("<html><body>403 Forbidden</body></html>"), wantErr: true},
+		{name: "empty", data: []byte{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := utils.VerifyMP4Segment(tt.data, tt.isInit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyMP4Segment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, utils.ErrCorruptSegment) {
+				t.Error("Expected ErrCorruptSegment but got", err)
+			}
+		})
+	}
+}
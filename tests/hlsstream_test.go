@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+//encryptAES128 encrypts the data with AES-128 in CBC mode with PKCS7 padding, like the encrypted HLS segments
+func encryptAES128(data []byte, key []byte, iv []byte) []byte {
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	padded := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	block, _ := aes.NewCipher(key)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+	return encrypted
+}
+
+func getSequenceIV(sequence int) []byte {
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[8:], uint64(sequence))
+	return iv
+}
+
+//encryptedHLSServer serves the playlist with the segments seg10 and seg11 encrypted by key1 with an explicit IV, seg12 by key2 with the
+//IV derived from its media sequence number and seg13 in the clear
+func encryptedHLSServer(playlist string, keyRequests map[string]int, mutex *sync.Mutex) *httptest.Server {
+	keys := map[string][]byte{"key1": []byte("0123456789abcdef"), "key2": []byte("fedcba9876543210")}
+	explicitIV := []byte("ivivivivivivivi!")
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Referer") != "https://www.hotstar.com/in/movies/x/1000" {
+			http.Error(writer, "Missing referer", http.StatusForbidden)
+			return
+		}
+		name := filepath.Base(request.URL.Path)
+		switch {
+		case name == "index.m3u8":
+			fmt.Fprint(writer, playlist)
+		case strings.HasSuffix(name, ".key"):
+			mutex.Lock()
+			keyRequests[name]++
+			mutex.Unlock()
+			writer.Write(keys[strings.TrimSuffix(name, ".key")])
+		case name == "seg10.ts", name == "seg11.ts":
+			writer.Write(encryptAES128([]byte("<"+name+">"), keys["key1"], explicitIV))
+		case name == "seg12.ts":
+			writer.Write(encryptAES128([]byte("<"+name+">"), keys["key2"], getSequenceIV(12)))
+		default:
+			fmt.Fprintf(writer, "<%s>", name)
+		}
+	}))
+}
+
+const encryptedPlaylist = "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:10\n" +
+	"#EXT-X-KEY:METHOD=AES-128,URI=\"keys/key1.key\",IV=0x69766976697669766976697669766921\n#EXTINF:4.0,\nseg10.ts\n#EXTINF:4.0,\nseg11.ts\n" +
+	"#EXT-X-KEY:METHOD=AES-128,URI=\"keys/key2.key\",KEYFORMAT=\"identity\"\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"skd://key2\",KEYFORMAT=\"com.apple.streamingkeydelivery\"\n#EXTINF:4.0,\nseg12.ts\n" +
+	"#EXT-X-KEY:METHOD=NONE\n#EXTINF:4.0,\nseg13.ts\n#EXT-X-ENDLIST\n"
+
+func TestDownloadHLSStream_AES128(t *testing.T) {
+	var mutex sync.Mutex
+	keyRequests := make(map[string]int)
+	server := encryptedHLSServer(encryptedPlaylist, keyRequests, &mutex)
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-hls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	utils.SetConcurrentFragments(3)
+	defer utils.SetConcurrentFragments(1)
+
+	filePath := filepath.Join(tempDir, "video.ts")
+	if err := utils.DownloadHLSStream(filePath, "hls-500", server.URL+"/hls/index.m3u8", map[string]string{"Referer": "https://www.hotstar.com/in/movies/x/1000"}); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	content, _ := ioutil.ReadFile(filePath)
+	if string(content) != "<seg10.ts><seg11.ts><seg12.ts><seg13.ts>" {
+		t.Errorf("Expected the decrypted segments in order but got %q", content)
+	}
+	if keyRequests["key1.key"] != 1 || keyRequests["key2.key"] != 1 {
+		t.Errorf("Expected each key to be downloaded once but got %v", keyRequests)
+	}
+}
+
+func TestRecordLiveHLS_AES128(t *testing.T) {
+	var mutex sync.Mutex
+	server := encryptedHLSServer(encryptedPlaylist, make(map[string]int), &mutex)
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-hls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "live.ts")
+	recordedSegments, err := utils.RecordLiveHLS(filePath, "hls-500", server.URL+"/hls/index.m3u8", map[string]string{"Referer": "https://www.hotstar.com/in/movies/x/1000"}, true, make(chan struct{}))
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	recording, _ := ioutil.ReadFile(filePath)
+	if recordedSegments != 4 || string(recording) != "<seg10.ts><seg11.ts><seg12.ts><seg13.ts>" {
+		t.Errorf("Expected the 4 decrypted segments but got %d segments %q", recordedSegments, recording)
+	}
+}
+
+func TestDownloadHLSStream_WrongKey(t *testing.T) {
+	var mutex sync.Mutex
+	playlist := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:12\n#EXT-X-KEY:METHOD=AES-128,URI=\"key1.key\"\n#EXTINF:4.0,\nseg12.ts\n#EXT-X-ENDLIST\n"
+	server := encryptedHLSServer(playlist, make(map[string]int), &mutex)
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-hls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	err = utils.DownloadHLSStream(filepath.Join(tempDir, "video.ts"), "hls-500", server.URL+"/index.m3u8", map[string]string{"Referer": "https://www.hotstar.com/in/movies/x/1000"})
+	if err == nil || !strings.Contains(err.Error(), "segment 12") {
+		t.Error("Expected error in decrypting the segment 12 with the other key but got", err)
+	}
+}
+
+func TestDownloadHLSStream_DRM(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+	}{
+		{name: "sample-aes", tag: `#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"`},
+		{name: "widevine", tag: `#EXT-X-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed"`},
+		{name: "aes-128 of widevine", tag: `#EXT-X-KEY:METHOD=AES-128,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segmentRequests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				if strings.HasSuffix(request.URL.Path, ".m3u8") {
+					fmt.Fprintf(writer, "#EXTM3U\n#EXT-X-TARGETDURATION:4\n%s\n#EXTINF:4.0,\nseg0.ts\n#EXT-X-ENDLIST\n", tt.tag)
+					return
+				}
+				segmentRequests++
+			}))
+			defer server.Close()
+
+			tempDir, err := ioutil.TempDir("", "hotstar-dl-hls")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			err = utils.DownloadHLSStream(filepath.Join(tempDir, "video.ts"), "hls-500", server.URL+"/index.m3u8", map[string]string{})
+			if !errors.Is(err, utils.ErrDRMProtected) || utils.GetErrorKind(err) != utils.ErrorKindDRMProtected {
+				t.Error("Expected the DRM error but got", err)
+			}
+			if segmentRequests != 0 {
+				t.Errorf("Expected no segments to be downloaded but got %d requests", segmentRequests)
+			}
+		})
+	}
+}
+
+func TestDownloadHLSStream_Retries(t *testing.T) {
+	var mutex sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		name := filepath.Base(request.URL.Path)
+		mutex.Lock()
+		requests[name]++
+		attempt := requests[name]
+		mutex.Unlock()
+		switch {
+		case name == "index.m3u8":
+			fmt.Fprint(writer, "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4.0,\nseg0.ts\n#EXTINF:4.0,\nseg1.ts\n#EXT-X-ENDLIST\n")
+		case name == "seg0.ts" && attempt == 1:
+			http.Error(writer, "Service Unavailable", http.StatusServiceUnavailable)
+		case name == "seg1.ts" && attempt == 1:
+			//the connection drops before the declared length is sent
+			writer.Header().Set("Content-Length", "100")
+			fmt.Fprint(writer, "<seg1")
+		default:
+			fmt.Fprintf(writer, "<%s>", name)
+		}
+	}))
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-hls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "video.ts")
+	if err := utils.DownloadHLSStream(filePath, "hls-500", server.URL+"/index.m3u8", map[string]string{}); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	content, _ := ioutil.ReadFile(filePath)
+	if string(content) != "<seg0.ts><seg1.ts>" || requests["seg0.ts"] != 2 || requests["seg1.ts"] != 2 {
+		t.Errorf("Expected the segments failing once to be downloaded again but got %q after %v", content, requests)
+	}
+}
+
+func TestDownloadHLSStream_NotFound(t *testing.T) {
+	segmentRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, ".m3u8") {
+			fmt.Fprint(writer, "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4.0,\nseg0.ts\n#EXT-X-ENDLIST\n")
+			return
+		}
+		segmentRequests++
+		http.NotFound(writer, request)
+	}))
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-hls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	err = utils.DownloadHLSStream(filepath.Join(tempDir, "video.ts"), "hls-500", server.URL+"/index.m3u8", map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "404") || segmentRequests != 1 {
+		t.Errorf("Expected the segment not found to fail at once but got %v after %d requests", err, segmentRequests)
+	}
+}
+
+func TestDownloadHLSStream_InvalidKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		wantErr string
+	}{
+		{name: "malformed iv", tag: `#EXT-X-KEY:METHOD=AES-128,URI="key1.key",IV=0x1234`, wantErr: "Invalid IV 0x1234"},
+		{name: "no uri", tag: `#EXT-X-KEY:METHOD=AES-128,IV=0x69766976697669766976697669766921`, wantErr: "has no URI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				fmt.Fprintf(writer, "#EXTM3U\n#EXT-X-TARGETDURATION:4\n%s\n#EXTINF:4.0,\nseg0.ts\n#EXT-X-ENDLIST\n", tt.tag)
+			}))
+			defer server.Close()
+
+			tempDir, err := ioutil.TempDir("", "hotstar-dl-hls")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			err = utils.DownloadHLSStream(filepath.Join(tempDir, "video.ts"), "hls-500", server.URL+"/index.m3u8", map[string]string{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error with %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+const liveMPDTemplate = `<?xml version="1.0" ?>
+<MPD type="%s" %s minBufferTime="PT2S" profiles="urn:mpeg:dash:profile:isoff-live:2011" xmlns="urn:mpeg:dash:schema:mpd:2011">
+  <Period>
+    <AdaptationSet mimeType="video/mp4" segmentAlignment="true" startWithSAP="1">
+      <SegmentTemplate duration="1000" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/seg-$Number$.m4s" startNumber="1" timescale="1000"/>
+      <Representation bandwidth="500000" codecs="avc1.4D401E" frameRate="25" height="360" id="video/1" scanType="progressive" width="640"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+
+func getLiveMPD(availabilityStartTime time.Time) string {
+	return fmt.Sprintf(liveMPDTemplate, "dynamic", fmt.Sprintf(`availabilityStartTime="%s" timeShiftBufferDepth="PT5S" minimumUpdatePeriod="PT0.2S"`, availabilityStartTime.UTC().Format(time.RFC3339Nano)))
+}
+
+func TestGetDashFormats_Live(t *testing.T) {
+	availabilityStartTime := time.Date(2020, 5, 1, 14, 0, 0, 0, time.UTC)
+	dashFormats := utils.GetDashFormats([]byte(getLiveMPD(availabilityStartTime)), "https://example.com/live/master.mpd")
+
+	format := dashFormats["video"]["500k"]
+	if format == nil {
+		t.Fatal("Expected the live video format 500k but got", dashFormats)
+	}
+	expectedValues := map[string]string{
+		"LIVE":                    "true",
+		"STREAM":                  "video only, live",
+		"AVAILABILITY-START-TIME": "2020-05-01T14:00:00Z",
+		"TIME-SHIFT-BUFFER-DEPTH": "5",
+		"MINIMUM-UPDATE-PERIOD":   "0.2",
+		"START-NUMBER":            "1",
+		"SEGMENT-DURATION":        "1",
+		"TOTAL-SEGMENTS":          "0",
+	}
+	for key, expectedValue := range expectedValues {
+		if format[key] != expectedValue {
+			t.Errorf("Expected %s %q but got %q", key, expectedValue, format[key])
+		}
+	}
+}
+
+func TestIsLiveM3u8(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "live", content: "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:120\n#EXTINF:4.0,\nseg120.ts\n", want: true},
+		{name: "ended", content: "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4.0,\nseg0.ts\n#EXT-X-ENDLIST\n"},
+		{name: "vod", content: "#EXTM3U\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:4.0,\nseg0.ts\n"},
+		{name: "master", content: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=500000\nmaster_360.m3u8\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.IsLiveM3u8(tt.content); got != tt.want {
+				t.Errorf("IsLiveM3u8() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordLiveHLS(t *testing.T) {
+	//the playlist slides forward a segment on every reload, ending on the third
+	playlists := []string{
+		"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:0\n#EXTINF:1.0,\nseg0.ts\n#EXTINF:1.0,\nseg1.ts\n",
+		"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:0\n#EXTINF:1.0,\nseg0.ts\n#EXTINF:1.0,\nseg1.ts\n#EXTINF:1.0,\nseg2.ts\n",
+		"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:1\n#EXTINF:1.0,\nseg1.ts\n#EXTINF:1.0,\nseg2.ts\n#EXTINF:1.0,\nseg3.ts\n#EXT-X-ENDLIST\n",
+	}
+	var mutex sync.Mutex
+	playlistRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/live/index.m3u8" {
+			fmt.Fprintf(writer, "<%s>", strings.TrimSuffix(filepath.Base(request.URL.Path), ".ts"))
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if playlistRequests >= len(playlists) {
+			playlistRequests = len(playlists) - 1
+		}
+		fmt.Fprint(writer, playlists[playlistRequests])
+		playlistRequests++
+	}))
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "live.ts")
+	recordedSegments, err := utils.RecordLiveHLS(filePath, "hls-500", server.URL+"/live/index.m3u8", map[string]string{}, true, make(chan struct{}))
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	recording, _ := ioutil.ReadFile(filePath)
+	if recordedSegments != 4 || string(recording) != "<seg0><seg1><seg2><seg3>" {
+		t.Errorf("Expected the 4 segments in order but got %d segments %q", recordedSegments, recording)
+	}
+}
+
+func TestRecordLiveHLS_Stopped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprint(writer, "#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXTINF:1.0,\nseg0.ts\n")
+	}))
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	stop := make(chan struct{})
+	close(stop)
+	recordedSegments, err := utils.RecordLiveHLS(filepath.Join(tempDir, "live.ts"), "hls-500", server.URL+"/index.m3u8", map[string]string{}, true, stop)
+	if err != nil || recordedSegments != 0 {
+		t.Errorf("Expected to stop at once but got %d segments, error %v", recordedSegments, err)
+	}
+}
+
+func TestRecordLiveHLS_EndedEmpty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprint(writer, "#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-ENDLIST\n")
+	}))
+	defer server.Close()
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	recordedSegments, err := utils.RecordLiveHLS(filepath.Join(tempDir, "live.ts"), "hls-500", server.URL+"/index.m3u8", map[string]string{}, true, make(chan struct{}))
+	if err != nil || recordedSegments != 0 {
+		t.Errorf("Expected nothing recorded from the ended playlist but got %d segments, error %v", recordedSegments, err)
+	}
+}
+
+func TestRecordLiveDashStream(t *testing.T) {
+	//the stream started 10.5 seconds ago with 1 second segments, keeping the last 5 seconds
+	availabilityStartTime := time.Now().Add(-10500 * time.Millisecond)
+	var mutex sync.Mutex
+	isEnded := false
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case request.URL.Path == "/live/master.mpd" && isEnded:
+			fmt.Fprintf(writer, liveMPDTemplate, "static", `mediaPresentationDuration="PT12.000S"`)
+		case request.URL.Path == "/live/master.mpd":
+			fmt.Fprint(writer, getLiveMPD(availabilityStartTime))
+		default:
+			//the stream ends once the 11th segment is out
+			segmentName := strings.TrimSuffix(filepath.Base(request.URL.Path), ".m4s")
+			isEnded = isEnded || segmentName == "seg-11"
+			fmt.Fprint(writer, dashSegment(fmt.Sprintf("<%s>", segmentName)))
+		}
+	}))
+	defer server.Close()
+
+	playbackURL := server.URL + "/live/master.mpd"
+	format := utils.GetDashFormats([]byte(getLiveMPD(availabilityStartTime)), playbackURL)["video"]["500k"]
+
+	tempDir, err := ioutil.TempDir("", "hotstar-dl-live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "live.mp4")
+	recordedSegments, err := utils.RecordLiveDashStream(filePath, "video-500k", []map[string]string{format}, map[string]string{}, nil, true, make(chan struct{}))
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	recording, _ := ioutil.ReadFile(filePath)
+	expectedRecording := dashSegment("<init.mp4>")
+	for segmentNum := 6; segmentNum <= 12; segmentNum++ {
+		expectedRecording += dashSegment(fmt.Sprintf("<seg-%d>", segmentNum))
+	}
+	if recordedSegments != 7 || string(recording) != expectedRecording {
+		t.Errorf("Expected the segments 6 to 12 from the start of the time shift buffer to the end but got %d segments %q", recordedSegments, recording)
+	}
+}
@@ -143,3 +143,11 @@ func TestParseM3u8Content3(t *testing.T) {
 	}
 
 }
+
+func TestGetM3u8Duration(t *testing.T) {
+	m3u8Content := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.006,\nsegment1.ts\n#EXTINF:6.006,\nsegment2.ts\n#EXTINF:3.5,title\r\nsegment3.ts\n#EXT-X-ENDLIST\n"
+
+	if duration := utils.GetM3u8Duration(m3u8Content); duration < 15.511 || duration > 15.513 {
+		t.Error("Expected duration 15.512 but got", duration)
+	}
+}
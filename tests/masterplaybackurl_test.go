@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Gotham25/hotstar-dl/utils"
@@ -27,7 +29,7 @@ func TestGetMasterPlaybackURL_ValidURLs(t *testing.T) {
 	}
 }
 
-func TestGetMasterPlaybackURL_shouldPanic(t *testing.T) {
+func TestGetMasterPlaybackURL_InvalidResponse(t *testing.T) {
 	playbackURIContent := `<html>
 	<head>
 	   <meta content="HTML Tidy for Java (vers. 27 Sep 2004), see www.w3.org" name="generator"/>
@@ -39,10 +41,47 @@ func TestGetMasterPlaybackURL_shouldPanic(t *testing.T) {
 	</body>
  </html>`
 
-	// No need to check whether `recover()` is nil. Just turn off the panic.
-	defer func() { recover() }()
-
 	actualMasterPlaybackURLs, actualError := utils.GetMasterPlaybackURLs([]byte(playbackURIContent))
 
-	t.Errorf("GetMasterPlaybackURLs did not panic. actualMasterPlaybackURLs: %v\tactualError: %v", actualMasterPlaybackURLs, actualError)
+	if actualError == nil || !strings.Contains(actualError.Error(), "playback api") || len(actualMasterPlaybackURLs) != 0 {
+		t.Errorf("Expected error naming the playback api but got %v\t%v", actualMasterPlaybackURLs, actualError)
+	}
+}
+
+func TestGetPlaybackSets_MissingField(t *testing.T) {
+	testCases := map[string]string{
+		`{"data":{}}`: "missing field message",
+		`{"message":"Playback URL's fetched successfully"}`:                                                  "missing field data",
+		`{"message":"Playback URL's fetched successfully","data":{"playBackSets":[{"playbackUrl":"a"},{}]}}`: "missing field data.playBackSets[1].playbackUrl",
+		`{"message":"Playback URL's fetched successfully","data":{"playBackSets":{}}}`:                       "field data.playBackSets",
+	}
+
+	for playbackURIContent, expectedError := range testCases {
+		playbackSets, err := utils.GetPlaybackSets([]byte(playbackURIContent))
+
+		var responseError *utils.ResponseError
+		if !errors.As(err, &responseError) || !strings.Contains(err.Error(), expectedError) {
+			t.Error("Expected error with", expectedError, "but got", playbackSets, err)
+		}
+	}
+}
+
+func TestGetPlaybackSets_KeepsTags(t *testing.T) {
+	playbackURIContent := `{"message":"Playback URL's fetched successfully","data":{"playBackSets":[{"tagsCombination":"container:ts;encryption:plain;ladder:phone;package:hls","playbackUrl":"https://hses4.hotstar.com/a/master.m3u8","playbackCDNType":"INTERNAL","tokenAlgorithm":"AKAMAI-HMAC"},{"tagsCombination":"encryption:plain;ladder:tv;package:dash;video_codec:h265","playbackUrl":"https://hses1.hotstar.com/b/master.mpd","playbackCDNType":"EXTERNAL"}]}}`
+
+	expectedPlaybackSets := []utils.PlaybackSet{
+		{PlaybackURL: "https://hses4.hotstar.com/a/master.m3u8", TagsCombination: "container:ts;encryption:plain;ladder:phone;package:hls", CDNType: "INTERNAL", TokenAlgorithm: "AKAMAI-HMAC"},
+		{PlaybackURL: "https://hses1.hotstar.com/b/master.mpd", TagsCombination: "encryption:plain;ladder:tv;package:dash;video_codec:h265", CDNType: "EXTERNAL"},
+	}
+
+	actualPlaybackSets, err := utils.GetPlaybackSets([]byte(playbackURIContent))
+
+	if err != nil || !reflect.DeepEqual(expectedPlaybackSets, actualPlaybackSets) {
+		t.Fatal("Expected", expectedPlaybackSets, "but got", actualPlaybackSets, err)
+	}
+
+	expectedTags := map[string]string{"encryption": "plain", "ladder": "tv", "package": "dash", "video_codec": "h265"}
+	if actualTags := actualPlaybackSets[1].Tags(); !reflect.DeepEqual(expectedTags, actualTags) {
+		t.Error("Expected", expectedTags, "but got", actualTags)
+	}
 }
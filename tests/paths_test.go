@@ -0,0 +1,30 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestParsePaths(t *testing.T) {
+	paths, err := utils.ParsePaths([]string{"~/Videos", "temp:/tmp/hotstar-dl", "OUTPUT:done", `C:\Videos`})
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	//the last home path wins, a drive letter isn't a path type
+	expectedPaths := utils.Paths{Home: `C:\Videos`, Temp: "/tmp/hotstar-dl", Output: "done"}
+	if paths != expectedPaths {
+		t.Error("Expected", expectedPaths, "but got", paths)
+	}
+}
+
+func TestParsePaths_Empty(t *testing.T) {
+	if paths, err := utils.ParsePaths(nil); err != nil || paths != (utils.Paths{}) {
+		t.Error("Expected empty paths but got", paths, err)
+	}
+
+	if _, err := utils.ParsePaths([]string{"temp:"}); err == nil {
+		t.Error("Expected error for the empty temp path")
+	}
+}
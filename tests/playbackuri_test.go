@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Gotham25/hotstar-dl/utils"
@@ -38,3 +40,46 @@ func TestGetPlaybackURI_InvalidPageContents(t *testing.T) {
 		t.Error("Expected", expectedPlaybackURIError, " but got", actualPlaybackURIError)
 	}
 }
+
+func TestGetPlaybackURI_MissingContent(t *testing.T) {
+	testPageContents := `<script>window.APP_STATE={"/in/movies/kabir-singh/1260009870":{"initialState":{"contentData":{}}}}</script>`
+
+	_, _, err := utils.GetPlaybackURI(testPageContents, "https://www.hotstar.com/in/movies/kabir-singh/1260009870", "1260009870", "79272307-fa98-4b08-8f5c-5afdde2687ff")
+
+	expectedError := "Invalid response from APP_STATE of the video page: missing field initialState.contentData.content"
+	if err == nil || expectedError != err.Error() {
+		t.Error("Expected", expectedError, "but got", err)
+	}
+}
+
+func TestGetPlaybackURI_Metadata(t *testing.T) {
+	testPageContents := `<script>window.APP_STATE={"menu":[],"/in/tv/show/s-1/episode/1100003795":{"initialState":{"contentData":{"content":{"contentId":1100003795,"title":"Episode","genre":"Drama","actors":["A","B"],"episodeNo":149,"drmProtected":false,"playbackUri":"https://api.hotstar.com/h/v1/play?contentId=1100003795"}}}}}</script>`
+
+	_, metadata, err := utils.GetPlaybackURI(testPageContents, "https://www.hotstar.com/in/tv/show/s-1/episode/1100003795", "1100003795", "79272307-fa98-4b08-8f5c-5afdde2687ff")
+
+	expectedMetadata := map[string]string{
+		"id":           "1100003795",
+		"title":        "Episode",
+		"album":        "Episode",
+		"genre":        "Drama",
+		"artist":       "A,\nB",
+		"album_artist": "A,\nB",
+		"episode_id":   "149",
+		"drmProtected": "false",
+		"playbackUri":  "https://api.hotstar.com/h/v1/play?contentId=1100003795",
+	}
+
+	if err != nil || !reflect.DeepEqual(expectedMetadata, metadata) {
+		t.Error("Expected", expectedMetadata, "but got", metadata, err)
+	}
+}
+
+func TestGetPlaybackURI_InvalidFieldType(t *testing.T) {
+	testPageContents := `<script>window.APP_STATE={"/in/movies/kabir-singh/1260009870":{"initialState":{"contentData":{"content":{"title":42}}}}}</script>`
+
+	_, _, err := utils.GetPlaybackURI(testPageContents, "https://www.hotstar.com/in/movies/kabir-singh/1260009870", "1260009870", "79272307-fa98-4b08-8f5c-5afdde2687ff")
+
+	if err == nil || !strings.Contains(err.Error(), "title") {
+		t.Error("Expected error naming the title field but got", err)
+	}
+}
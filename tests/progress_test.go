@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+//recordingProgress records the reported events
+type recordingProgress struct {
+	mutex  sync.Mutex
+	events []utils.ProgressEvent
+}
+
+func (progress *recordingProgress) Report(event utils.ProgressEvent) {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	progress.events = append(progress.events, event)
+}
+
+func TestJSONProgress(t *testing.T) {
+	var output bytes.Buffer
+	progress := utils.NewJSONProgress(&output)
+
+	eventTime := time.Date(2020, 6, 14, 10, 30, 0, 0, time.UTC)
+	progress.Report(utils.ProgressEvent{Type: utils.EventSegmentDone, Time: eventTime, VideoID: "1260009870", Format: "dash-video-1500", Segment: 3, TotalSegments: 10, Bytes: 2048})
+	progress.Report(utils.ProgressEvent{Type: utils.EventError, Time: eventTime, Error: "Geo restricted", ErrorKind: utils.ErrorKindGeoRestricted})
+
+	expectedOutput := `{"event":"segment-done","time":"2020-06-14T10:30:00Z","videoId":"1260009870","format":"dash-video-1500","segment":3,"totalSegments":10,"bytes":2048}` + "\n" +
+		`{"event":"error","time":"2020-06-14T10:30:00Z","error":"Geo restricted","errorKind":"geo-restricted"}` + "\n"
+	if output.String() != expectedOutput {
+		t.Error("Expected", expectedOutput, "but got", output.String())
+	}
+}
+
+func TestDownloadDashStream_ReportsSegments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, dashSegment(filepath.Base(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	format := map[string]string{
+		"INIT-URL":       "video/init.mp4",
+		"STREAM-URL":     "video/$Number$.m4s",
+		"TOTAL-SEGMENTS": "3",
+		"PLAYBACK-URL":   server.URL + "/content/master.mpd",
+	}
+
+	workingDir, err := ioutil.TempDir("", "hotstar-dl-dash")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	progress := &recordingProgress{}
+	utils.SetProgressReporter(progress)
+	defer utils.SetProgressReporter(utils.NewTerminalProgress())
+
+	if err := utils.DownloadDashStream(filepath.Join(workingDir, "video.part"), "dash-video-1500", []map[string]string{format}, map[string]string{}, nil); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	//the init segment of 24 bytes is counted in the bytes, each media segment adding 21
+	type segmentDone struct {
+		segment, totalSegments int
+		bytes                  int64
+	}
+	segmentsDone := make([]segmentDone, 0)
+	for _, event := range progress.events {
+		if event.Type != utils.EventSegmentDone || event.Format != "dash-video-1500" || event.Time.IsZero() {
+			t.Fatal("Unexpected event", event)
+		}
+		segmentsDone = append(segmentsDone, segmentDone{event.Segment, event.TotalSegments, event.Bytes})
+	}
+	expectedSegmentsDone := []segmentDone{{1, 3, 45}, {2, 3, 66}, {3, 3, 87}}
+	if !reflect.DeepEqual(expectedSegmentsDone, segmentsDone) {
+		t.Error("Expected", expectedSegmentsDone, "but got", segmentsDone)
+	}
+}
+
+func TestRunJobs_ReportsError(t *testing.T) {
+	progress := &recordingProgress{}
+	utils.SetProgressReporter(progress)
+	defer utils.SetProgressReporter(utils.NewTerminalProgress())
+
+	utils.RunJobs([]string{"https://www.example.com/video"}, &utils.Options{})
+
+	if len(progress.events) != 1 || progress.events[0].Type != utils.EventError || progress.events[0].URL != "https://www.example.com/video" {
+		t.Fatal("Expected an error event for the url but got", progress.events)
+	}
+	if _, err := json.Marshal(progress.events[0]); err != nil {
+		t.Error("Expected the event to encode but got", err)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "bytes", value: "2048", want: 2048},
+		{name: "kibibytes", value: "500K", want: 500 << 10},
+		{name: "fractional mebibytes", value: "1.5M", want: 3 << 19},
+		{name: "lower case with unit", value: "2mib/s", want: 2 << 20},
+		{name: "gibibytes", value: "1G", want: 1 << 30},
+		{name: "invalid suffix", value: "5T", wantErr: true},
+		{name: "zero", value: "0", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ParseRate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRate(%s) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%s) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLimitRate(t *testing.T) {
+	body := bytes.Repeat([]byte{0x47}, 96<<10)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(body)
+	}))
+	defer server.Close()
+
+	utils.SetLimitRate(32 << 10)
+	defer utils.SetLimitRate(0)
+
+	//a second worth of the rate is taken at once, the rest of the body takes two seconds more
+	startTime := time.Now()
+	content, err := utils.MakeGetRequest(server.URL, nil)
+	elapsed := time.Since(startTime)
+	if err != nil {
+		t.Fatalf("MakeGetRequest() error = %v", err)
+	}
+	if !bytes.Equal(content, body) {
+		t.Errorf("MakeGetRequest() returned %d bytes, want %d", len(content), len(body))
+	}
+	if elapsed < 1800*time.Millisecond {
+		t.Errorf("MakeGetRequest() took %s at 32K per second for 96K, want at least 2s", elapsed)
+	}
+
+	utils.SetLimitRate(0)
+	startTime = time.Now()
+	if _, err := utils.MakeGetRequest(server.URL, nil); err != nil {
+		t.Fatalf("MakeGetRequest() error = %v", err)
+	}
+	if elapsed := time.Since(startTime); elapsed > time.Second {
+		t.Errorf("MakeGetRequest() took %s without a limit", elapsed)
+	}
+}
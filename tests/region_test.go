@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestSetRegion(t *testing.T) {
+	defer utils.SetRegion("")
+
+	if err := utils.SetRegion(" US "); err != nil || utils.GetRegion() != "us" {
+		t.Error("Expected region us but got", utils.GetRegion(), err)
+	}
+
+	for _, region := range []string{"usa", "1n", "u"} {
+		if err := utils.SetRegion(region); err == nil {
+			t.Error("Expected error for region", region)
+		}
+	}
+
+	if err := utils.SetRegion(""); err != nil || utils.GetRegion() != utils.DefaultRegion {
+		t.Error("Expected region", utils.DefaultRegion, "but got", utils.GetRegion(), err)
+	}
+}
+
+func TestGetPlaybackURI2_Region(t *testing.T) {
+	utils.SetClientProfile(nil)
+	utils.SetRegion("ca")
+	defer utils.SetRegion("")
+
+	expectedPlaybackURI := "https://api.hotstar.com/h/v2/play/ca/contents/1100025368?desiredConfig=encryption:plain;ladder:phone,tv;package:hls,dash&client=mweb&clientVersion=6.18.0&deviceId=79272307-fa98-4b08-8f5c-5afdde2687ff&osName=Windows&osVersion=10"
+	actualPlaybackURI := utils.GetPlaybackURI2("1100025368", "79272307-fa98-4b08-8f5c-5afdde2687ff")
+
+	if expectedPlaybackURI != actualPlaybackURI {
+		t.Error("Expected", expectedPlaybackURI, "but got", actualPlaybackURI)
+	}
+}
+
+func TestSetGeoVerificationProxy_Invalid(t *testing.T) {
+	defer utils.SetGeoVerificationProxy("")
+
+	for _, proxyURL := range []string{"127.0.0.1:8080", "http://", "://proxy"} {
+		if err := utils.SetGeoVerificationProxy(proxyURL); err == nil {
+			t.Error("Expected error for proxy", proxyURL)
+		}
+	}
+}
+
+func TestSetGeoVerificationProxy_OnlyAPIRequests(t *testing.T) {
+	var mutex sync.Mutex
+	proxiedHosts := make([]string, 0)
+	proxy := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		proxiedHosts = append(proxiedHosts, request.Host)
+		mutex.Unlock()
+		writer.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	cdn := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("segment"))
+	}))
+	defer cdn.Close()
+
+	if err := utils.SetGeoVerificationProxy(proxy.URL); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer utils.SetGeoVerificationProxy("")
+
+	if contentBytes, err := utils.MakeGetRequest(cdn.URL, nil); err != nil || string(contentBytes) != "segment" {
+		t.Error("Expected the CDN download to go direct but got", string(contentBytes), err)
+	}
+
+	if playlistItems, err := utils.GetPlaylistItems("1260000001"); err == nil {
+		t.Error("Expected error from the proxy but got", playlistItems)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(proxiedHosts) != 1 || proxiedHosts[0] != "api.hotstar.com:443" {
+		t.Error("Expected only the api request to go through the proxy but got", proxiedHosts)
+	}
+}
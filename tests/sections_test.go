@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestParseSection(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *utils.Section
+		wantErr bool
+	}{
+		{name: "hours", value: "*00:10:00-00:15:30", want: &utils.Section{Start: 10 * time.Minute, End: 15*time.Minute + 30*time.Second}},
+		{name: "minutes", value: "*1:30-2:00.5", want: &utils.Section{Start: 90 * time.Second, End: 120*time.Second + 500*time.Millisecond}},
+		{name: "seconds", value: "*600-930", want: &utils.Section{Start: 600 * time.Second, End: 930 * time.Second}},
+		{name: "to the end", value: "*10:00-", want: &utils.Section{Start: 10 * time.Minute}},
+		{name: "to inf", value: "*10:00-inf", want: &utils.Section{Start: 10 * time.Minute}},
+		{name: "end before start", value: "*00:15:00-00:10:00", wantErr: true},
+		{name: "no range", value: "*00:10:00", wantErr: true},
+		{name: "invalid time", value: "*ten-eleven", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ParseSection(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSection(%s) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSection(%s) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDashSectionFormat(t *testing.T) {
+	format := map[string]string{"TOTAL-SEGMENTS": "317", "SEGMENT-DURATION": "4"}
+
+	sectionFormat, start, err := utils.GetDashSectionFormat(format, &utils.Section{Start: 10 * time.Minute, End: 15*time.Minute + 30*time.Second})
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	if sectionFormat["FIRST-SEGMENT"] != "151" || sectionFormat["LAST-SEGMENT"] != "233" || start != 600*time.Second {
+		t.Error("Expected segments 151-233 from 10m0s but got", sectionFormat["FIRST-SEGMENT"], sectionFormat["LAST-SEGMENT"], start)
+	}
+	if _, isLimited := format["FIRST-SEGMENT"]; isLimited {
+		t.Error("Expected the given format to be left as is")
+	}
+
+	sectionFormat, start, err = utils.GetDashSectionFormat(format, &utils.Section{Start: 21*time.Minute + 1*time.Second})
+	if err != nil || sectionFormat["FIRST-SEGMENT"] != "316" || sectionFormat["LAST-SEGMENT"] != "317" || start != 1260*time.Second {
+		t.Error("Expected segments 316-317 from 21m0s but got", sectionFormat["FIRST-SEGMENT"], sectionFormat["LAST-SEGMENT"], start, err)
+	}
+
+	if _, _, err := utils.GetDashSectionFormat(format, &utils.Section{Start: time.Hour}); err == nil {
+		t.Error("Expected error for a section beyond the end of the video")
+	}
+}
+
+func TestGetSectionM3u8(t *testing.T) {
+	m3u8Content := strings.Join([]string{
+		"#EXTM3U",
+		"#EXT-X-VERSION:3",
+		"#EXT-X-TARGETDURATION:6",
+		"#EXT-X-MEDIA-SEQUENCE:100",
+		`#EXT-X-KEY:METHOD=AES-128,URI="keys/1.key"`,
+		"#EXTINF:6.000,",
+		"segment-0.ts",
+		"#EXTINF:6.000,",
+		"segment-1.ts",
+		"#EXTINF:6.000,",
+		"segment-2.ts",
+		"#EXTINF:6.000,",
+		"segment-3.ts",
+		"#EXTINF:4.500,",
+		"https://cdn.example.com/segment-4.ts",
+		"#EXT-X-ENDLIST",
+	}, "\n")
+
+	sectionM3u8, start, err := utils.GetSectionM3u8(m3u8Content, "https://hses.akamaized.net/videos/a/index_1.m3u8?hdnea=st=1", &utils.Section{Start: 7 * time.Second, End: 13 * time.Second})
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	expectedM3u8 := strings.Join([]string{
+		"#EXTM3U",
+		"#EXT-X-VERSION:3",
+		"#EXT-X-TARGETDURATION:6",
+		"#EXT-X-MEDIA-SEQUENCE:101",
+		`#EXT-X-KEY:METHOD=AES-128,URI="https://hses.akamaized.net/videos/a/keys/1.key"`,
+		"#EXTINF:6.000,",
+		"https://hses.akamaized.net/videos/a/segment-1.ts",
+		"#EXTINF:6.000,",
+		"https://hses.akamaized.net/videos/a/segment-2.ts",
+		"#EXT-X-ENDLIST",
+		"",
+	}, "\n")
+	if sectionM3u8 != expectedM3u8 || start != 6*time.Second {
+		t.Error("Expected", expectedM3u8, "from 6s but got", sectionM3u8, start)
+	}
+
+	sectionM3u8, _, err = utils.GetSectionM3u8(m3u8Content, "https://hses.akamaized.net/videos/a/index_1.m3u8", &utils.Section{Start: 25 * time.Second})
+	if err != nil || !strings.Contains(sectionM3u8, "\nhttps://cdn.example.com/segment-4.ts\n") || strings.Contains(sectionM3u8, "segment-3.ts") {
+		t.Error("Expected only the last segment but got", sectionM3u8, err)
+	}
+
+	if _, _, err := utils.GetSectionM3u8(m3u8Content, "https://hses.akamaized.net/videos/a/index_1.m3u8", &utils.Section{Start: time.Minute}); err == nil {
+		t.Error("Expected error for a section beyond the end of the video")
+	}
+}
+
+func TestDownloadDashStream_Section(t *testing.T) {
+	requestedSegments := make(chan string, 20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedSegments <- filepath.Base(r.URL.Path)
+		fmt.Fprint(w, dashSegment(fmt.Sprintf("[%s]", filepath.Base(r.URL.Path))))
+	}))
+	defer server.Close()
+
+	format := map[string]string{
+		"INIT-URL":         "video/init.mp4",
+		"STREAM-URL":       "video/$Number$.m4s",
+		"TOTAL-SEGMENTS":   "12",
+		"SEGMENT-DURATION": "4",
+		"PLAYBACK-URL":     server.URL + "/content/master.mpd",
+	}
+	sectionFormat, _, err := utils.GetDashSectionFormat(format, &utils.Section{Start: 9 * time.Second, End: 18 * time.Second})
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	workingDir, err := ioutil.TempDir("", "hotstar-dl-dash")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	filePath := filepath.Join(workingDir, "video.mp4.dash-video-1500.part")
+	if err := utils.DownloadDashStream(filePath, "dash-video-1500", []map[string]string{sectionFormat}, map[string]string{}, nil); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	close(requestedSegments)
+
+	expectedContents := dashSegment("[init.mp4]") + dashSegment("[3.m4s]") + dashSegment("[4.m4s]") + dashSegment("[5.m4s]")
+	if contents, err := ioutil.ReadFile(filePath); err != nil || string(contents) != expectedContents {
+		t.Error("Expected", expectedContents, "but got", string(contents), err)
+	}
+	if len(requestedSegments) != 4 {
+		t.Error("Expected only the init and the 3 chunks of the section to be requested but got", len(requestedSegments), "requests")
+	}
+}
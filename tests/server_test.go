@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestServer_Extract(t *testing.T) {
+	server := utils.NewServer(&utils.Options{})
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/extract?url=https://www.hotstar.com/in/movies/kabir-singh/1260009870", nil))
+
+	var contentRef utils.ContentRef
+	json.NewDecoder(recorder.Body).Decode(&contentRef)
+
+	if recorder.Code != http.StatusOK || contentRef.ContentID != "1260009870" {
+		t.Error("Expected", http.StatusOK, "1260009870", "but got", recorder.Code, contentRef.ContentID)
+	}
+}
+
+func TestServer_SubmitInvalidJob(t *testing.T) {
+	server := utils.NewServer(&utils.Options{})
+
+	oversizedBody := `{"url":"https://www.hotstar.com/in/movies/kabir-singh/1260009870","format":"` + strings.Repeat("hls-", 20000) + `"}`
+	for _, body := range []string{`{"url":"https://www.google.com"}`, `{"url":"https://www.hotstar.com/in/movies/kabir-singh/1260009870","format":"mp4"}`, `not json`, oversizedBody} {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("POST", "/api/jobs", strings.NewReader(body)))
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Error("Expected", http.StatusBadRequest, "for", body[:20], "but got", recorder.Code)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/jobs", nil))
+
+	if actualJobs := strings.TrimSpace(recorder.Body.String()); actualJobs != "[]" {
+		t.Error("Expected no jobs but got", actualJobs)
+	}
+}
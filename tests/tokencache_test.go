@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func newTestTokenCache(t *testing.T) (*utils.TokenCache, func()) {
+	cacheDir, err := ioutil.TempDir("", "hotstar-dl-cache")
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	return utils.NewTokenCache(filepath.Join(cacheDir, "hotstar-dl")), func() { os.RemoveAll(cacheDir) }
+}
+
+func TestTokenCache_ReusesValidCredentials(t *testing.T) {
+	cache, cleanup := newTestTokenCache(t)
+	defer cleanup()
+
+	refreshCount := 0
+	refresh := func(cached *utils.Credentials) (*utils.Credentials, error) {
+		refreshCount++
+		return &utils.Credentials{UserToken: "token", DeviceID: "device", Expiry: time.Now().Add(time.Hour), IsGuest: true}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		credentials, err := cache.GetOrRefresh(utils.GuestCacheKey, refresh)
+		if err != nil || credentials.UserToken != "token" || credentials.DeviceID != "device" {
+			t.Fatal("Expected cached credentials but got", credentials, err)
+		}
+	}
+
+	if refreshCount != 1 {
+		t.Error("Expected 1 refresh but got", refreshCount)
+	}
+}
+
+func TestTokenCache_RefreshesExpiringCredentials(t *testing.T) {
+	cache, cleanup := newTestTokenCache(t)
+	defer cleanup()
+
+	expiring := &utils.Credentials{UserToken: "old", DeviceID: "device", Expiry: time.Now().Add(time.Minute)}
+	if err := cache.Save(utils.GetCacheKey(testUserToken), expiring); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	credentials, err := cache.GetOrRefresh(utils.GetCacheKey(testUserToken), func(cached *utils.Credentials) (*utils.Credentials, error) {
+		if cached == nil || cached.UserToken != "old" {
+			t.Error("Expected the expiring credentials to refresh from but got", cached)
+		}
+		return &utils.Credentials{UserToken: "new", DeviceID: cached.DeviceID, Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	if err != nil || credentials.UserToken != "new" || credentials.DeviceID != "device" {
+		t.Error("Expected refreshed credentials but got", credentials, err)
+	}
+
+	if cached, err := cache.Load(utils.GetCacheKey(testUserToken)); err != nil || cached == nil || cached.UserToken != "new" {
+		t.Error("Expected refreshed credentials to be cached but got", cached, err)
+	}
+
+	if cached, err := cache.Load(utils.GuestCacheKey); err != nil || cached != nil {
+		t.Error("Expected no guest credentials but got", cached, err)
+	}
+}
+
+func TestTokenCache_RefreshErrorNotCached(t *testing.T) {
+	cache, cleanup := newTestTokenCache(t)
+	defer cleanup()
+
+	refreshErr := errors.New("refresh failed")
+	if _, err := cache.GetOrRefresh(utils.GuestCacheKey, func(*utils.Credentials) (*utils.Credentials, error) { return nil, refreshErr }); err != refreshErr {
+		t.Error("Expected", refreshErr, "but got", err)
+	}
+
+	if cached, err := cache.Load(utils.GuestCacheKey); err != nil || cached != nil {
+		t.Error("Expected nothing cached but got", cached, err)
+	}
+}
+
+func TestTokenCache_ConcurrentRefreshOnce(t *testing.T) {
+	cache, cleanup := newTestTokenCache(t)
+	defer cleanup()
+
+	var mutex sync.Mutex
+	refreshCount := 0
+	refresh := func(*utils.Credentials) (*utils.Credentials, error) {
+		mutex.Lock()
+		refreshCount++
+		mutex.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		return &utils.Credentials{UserToken: "token", Expiry: time.Now().Add(time.Hour), IsGuest: true}, nil
+	}
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			if _, err := cache.GetOrRefresh(utils.GuestCacheKey, refresh); err != nil {
+				t.Error("Expected no error but got", err)
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	if refreshCount != 1 {
+		t.Error("Expected 1 refresh but got", refreshCount)
+	}
+}
+
+func TestTokenCache_ReclaimsStaleLock(t *testing.T) {
+	cache, cleanup := newTestTokenCache(t)
+	defer cleanup()
+
+	//the lock left behind by a run which crashed a minute ago
+	lockPath := cache.Path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(lockPath, []byte("1 1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	staleTime := time.Now().Add(-time.Minute)
+	os.Chtimes(lockPath, staleTime, staleTime)
+
+	refresh := func(*utils.Credentials) (*utils.Credentials, error) {
+		return &utils.Credentials{UserToken: "token", Expiry: time.Now().Add(time.Hour), IsGuest: true}, nil
+	}
+	if _, err := cache.GetOrRefresh(utils.GuestCacheKey, refresh); err != nil {
+		t.Fatal("Expected the stale lock to be reclaimed but got", err)
+	}
+
+	files, _ := ioutil.ReadDir(filepath.Dir(lockPath))
+	for _, file := range files {
+		if file.Name() != filepath.Base(cache.Path) {
+			t.Error("Expected only the cache file to be left but found", file.Name())
+		}
+	}
+}
+
+func TestTokenCache_KeepsLockTakenOver(t *testing.T) {
+	cache, cleanup := newTestTokenCache(t)
+	defer cleanup()
+
+	//another run takes the lock over while the refresh is running, as if it had turned stale
+	lockPath := cache.Path + ".lock"
+	refresh := func(*utils.Credentials) (*utils.Credentials, error) {
+		if err := ioutil.WriteFile(lockPath, []byte("1 1"), 0600); err != nil {
+			t.Error("Expected no error but got", err)
+		}
+		return &utils.Credentials{UserToken: "token", Expiry: time.Now().Add(time.Hour), IsGuest: true}, nil
+	}
+	if _, err := cache.GetOrRefresh(utils.GuestCacheKey, refresh); err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+
+	if content, err := ioutil.ReadFile(lockPath); err != nil || string(content) != "1 1" {
+		t.Errorf("Expected the lock of the other run to be kept but got %q, %v", content, err)
+	}
+}
+
+func TestGetCacheKey(t *testing.T) {
+	if key := utils.GetCacheKey(""); key != utils.GuestCacheKey {
+		t.Error("Expected", utils.GuestCacheKey, "but got", key)
+	}
+
+	if key := utils.GetCacheKey(testUserToken); key == utils.GuestCacheKey || key != utils.GetCacheKey(testUserToken) || key == utils.GetCacheKey("other") {
+		t.Error("Expected a stable key per user token but got", key)
+	}
+}
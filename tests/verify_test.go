@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gotham25/hotstar-dl/utils"
+)
+
+func TestParseFfprobeOutput(t *testing.T) {
+	output := `{
+		"streams": [
+			{"index": 0, "codec_name": "h264", "codec_type": "video", "width": 1280, "height": 720},
+			{"index": 1, "codec_name": "aac", "codec_type": "audio", "sample_rate": "48000"},
+			{"index": 2, "codec_name": "timed_id3", "codec_type": "data"}
+		],
+		"format": {"filename": "video.mp4", "duration": "1267.800000"}
+	}`
+
+	mediaInfo, err := utils.ParseFfprobeOutput([]byte(output))
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	expectedMediaInfo := &utils.MediaInfo{
+		Duration: 1267800 * time.Millisecond,
+		Streams:  []utils.MediaStream{{Type: "video", Codec: "h264", Width: 1280, Height: 720}, {Type: "audio", Codec: "aac"}},
+	}
+	if !reflect.DeepEqual(expectedMediaInfo, mediaInfo) {
+		t.Errorf("Expected %+v but got %+v", expectedMediaInfo, mediaInfo)
+	}
+
+	if _, err := utils.ParseFfprobeOutput([]byte("Invalid data found when processing input")); err == nil {
+		t.Error("Expected error for the output not in JSON")
+	}
+}
+
+func TestGetExpectedMediaInfo(t *testing.T) {
+	dashVideo := map[string]string{"CODECS": "mp4_dash container, avc1.640028", "RESOLUTION": "1920x1080"}
+	dashAudio := map[string]string{"CODECS": "m4a_dash container, mp4a.40.2"}
+	hls := map[string]string{"CODECS": `"avc1.4d401f,mp4a.40.2"`, "RESOLUTION": "1280x720"}
+
+	tests := []struct {
+		name    string
+		formats []map[string]string
+		want    []utils.MediaStream
+	}{
+		{name: "dash video and audio", formats: []map[string]string{dashVideo, dashAudio}, want: []utils.MediaStream{{Type: "video", Codec: "h264", Width: 1920, Height: 1080}, {Type: "audio", Codec: "aac"}}},
+		{name: "hls", formats: []map[string]string{hls}, want: []utils.MediaStream{{Type: "video", Codec: "h264", Width: 1280, Height: 720}, {Type: "audio", Codec: "aac"}}},
+		{name: "hevc", formats: []map[string]string{{"CODECS": "mp4_dash container, hvc1.2.4.L123.90", "RESOLUTION": "3840x2160"}}, want: []utils.MediaStream{{Type: "video", Codec: "hevc", Width: 3840, Height: 2160}}},
+		{name: "unknown codec", formats: []map[string]string{dashVideo, {"CODECS": "m4a_dash container, mha1"}}},
+		{name: "no codecs", formats: []map[string]string{{"RESOLUTION": "1280x720"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := utils.GetExpectedMediaInfo(tt.formats, time.Minute)
+			if got.Duration != time.Minute || !reflect.DeepEqual(got.Streams, tt.want) {
+				t.Errorf("GetExpectedMediaInfo() = %+v, want streams %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyMediaInfo(t *testing.T) {
+	expected := &utils.MediaInfo{
+		Duration: 20 * time.Minute,
+		Streams:  []utils.MediaStream{{Type: "video", Codec: "h264", Width: 1280, Height: 720}, {Type: "audio", Codec: "aac"}},
+	}
+	tests := []struct {
+		name     string
+		expected *utils.MediaInfo
+		actual   *utils.MediaInfo
+		wantErr  string
+	}{
+		{name: "matching", expected: expected, actual: &utils.MediaInfo{Duration: 20*time.Minute - time.Second, Streams: []utils.MediaStream{{Type: "audio", Codec: "aac"}, {Type: "video", Codec: "h264", Width: 1280, Height: 720}}}},
+		{name: "cut short", expected: expected, actual: &utils.MediaInfo{Duration: 12 * time.Minute, Streams: expected.Streams}, wantErr: "duration 12m0s instead of 20m0s"},
+		{name: "missing audio", expected: expected, actual: &utils.MediaInfo{Duration: 20 * time.Minute, Streams: expected.Streams[:1]}, wantErr: "0 audio streams instead of 1"},
+		{name: "other codec", expected: expected, actual: &utils.MediaInfo{Duration: 20 * time.Minute, Streams: []utils.MediaStream{{Type: "video", Codec: "hevc", Width: 1280, Height: 720}, {Type: "audio", Codec: "aac"}}}, wantErr: "video codec hevc instead of h264"},
+		{name: "other resolution", expected: expected, actual: &utils.MediaInfo{Duration: 20 * time.Minute, Streams: []utils.MediaStream{{Type: "video", Codec: "h264", Width: 640, Height: 360}, {Type: "audio", Codec: "aac"}}}, wantErr: "resolution 640x360 instead of 1280x720"},
+		{name: "unknown expectations", expected: &utils.MediaInfo{}, actual: &utils.MediaInfo{Duration: time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := utils.VerifyMediaInfo(tt.expected, tt.actual, 2*time.Second)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Error("Expected no error but got", err)
+				}
+				return
+			}
+			if err == nil || !errors.Is(err, utils.ErrVerificationFailed) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected the verification to fail with %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+//probeTestTrak builds the track of the given handler and sample entry, the durations of its samples given by the fragments
+func probeTestTrak(trackID uint32, timescale uint32, handler string, sampleEntry []byte) []byte {
+	return mp4Box("trak",
+		mp4Box("tkhd", fullBoxPayload(80, 12, trackID)),
+		mp4Box("mdia",
+			mp4Box("mdhd", fullBoxPayload(20, 12, timescale)),
+			mp4Box("hdlr", uint32Bytes(0, 0), []byte(handler), make([]byte, 13)),
+			mp4Box("minf", mp4Box("stbl", mp4Box("stsd", uint32Bytes(0, 1), sampleEntry)))))
+}
+
+func TestProbeMP4_Fragmented(t *testing.T) {
+	//the visual sample entry holds the width and the height after 24 bytes
+	avc1 := make([]byte, 78)
+	copy(avc1[24:], []byte{0x05, 0x00, 0x02, 0xD0})
+	mp4a := make([]byte, 28)
+
+	movie := append(mp4Box("ftyp", []byte("iso6"), uint32Bytes(0)),
+		mp4Box("moov",
+			mp4Box("mvhd", fullBoxPayload(100, 96, 3)),
+			probeTestTrak(1, 1000, "vide", mp4Box("avc1", avc1)),
+			probeTestTrak(2, 48000, "soun", mp4Box("mp4a", mp4a)),
+			mp4Box("mvex", mp4Box("trex", uint32Bytes(0, 1, 1, 40, 0, 0)), mp4Box("trex", uint32Bytes(0, 2, 1, 0, 0, 0))))...)
+	//50 video samples of the default 40ms with 94 audio samples of 1024 from tfhd, then 2 video samples of their own 40ms
+	movie = append(movie, mp4Box("moof",
+		mp4Box("mfhd", uint32Bytes(0, 1)),
+		mp4Box("traf", mp4Box("tfhd", uint32Bytes(0x020000, 1)), mp4Box("trun", uint32Bytes(0, 50))),
+		mp4Box("traf", mp4Box("tfhd", uint32Bytes(0x020008, 2, 1024)), mp4Box("trun", uint32Bytes(0, 94))))...)
+	movie = append(movie, mp4Box("mdat", make([]byte, 4096))...)
+	movie = append(movie, mp4Box("moof",
+		mp4Box("mfhd", uint32Bytes(0, 2)),
+		mp4Box("traf", mp4Box("tfhd", uint32Bytes(0x020000, 1)), mp4Box("trun", uint32Bytes(0x000301, 2, 0, 40, 100, 40, 100))))...)
+	movie = append(movie, mp4Box("mdat", make([]byte, 200))...)
+
+	tempDir, err := ioutil.TempDir("", "probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+	filePath := filepath.Join(tempDir, "video.mp4")
+	if err := ioutil.WriteFile(filePath, movie, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mediaInfo, err := utils.ProbeMP4(filePath)
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	expectedMediaInfo := &utils.MediaInfo{
+		Duration: 2080 * time.Millisecond,
+		Streams:  []utils.MediaStream{{Type: "video", Codec: "h264", Width: 1280, Height: 720}, {Type: "audio", Codec: "aac"}},
+	}
+	if !reflect.DeepEqual(expectedMediaInfo, mediaInfo) {
+		t.Errorf("Expected %+v but got %+v", expectedMediaInfo, mediaInfo)
+	}
+}
+
+func TestProbeMP4_NotMP4(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+	filePath := filepath.Join(tempDir, "video.mp4")
+	if err := ioutil.WriteFile(filePath, []byte("<html>Access Denied</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ProbeMP4(filePath); err == nil {
+		t.Error("Expected error in probing a file which isn't MP4")
+	}
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//ErrorKind classifies the reasons Hotstar refuses to play a content.
+type ErrorKind string
+
+//kinds of the errors returned by the Hotstar apis
+const (
+	ErrorKindGeoRestricted        ErrorKind = "geo-restricted"
+	ErrorKindSubscriptionRequired ErrorKind = "subscription-required"
+	ErrorKindLoginRequired        ErrorKind = "login-required"
+	ErrorKindContentRemoved       ErrorKind = "content-removed"
+	ErrorKindRateLimited          ErrorKind = "rate-limited"
+	ErrorKindDRMProtected         ErrorKind = "drm-protected"
+	ErrorKindNotStarted           ErrorKind = "not-started"
+)
+
+//errorKindDescriptions holds the explanation reported for each kind of error
+var errorKindDescriptions = map[ErrorKind]string{
+	ErrorKindGeoRestricted:        "The content isn't available in your region",
+	ErrorKindSubscriptionRequired: "The content requires a Hotstar subscription (or) a higher plan",
+	ErrorKindLoginRequired:        "The content requires a signed in Hotstar account. Pass the user token with --token (or) the browser cookies with --cookies",
+	ErrorKindContentRemoved:       "The content was removed (or) is no longer available",
+	ErrorKindRateLimited:          "Too many requests to Hotstar. Try again later",
+	ErrorKindDRMProtected:         "The content is DRM Protected",
+	ErrorKindNotStarted:           "The live stream (or) the content is scheduled and hasn't started yet",
+}
+
+//words of the api messages telling the kind of error, checked in order
+var errorKindMessageWords = []struct {
+	kind  ErrorKind
+	words []string
+}{
+	{ErrorKindRateLimited, []string{"too many requests", "rate limit"}},
+	{ErrorKindGeoRestricted, []string{"region", "country", "geo", "location"}},
+	{ErrorKindLoginRequired, []string{"login", "log in", "sign in", "signin"}},
+	{ErrorKindSubscriptionRequired, []string{"subscri", "premium", "upgrade", "vip"}},
+	{ErrorKindDRMProtected, []string{"drm", "widevine", "playready", "fairplay"}},
+	{ErrorKindNotStarted, []string{"not started", "not yet started", "yet to start", "hasn't started", "upcoming", "scheduled", "coming soon"}},
+	{ErrorKindContentRemoved, []string{"not found", "no longer available", "removed", "unavailable", "does not exist"}},
+}
+
+//errors of each kind, to be matched with errors.Is
+var (
+	ErrGeoRestricted        = &APIError{Kind: ErrorKindGeoRestricted}
+	ErrSubscriptionRequired = &APIError{Kind: ErrorKindSubscriptionRequired}
+	ErrLoginRequired        = &APIError{Kind: ErrorKindLoginRequired}
+	ErrContentRemoved       = &APIError{Kind: ErrorKindContentRemoved}
+	ErrRateLimited          = &APIError{Kind: ErrorKindRateLimited}
+	ErrDRMProtected         = &APIError{Kind: ErrorKindDRMProtected}
+	ErrNotStarted           = &APIError{Kind: ErrorKindNotStarted}
+)
+
+//APIError is a classified refusal of a Hotstar api.
+type APIError struct {
+	Kind       ErrorKind
+	Endpoint   string
+	StatusCode int    //HTTP status code, 0 when the api responded with 200
+	Code       string //error code of the api, if any
+	Message    string //message of the api, if any
+}
+
+func (apiError *APIError) Error() string {
+	var errorStr strings.Builder
+	errorStr.WriteString(errorKindDescriptions[apiError.Kind])
+
+	details := make([]string, 0)
+	if apiError.Endpoint != "" {
+		details = append(details, apiError.Endpoint)
+	}
+	if apiError.StatusCode != 0 {
+		details = append(details, fmt.Sprintf("status %d", apiError.StatusCode))
+	}
+	if apiError.Code != "" {
+		details = append(details, apiError.Code)
+	}
+	if apiError.Message != "" {
+		details = append(details, fmt.Sprintf("%q", apiError.Message))
+	}
+	if len(details) != 0 {
+		errorStr.WriteString(fmt.Sprintf(" (%s)", strings.Join(details, ", ")))
+	}
+	return errorStr.String()
+}
+
+//Is matches the errors of the same kind.
+func (apiError *APIError) Is(target error) bool {
+	targetError, isAPIError := target.(*APIError)
+	return isAPIError && targetError.Kind == apiError.Kind
+}
+
+//GetErrorKind returns the kind of the API error wrapped in the given error, empty if none.
+func GetErrorKind(err error) ErrorKind {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.Kind
+	}
+	return ""
+}
+
+//ParseErrorKinds parses the comma separated error kinds.
+func ParseErrorKinds(kinds string) ([]ErrorKind, error) {
+	errorKinds := make([]ErrorKind, 0)
+	for _, kind := range strings.Split(kinds, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" {
+			continue
+		}
+		if _, isKnown := errorKindDescriptions[ErrorKind(kind)]; !isKnown {
+			return nil, fmt.Errorf("Invalid error kind %s. Should be one of %s", kind, strings.Join(GetErrorKindNames(), ", "))
+		}
+		errorKinds = append(errorKinds, ErrorKind(kind))
+	}
+	return errorKinds, nil
+}
+
+//GetErrorKindNames returns the names of the error kinds.
+func GetErrorKindNames() []string {
+	return []string{
+		string(ErrorKindGeoRestricted),
+		string(ErrorKindSubscriptionRequired),
+		string(ErrorKindLoginRequired),
+		string(ErrorKindContentRemoved),
+		string(ErrorKindRateLimited),
+		string(ErrorKindDRMProtected),
+		string(ErrorKindNotStarted),
+	}
+}
+
+//classifyMessage finds the kind of error from the message (or) error code of the api
+func classifyMessage(message string) ErrorKind {
+	message = strings.ToLower(message)
+	for _, kindWords := range errorKindMessageWords {
+		for _, word := range kindWords.words {
+			if strings.Contains(message, word) {
+				return kindWords.kind
+			}
+		}
+	}
+	return ""
+}
+
+//classifyStatusCode finds the kind of error from the HTTP status code
+func classifyStatusCode(statusCode int, isGuest bool) ErrorKind {
+	switch statusCode {
+	case 401:
+		return ErrorKindLoginRequired
+	case 403:
+		//a guest is refused the content reserved to the signed in users
+		if isGuest {
+			return ErrorKindLoginRequired
+		}
+	case 404, 410:
+		return ErrorKindContentRemoved
+	case 429:
+		return ErrorKindRateLimited
+	case 451:
+		return ErrorKindGeoRestricted
+	}
+	return ""
+}
+
+//newAPIError classifies the refusal of the given endpoint, nil when it can't be
+func newAPIError(endpoint string, statusCode int, code string, message string, isGuest bool) *APIError {
+	kind := classifyMessage(code + " " + message)
+	if kind == "" || statusCode == 429 {
+		if statusKind := classifyStatusCode(statusCode, isGuest); statusKind != "" {
+			kind = statusKind
+		}
+	}
+	if kind == "" {
+		return nil
+	}
+	return &APIError{Kind: kind, Endpoint: endpoint, StatusCode: statusCode, Code: code, Message: message}
+}
+
+//classifyHTTPError classifies the failed request of the given endpoint from its status code and the error in the response body,
+//returning the given error as is when it can't be
+func classifyHTTPError(endpoint string, err error, body []byte, isGuest bool) error {
+	statusCode := GetHTTPStatusCode(err)
+	if statusCode == 0 {
+		return err
+	}
+
+	var errorBody struct {
+		Message     string `json:"message"`
+		ErrorCode   string `json:"errorCode"`
+		Code        string `json:"code"`
+		Description string `json:"description"`
+	}
+	json.Unmarshal(body, &errorBody)
+
+	code := errorBody.ErrorCode
+	if code == "" {
+		code = errorBody.Code
+	}
+	message := errorBody.Message
+	if message == "" {
+		message = errorBody.Description
+	}
+
+	if apiError := newAPIError(endpoint, statusCode, code, message, isGuest); apiError != nil {
+		return apiError
+	}
+	return err
+}
@@ -0,0 +1,380 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+//tokenRefreshMargin is the time before the token expiry from when the token is refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+const guestTokenURL = "https://api.hotstar.com/um/v3/users"
+
+//refreshTokenURL is the url of the refresh token api, formatted with the region twice
+const refreshTokenURL = "https://api.hotstar.com/%s/aadhar/v2/web/%s/user/refresh-token"
+
+//names of the cookies holding the user token and the device id on hotstar.com
+var userTokenCookieNames = []string{"sessionUserUP", "userUP"}
+
+const deviceIDCookieName = "deviceId"
+
+//Credentials holds the user token sent as X-HS-UserToken along with the device it was issued to.
+type Credentials struct {
+	UserToken string
+	DeviceID  string
+	Expiry    time.Time
+	IsGuest   bool
+}
+
+//userCredentials holds the credentials given by the user, nil for guest access
+var userCredentials *Credentials
+
+//currentCredentials holds the credentials last used for the API requests
+var currentCredentials *Credentials
+
+//guestTokenLifetime is assumed for the guest tokens whose expiry can't be decoded
+const guestTokenLifetime = time.Hour
+
+//maxTokenAttempts is the count of the attempts of each token request
+const maxTokenAttempts = 10
+
+//tokenRetryDelay is the delay before the first retry of a token request, doubled for each retry after it up to maxTokenRetryDelay
+const tokenRetryDelay = 250 * time.Millisecond
+
+//maxTokenRetryDelay caps the delay between the retries of a token request
+const maxTokenRetryDelay = 2 * time.Second
+
+//getTokenRetryDelay returns the delay before the given retry of a token request, from 1
+func getTokenRetryDelay(retry int) time.Duration {
+	if delay := tokenRetryDelay << uint(retry-1); retry <= 4 && delay < maxTokenRetryDelay {
+		return delay
+	}
+	return maxTokenRetryDelay
+}
+
+//NetscapeCookie is an entry of a Netscape cookies file.
+type NetscapeCookie struct {
+	Domain  string
+	Path    string
+	Secure  bool
+	Expires int64
+	Name    string
+	Value   string
+}
+
+//ParseNetscapeCookies parses the cookies in Netscape cookies file format as exported by browser extensions (or) curl.
+func ParseNetscapeCookies(reader io.Reader) ([]NetscapeCookie, error) {
+	cookies := make([]NetscapeCookie, 0)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab separated fields but got %d", lineNumber, len(fields))
+		}
+
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %s", lineNumber, fields[4])
+		}
+
+		cookies = append(cookies, NetscapeCookie{
+			Domain:  fields[0],
+			Path:    fields[2],
+			Secure:  strings.EqualFold(fields[3], "TRUE"),
+			Expires: expires,
+			Name:    fields[5],
+			Value:   fields[6],
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cookies, nil
+}
+
+//GetCredentialsFromCookies gets the credentials from the hotstar.com cookies.
+func GetCredentialsFromCookies(cookies []NetscapeCookie) (*Credentials, error) {
+	values := make(map[string]string)
+	for _, cookie := range cookies {
+		if strings.HasSuffix(strings.TrimPrefix(cookie.Domain, "."), "hotstar.com") {
+			values[cookie.Name] = cookie.Value
+		}
+	}
+
+	for _, cookieName := range userTokenCookieNames {
+		if userToken, isPresent := values[cookieName]; isPresent && userToken != "" {
+			return NewCredentials(userToken, values[deviceIDCookieName])
+		}
+	}
+
+	return nil, fmt.Errorf("No hotstar.com user token cookie (%s) found. Make sure to export the cookies after signing in", strings.Join(userTokenCookieNames, ", "))
+}
+
+//NewCredentials creates the credentials for the given user token, generating a device id when none is given.
+func NewCredentials(userToken string, deviceID string) (*Credentials, error) {
+	expiry, err := GetJWTExpiry(userToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid user token")
+	}
+
+	if deviceID == "" {
+		deviceID = uuid.New().String()
+	}
+
+	return &Credentials{UserToken: userToken, DeviceID: deviceID, Expiry: expiry}, nil
+}
+
+//GetJWTExpiry decodes the expiry (exp claim) of the given JWT.
+func GetJWTExpiry(token string) (time.Time, error) {
+	tokenParts := strings.Split(token, ".")
+	if len(tokenParts) != 3 {
+		return time.Time{}, fmt.Errorf("expected 3 dot separated parts in JWT but got %d", len(tokenParts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(tokenParts[1], "="))
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid JWT payload encoding")
+	}
+
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid JWT payload")
+	}
+
+	if claims.Exp == nil {
+		return time.Time{}, errors.New("JWT has no exp claim")
+	}
+
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid JWT exp claim")
+	}
+
+	return time.Unix(int64(exp), 0), nil
+}
+
+//IsExpiring tells whether the token expires within the given duration.
+func (credentials *Credentials) IsExpiring(within time.Duration) bool {
+	return !credentials.Expiry.IsZero() && time.Now().Add(within).After(credentials.Expiry)
+}
+
+//LoadCredentials loads the credentials from the given user token (or) Netscape cookies file. Both empty means guest access.
+func LoadCredentials(userToken string, cookiesFilePath string) (*Credentials, error) {
+	if userToken != "" {
+		return NewCredentials(userToken, "")
+	}
+
+	if cookiesFilePath == "" {
+		return nil, nil
+	}
+
+	cookiesFile, err := os.Open(cookiesFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer cookiesFile.Close()
+
+	cookies, err := ParseNetscapeCookies(cookiesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid cookies file %s", cookiesFilePath)
+	}
+
+	return GetCredentialsFromCookies(cookies)
+}
+
+//SetCredentials sets the credentials used for the API requests. nil means guest access.
+func SetCredentials(credentials *Credentials) {
+	userCredentials = credentials
+	currentCredentials = nil
+}
+
+//getCacheKey returns the key the current credentials are cached under, the guest tokens being issued per region
+func getCacheKey() string {
+	if userCredentials == nil {
+		if region != DefaultRegion {
+			return GuestCacheKey + "-" + region
+		}
+		return GuestCacheKey
+	}
+	return GetCacheKey(userCredentials.UserToken)
+}
+
+//getGuestToken gets a guest token for the given device, a new device id is generated when none is given
+func getGuestToken(deviceID string) (*Credentials, error) {
+	var result struct {
+		UserIdentity string `json:"user_identity"`
+	}
+
+	if deviceID == "" {
+		deviceID = uuid.New().String()
+	}
+	requestBody := fmt.Sprintf(`{"device_ids":[{"id":"%s","type":"device_id"}]}`, deviceID)
+	requestHeaders := map[string]string{
+		"hotstarauth":    GenerateHotstarAuth(),
+		"x-hs-platform":  "PCTV",
+		"Content-Type":   "application/json",
+		"X-Country-Code": getCountryCode(),
+	}
+
+	var contentBytes []byte
+	var err error
+	for attempt := 1; attempt <= maxTokenAttempts; attempt++ {
+		contentBytes, err = makeAPIRequest("PUT", guestTokenURL, requestHeaders, bytes.NewBufferString(requestBody))
+		//the refusals like the rate limiting aren't retried
+		if statusCode := GetHTTPStatusCode(err); err == nil || (statusCode >= 400 && statusCode < 500) || attempt == maxTokenAttempts {
+			break
+		}
+		logVerbose("Retrying the guest token request", "attempt", attempt, "error", err)
+		time.Sleep(getTokenRetryDelay(attempt))
+	}
+	if err != nil {
+		if classifiedErr := classifyHTTPError(guestTokenEndpoint, err, contentBytes, true); GetErrorKind(classifiedErr) == ErrorKindRateLimited {
+			return nil, classifiedErr
+		}
+		return nil, errors.Wrap(err, "Error in requesting guest token")
+	}
+
+	if err := decodeResponse(guestTokenEndpoint, contentBytes, &result); err != nil {
+		return nil, err
+	}
+	if result.UserIdentity == "" {
+		return nil, missingFieldError(guestTokenEndpoint, "user_identity")
+	}
+
+	expiry, err := GetJWTExpiry(result.UserIdentity)
+	if err != nil {
+		expiry = time.Now().Add(guestTokenLifetime)
+	}
+	return &Credentials{UserToken: result.UserIdentity, DeviceID: deviceID, Expiry: expiry, IsGuest: true}, nil
+}
+
+func refreshCredentials(credentials *Credentials, referer string) (*Credentials, error) {
+	var result struct {
+		Description struct {
+			UserIdentity string `json:"userIdentity"`
+		} `json:"description"`
+	}
+
+	requestHeaders := map[string]string{
+		"hotstarauth":    GenerateHotstarAuth(),
+		"userIdentity":   credentials.UserToken,
+		"Origin":         "https://www.hotstar.com",
+		"deviceId":       credentials.DeviceID,
+		"Referer":        referer,
+		"X-Country-Code": getCountryCode(),
+	}
+
+	var contentBytes []byte
+	var err error
+	for attempt := 1; attempt <= maxTokenAttempts; attempt++ {
+		contentBytes, err = makeAPIGetRequest(getRefreshTokenURL(), requestHeaders)
+		if statusCode := GetHTTPStatusCode(err); err == nil || statusCode == 401 || statusCode == 403 || statusCode == 429 || attempt == maxTokenAttempts {
+			break
+		}
+		logVerbose("Retrying the token refresh request", "attempt", attempt, "error", err)
+		time.Sleep(getTokenRetryDelay(attempt))
+	}
+	if err != nil {
+		if credentials.IsExpiring(0) {
+			return nil, errors.Wrap(ErrLoginRequired, "The user token expired and couldn't be refreshed. Sign in again")
+		}
+		if classifiedErr := classifyHTTPError(refreshTokenEndpoint, err, contentBytes, false); GetErrorKind(classifiedErr) != "" {
+			return nil, classifiedErr
+		}
+		return nil, errors.Wrap(err, "Error in refreshing user token")
+	}
+
+	if err := decodeResponse(refreshTokenEndpoint, contentBytes, &result); err != nil {
+		return nil, err
+	}
+	if result.Description.UserIdentity == "" {
+		return nil, missingFieldError(refreshTokenEndpoint, "description.userIdentity")
+	}
+
+	return NewCredentials(result.Description.UserIdentity, credentials.DeviceID)
+}
+
+//obtainCredentials gets fresh credentials starting from the given previously obtained ones (nil when none)
+func obtainCredentials(previous *Credentials, referer string) (*Credentials, error) {
+	if userCredentials == nil {
+		deviceID := ""
+		if previous != nil {
+			deviceID = previous.DeviceID
+		}
+		return getGuestToken(deviceID)
+	}
+
+	credentials := userCredentials
+	if previous != nil && previous.Expiry.After(credentials.Expiry) {
+		credentials = previous
+	}
+
+	if !credentials.IsExpiring(tokenRefreshMargin) {
+		return credentials, nil
+	}
+
+	return refreshCredentials(credentials, referer)
+}
+
+//getCredentials returns the credentials for the API requests. They are reused (from the token cache when enabled) and
+//refreshed only when about to expire.
+func getCredentials(referer string) (*Credentials, error) {
+	if currentCredentials != nil && !currentCredentials.IsExpiring(tokenRefreshMargin) {
+		return currentCredentials, nil
+	}
+
+	refresh := func(cached *Credentials) (*Credentials, error) {
+		if cached == nil {
+			cached = currentCredentials
+		}
+		return obtainCredentials(cached, referer)
+	}
+
+	var credentials *Credentials
+	var err error
+	if tokenCache != nil {
+		isRefreshError := false
+		credentials, err = tokenCache.GetOrRefresh(getCacheKey(), func(cached *Credentials) (*Credentials, error) {
+			refreshedCredentials, refreshErr := refresh(cached)
+			isRefreshError = refreshErr != nil
+			return refreshedCredentials, refreshErr
+		})
+		if err != nil && !isRefreshError {
+			logInfo("Warning: Token cache %s is unusable, %s\n", tokenCache.Path, err)
+			credentials, err = refresh(nil)
+		}
+	} else {
+		credentials, err = refresh(nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	currentCredentials = credentials
+	return credentials, nil
+}
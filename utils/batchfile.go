@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+//ReadBatchURLs reads the urls from the given reader, one per line, skipping blank lines and comments starting with #, ; (or) ].
+func ReadBatchURLs(reader io.Reader) ([]string, error) {
+	urls := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "]") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+//ReadBatchFile reads the urls from the given batch file. The path "-" reads from the standard input.
+func ReadBatchFile(batchFilePath string) ([]string, error) {
+	if batchFilePath == "-" {
+		return ReadBatchURLs(os.Stdin)
+	}
+
+	batchFile, err := os.Open(batchFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer batchFile.Close()
+
+	return ReadBatchURLs(batchFile)
+}
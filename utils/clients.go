@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//DefaultClientName is the client profile used when none is selected
+const DefaultClientName = "web"
+
+//ClientProfile bundles the headers, playback query parameters and desired config sent by a Hotstar client.
+type ClientProfile struct {
+	Name          string
+	Description   string
+	Headers       map[string]string
+	ClientName    string //client query parameter of the v2 playback api
+	ClientVersion string
+	OSName        string
+	OSVersion     string
+	DesiredConfig string
+}
+
+var clientProfiles = map[string]*ClientProfile{
+	"web": {
+		Name:        "web",
+		Description: "Desktop browser",
+		Headers: map[string]string{
+			"X-Platform-Code": "JIO",
+			"X-HS-Platform":   "web",
+			"X-HS-AppVersion": "6.72.2",
+			"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/74.0.3729.122 Safari/537.36",
+		},
+		//the desktop player requests the v2 playback api as mweb
+		ClientName:    "mweb",
+		ClientVersion: "6.18.0",
+		OSName:        "Windows",
+		OSVersion:     "10",
+		DesiredConfig: "encryption:plain;ladder:phone,tv;package:hls,dash",
+	},
+	"mweb": {
+		Name:        "mweb",
+		Description: "Mobile browser",
+		Headers: map[string]string{
+			"X-Platform-Code": "MWEB",
+			"X-HS-Platform":   "mweb",
+			"X-HS-AppVersion": "6.72.2",
+			"User-Agent":      "Mozilla/5.0 (Linux; Android 10; SM-G973F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/83.0.4103.106 Mobile Safari/537.36",
+		},
+		ClientName:    "mweb",
+		ClientVersion: "6.18.0",
+		OSName:        "Android",
+		OSVersion:     "10",
+		DesiredConfig: "encryption:plain;ladder:phone;package:hls,dash",
+	},
+	"android": {
+		Name:        "android",
+		Description: "Android app",
+		Headers: map[string]string{
+			"X-Platform-Code": "ANDROID",
+			"X-HS-Platform":   "android",
+			"X-HS-AppVersion": "11.5.1",
+			"User-Agent":      "Hotstar;in.startv.hotstar/11.5.1 (Android/10)",
+		},
+		ClientName:    "android",
+		ClientVersion: "11.5.1",
+		OSName:        "Android",
+		OSVersion:     "10",
+		DesiredConfig: "encryption:plain;ladder:phone;package:hls,dash",
+	},
+	"tv": {
+		Name:        "tv",
+		Description: "Android TV app",
+		Headers: map[string]string{
+			"X-Platform-Code": "ANDROIDTV",
+			"X-HS-Platform":   "androidtv",
+			"X-HS-AppVersion": "7.6.0",
+			"User-Agent":      "Hotstar;in.startv.hotstar.tv/7.6.0 (Android/9)",
+		},
+		ClientName:    "androidtv",
+		ClientVersion: "7.6.0",
+		OSName:        "Android",
+		OSVersion:     "9",
+		DesiredConfig: "encryption:plain;ladder:tv;package:hls,dash",
+	},
+}
+
+//clientProfile is the client profile used for the requests
+var clientProfile = clientProfiles[DefaultClientName]
+
+//GetClientProfileNames returns the names of the known client profiles.
+func GetClientProfileNames() []string {
+	names := make([]string, 0, len(clientProfiles))
+	for name := range clientProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//GetClientProfile returns a copy of the client profile with the given name, which can be customized before setting it.
+func GetClientProfile(name string) (*ClientProfile, error) {
+	profile, isPresent := clientProfiles[strings.ToLower(name)]
+	if !isPresent {
+		return nil, fmt.Errorf("Invalid client %s. Should be one of %s", name, strings.Join(GetClientProfileNames(), ", "))
+	}
+	return profile.Clone(), nil
+}
+
+//Clone returns a copy of the profile that can be modified without affecting the original.
+func (profile *ClientProfile) Clone() *ClientProfile {
+	clone := *profile
+	clone.Headers = make(map[string]string, len(profile.Headers))
+	for headerName, headerValue := range profile.Headers {
+		clone.Headers[headerName] = headerValue
+	}
+	return &clone
+}
+
+//SetHeader sets the header of the profile, replacing the one of the same name in any case so that only one of them is sent.
+func (profile *ClientProfile) SetHeader(name string, value string) {
+	name = http.CanonicalHeaderKey(name)
+	for headerName := range profile.Headers {
+		if http.CanonicalHeaderKey(headerName) == name {
+			delete(profile.Headers, headerName)
+		}
+	}
+	profile.Headers[name] = value
+}
+
+//SetClientProfile sets the client profile used for the requests. nil restores the default one.
+func SetClientProfile(profile *ClientProfile) {
+	if profile == nil {
+		profile = clientProfiles[DefaultClientName]
+	}
+	clientProfile = profile
+}
+
+//ParseHeader parses a header given as "Name: Value", with the name in its canonical form like X-Country-Code.
+func ParseHeader(header string) (string, string, error) {
+	headerParts := strings.SplitN(header, ":", 2)
+	if len(headerParts) != 2 || strings.TrimSpace(headerParts[0]) == "" {
+		return "", "", fmt.Errorf("Invalid header '%s'. Should be of form Name:Value", header)
+	}
+	return http.CanonicalHeaderKey(strings.TrimSpace(headerParts[0])), strings.TrimSpace(headerParts[1]), nil
+}
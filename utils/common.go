@@ -43,7 +43,9 @@ func GetDateStr(timeFloat64 float64) string {
 	}
 	location, err := time.LoadLocation("Asia/Kolkata")
 	if err != nil {
-		panic(err)
+		//the time zone database may be missing, India has no daylight saving time
+		logDebug("Using the fixed IST offset", "error", err)
+		location = time.FixedZone("IST", 5*60*60+30*60)
 	}
 	return time.Unix(0, timeMillis*int64(time.Millisecond)).In(location).String()
 }
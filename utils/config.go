@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//EnvPrefix is the prefix of the environment variables overriding the options
+const EnvPrefix = "HOTSTAR_DL_"
+
+//Config holds the option values read from a config file. Values outside of any section apply to every profile.
+type Config struct {
+	Path     string
+	sections map[string]map[string][]string
+}
+
+//GetConfigDir returns the directory holding the config file, following the XDG base directory spec.
+func GetConfigDir() (string, error) {
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, "hotstar-dl"), nil
+	}
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userConfigDir, "hotstar-dl"), nil
+}
+
+//GetDefaultConfigPath returns the path of the config file loaded when none is given.
+func GetDefaultConfigPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config"), nil
+}
+
+//ParseConfig parses the config contents made of "option = value" lines grouped in optional [profile] sections.
+//Comments start with # (or) ; and boolean options can be given without a value.
+func ParseConfig(reader io.Reader) (*Config, error) {
+	config := &Config{sections: map[string]map[string][]string{"": {}}}
+	section := ""
+	scanner := bufio.NewScanner(reader)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return nil, fmt.Errorf("line %d: invalid section %s", lineNumber, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, isPresent := config.sections[section]; !isPresent {
+				config.sections[section] = make(map[string][]string)
+			}
+			continue
+		}
+
+		name, value := line, "true"
+		if separatorIndex := strings.IndexAny(line, "= \t"); separatorIndex != -1 {
+			name = line[:separatorIndex]
+			value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[separatorIndex:]), "="))
+			value = strings.Trim(value, `"`)
+		}
+		name = strings.TrimLeft(name, "-")
+
+		if name == "" {
+			return nil, fmt.Errorf("line %d: missing option name", lineNumber)
+		}
+
+		config.sections[section][name] = append(config.sections[section][name], value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+//LoadConfig loads the config file in the given path.
+func LoadConfig(configPath string) (*Config, error) {
+	configFile, err := os.Open(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer configFile.Close()
+
+	config, err := ParseConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", configPath, err)
+	}
+	config.Path = configPath
+	return config, nil
+}
+
+//Profiles returns the profile sections of the config.
+func (config *Config) Profiles() []string {
+	profiles := make([]string, 0)
+	for section := range config.sections {
+		if section != "" {
+			profiles = append(profiles, section)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+//Options returns the option values of the given profile, falling back to the values outside of any section.
+func (config *Config) Options(profile string) (map[string][]string, error) {
+	options := make(map[string][]string)
+	for name, values := range config.sections[""] {
+		options[name] = values
+	}
+
+	if profile != "" {
+		profileOptions, isPresent := config.sections[profile]
+		if !isPresent {
+			return nil, fmt.Errorf("profile %s not found in %s. Available profiles: %s", profile, config.Path, strings.Join(config.Profiles(), ", "))
+		}
+		for name, values := range profileOptions {
+			options[name] = values
+		}
+	}
+
+	return options, nil
+}
+
+//GetEnvName returns the environment variable overriding the given option, like HOTSTAR_DL_ADD_METADATA for add-metadata.
+func GetEnvName(optionName string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(optionName, "-", "_", -1))
+}
+
+//GetEnvOptions returns the values of the given options set through environment variables.
+func GetEnvOptions(optionNames []string) map[string][]string {
+	options := make(map[string][]string)
+	for _, optionName := range optionNames {
+		if value, isSet := os.LookupEnv(GetEnvName(optionName)); isSet {
+			options[optionName] = []string{value}
+		}
+	}
+	return options
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//maxStreamBufferSize limits the size of the segments downloaded ahead of the one appended next, held in memory meanwhile
+const maxStreamBufferSize = 64 << 20
+
+//concurrentFragments is the count of the segments downloaded at once
+var concurrentFragments = 1
+
+//SetConcurrentFragments sets the count of the DASH segments (or) the encrypted HLS segments downloaded at once.
+func SetConcurrentFragments(count int) {
+	if count < 1 {
+		count = 1
+	}
+	concurrentFragments = count
+}
+
+//getSegmentName names the segment of the given index in the errors, the init segment coming first followed by the media segments
+//numbered from the given first segment
+func getSegmentName(index int, firstSegment int) string {
+	if index == 0 {
+		return "init segment"
+	}
+	return fmt.Sprintf("segment %d", firstSegment+index-1)
+}
+
+//streamSegments downloads the given segments, the init segment followed by the media segments numbered from the given first segment,
+//with the given count of concurrent downloads and writes them in order to the writer. The segments downloaded ahead of the one written
+//next are held in memory up to the given size, pausing the downloads beyond it. On error, the segments before the failed one are written.
+func streamSegments(writer io.Writer, segmentIDs []string, firstSegment int, concurrency int, bufferLimit int, read func(segmentID string) ([]byte, error), onWritten func(index int, size int)) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mutex sync.Mutex
+	cond := sync.NewCond(&mutex)
+	buffered := make(map[int][]byte)
+	bufferedSize := 0
+	nextToRead, nextToWrite := 0, 0
+	var firstErr error
+
+	var workers sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			mutex.Lock()
+			defer mutex.Unlock()
+			for {
+				//the segment written next is always being read (or) buffered, so the buffer drains eventually
+				for firstErr == nil && nextToRead < len(segmentIDs) && bufferedSize >= bufferLimit {
+					cond.Wait()
+				}
+				if firstErr != nil || nextToRead >= len(segmentIDs) {
+					return
+				}
+				index := nextToRead
+				nextToRead++
+
+				mutex.Unlock()
+				data, err := read(segmentIDs[index])
+				mutex.Lock()
+
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("Error in downloading %s: %s", getSegmentName(index, firstSegment), err)
+					}
+				} else {
+					buffered[index] = data
+					bufferedSize += len(data)
+				}
+				cond.Broadcast()
+			}
+		}()
+	}
+
+	mutex.Lock()
+	for nextToWrite < len(segmentIDs) {
+		data, isBuffered := buffered[nextToWrite]
+		if !isBuffered {
+			if firstErr != nil {
+				break
+			}
+			cond.Wait()
+			continue
+		}
+		delete(buffered, nextToWrite)
+
+		mutex.Unlock()
+		_, writeErr := writer.Write(data)
+		mutex.Lock()
+
+		bufferedSize -= len(data)
+		if writeErr != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("Error in writing %s: %s", getSegmentName(nextToWrite, firstSegment), writeErr)
+			}
+			cond.Broadcast()
+			break
+		}
+		if onWritten != nil {
+			onWritten(nextToWrite, len(data))
+		}
+		nextToWrite++
+		cond.Broadcast()
+	}
+	err := firstErr
+	mutex.Unlock()
+
+	workers.Wait()
+	return err
+}
+
+//getDashSegmentIDs returns the init segment followed by the media segments of the format
+func getDashSegmentIDs(format map[string]string) []string {
+	firstSegment, lastSegment := getDashSegmentRange(format)
+	segmentIDs := make([]string, 0, lastSegment-firstSegment+2)
+	segmentIDs = append(segmentIDs, format["INIT-URL"])
+	for _, segmentNum := range MakeRange(firstSegment, lastSegment) {
+		segmentIDs = append(segmentIDs, strings.Replace(format["STREAM-URL"], "$Number$", fmt.Sprintf("%d", segmentNum), -1))
+	}
+	return segmentIDs
+}
+
+//getDashSegmentRange returns the numbers of the first and the last segments of the format to download, all of them unless it is limited
+//to a section with GetDashSectionFormat
+func getDashSegmentRange(format map[string]string) (int, int) {
+	totalSegments, _ := strconv.Atoi(format["TOTAL-SEGMENTS"])
+	firstSegment, lastSegment := 1, totalSegments
+	if segmentNum, err := strconv.Atoi(format["FIRST-SEGMENT"]); err == nil && segmentNum > firstSegment {
+		firstSegment = segmentNum
+	}
+	if segmentNum, err := strconv.Atoi(format["LAST-SEGMENT"]); err == nil && segmentNum < lastSegment {
+		lastSegment = segmentNum
+	}
+	if lastSegment < firstSegment {
+		lastSegment = firstSegment - 1
+	}
+	return firstSegment, lastSegment
+}
+
+//DownloadDashStream downloads the init and media segments of the first of the given formats and appends them in order to the given
+//file, giving a single fragmented MP4 of the format of the given code. The segments are downloaded concurrently as set with SetConcurrentFragments,
+//fetching a segment failing to download from the other formats serving it and refreshing the signed playback urls when they expire
+//like DownloadDashFilesBatch. The segments are checked with VerifyMP4Segment, those received corrupt being downloaded again.
+func DownloadDashStream(filePath string, formatCode string, formats []map[string]string, requestHeaders map[string]string, refreshFormats FormatsRefresher) error {
+	format := formats[0]
+	segmentIDs := getDashSegmentIDs(format)
+	firstSegment, _ := getDashSegmentRange(format)
+
+	source := &dashSegmentSource{
+		format:         format,
+		playbackURLs:   getSegmentPlaybackURLs(format, formats[1:]),
+		requestHeaders: requestHeaders,
+		refreshFormats: refreshFormats,
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	logInfo("\nDownloading DASH chunks to %s\n", filePath)
+
+	var writtenBytes int64
+	err = streamSegments(file, segmentIDs, firstSegment, concurrentFragments, maxStreamBufferSize, source.read, func(index int, size int) {
+		writtenBytes += int64(size)
+		if index != 0 {
+			reportProgress(ProgressEvent{Type: EventSegmentDone, Format: formatCode, Segment: index, TotalSegments: len(segmentIDs) - 1, Bytes: writtenBytes})
+		}
+	})
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
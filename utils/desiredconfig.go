@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//dimensions of the desired config sent with the playback request
+const (
+	DimensionVideoCodec   = "video_codec"
+	DimensionDynamicRange = "dynamic_range"
+	DimensionAudioCodec   = "audio_codec"
+	DimensionLadder       = "ladder"
+	DimensionResolution   = "resolution"
+	DimensionContainer    = "container"
+	DimensionPackage      = "package"
+	DimensionEncryption   = "encryption"
+)
+
+//desiredConfigValues holds the values known for each dimension of the desired config
+var desiredConfigValues = map[string][]string{
+	DimensionVideoCodec:   {"h264", "h265", "vp9"},
+	DimensionDynamicRange: {"sdr", "hdr10", "dv"},
+	DimensionAudioCodec:   {"aac", "ec3", "ac4"},
+	DimensionLadder:       {"phone", "tv", "full"},
+	DimensionResolution:   {"sd", "hd", "fhd", "4k"},
+	DimensionContainer:    {"ts", "fmp4"},
+	DimensionPackage:      {"hls", "dash"},
+	DimensionEncryption:   {"plain"},
+}
+
+//splitDimensions are answered with the playBackSets of a single value, so a playback request is made for each combination of their values
+var splitDimensions = []string{DimensionVideoCodec, DimensionDynamicRange}
+
+var desiredConfigQueryRegex = regexp.MustCompile(`((?:desired-config|desiredConfig)=)[^&]*`)
+
+//DesiredConfig holds the values requested for each dimension of the desired config like "encryption:plain;ladder:phone,tv;package:hls,dash".
+type DesiredConfig map[string][]string
+
+//ParseDesiredConfig parses the desired config of a playback request.
+func ParseDesiredConfig(desiredConfig string) (DesiredConfig, error) {
+	config := make(DesiredConfig)
+	for _, dimension := range strings.Split(desiredConfig, ";") {
+		if strings.TrimSpace(dimension) == "" {
+			continue
+		}
+		dimensionParts := strings.SplitN(dimension, ":", 2)
+		if len(dimensionParts) != 2 || dimensionParts[0] == "" || dimensionParts[1] == "" {
+			return nil, fmt.Errorf("Invalid desired config dimension '%s'. Should be of form name:value[,value...]", dimension)
+		}
+		config[dimensionParts[0]] = strings.Split(dimensionParts[1], ",")
+	}
+	return config, nil
+}
+
+//GetDesiredConfigValues returns the values known for the given dimension.
+func GetDesiredConfigValues(dimension string) []string {
+	return desiredConfigValues[dimension]
+}
+
+//Set sets the values requested for the given dimension after validating them.
+func (config DesiredConfig) Set(dimension string, values []string) error {
+	knownValues, isKnownDimension := desiredConfigValues[dimension]
+	if !isKnownDimension {
+		return fmt.Errorf("Invalid desired config dimension %s", dimension)
+	}
+
+	requestedValues := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if !containsString(knownValues, value) {
+			return fmt.Errorf("Invalid %s %s. Should be one of %s", strings.Replace(dimension, "_", " ", -1), value, strings.Join(knownValues, ", "))
+		}
+		if !containsString(requestedValues, value) {
+			requestedValues = append(requestedValues, value)
+		}
+	}
+
+	config[dimension] = requestedValues
+	return nil
+}
+
+//String formats the desired config with the dimensions sorted by name as the playback api expects it.
+func (config DesiredConfig) String() string {
+	dimensions := make([]string, 0, len(config))
+	for dimension := range config {
+		dimensions = append(dimensions, dimension)
+	}
+	sort.Strings(dimensions)
+
+	formattedDimensions := make([]string, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		if len(config[dimension]) != 0 {
+			formattedDimensions = append(formattedDimensions, dimension+":"+strings.Join(config[dimension], ","))
+		}
+	}
+	return strings.Join(formattedDimensions, ";")
+}
+
+//Split splits the desired config into the ones to request separately, one for each combination of the video codecs and dynamic ranges.
+func (config DesiredConfig) Split() []DesiredConfig {
+	configs := []DesiredConfig{config.clone()}
+
+	for _, dimension := range splitDimensions {
+		if len(config[dimension]) < 2 {
+			continue
+		}
+		splitConfigs := make([]DesiredConfig, 0, len(configs)*len(config[dimension]))
+		for _, splitConfig := range configs {
+			for _, value := range config[dimension] {
+				valueConfig := splitConfig.clone()
+				valueConfig[dimension] = []string{value}
+				splitConfigs = append(splitConfigs, valueConfig)
+			}
+		}
+		configs = splitConfigs
+	}
+
+	return configs
+}
+
+func (config DesiredConfig) clone() DesiredConfig {
+	clone := make(DesiredConfig, len(config))
+	for dimension, values := range config {
+		clone[dimension] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+//getDesiredConfigs returns the desired configs to request the playback with for the client profile in use
+func getDesiredConfigs() ([]string, error) {
+	config, err := ParseDesiredConfig(clientProfile.DesiredConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	splitConfigs := config.Split()
+	if len(splitConfigs) == 1 {
+		//keep the desired config as given when a single request does
+		return []string{clientProfile.DesiredConfig}, nil
+	}
+
+	desiredConfigs := make([]string, 0, len(splitConfigs))
+	for _, splitConfig := range splitConfigs {
+		desiredConfigs = append(desiredConfigs, splitConfig.String())
+	}
+	return desiredConfigs, nil
+}
+
+//setDesiredConfig replaces the desired config in the given playback uri
+func setDesiredConfig(playbackURI string, desiredConfig string) string {
+	return desiredConfigQueryRegex.ReplaceAllString(playbackURI, "${1}"+strings.Replace(desiredConfig, "$", "$$", -1))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+//Statuses of the doctor checks
+const (
+	DoctorCheckOK      = "OK"
+	DoctorCheckWarning = "WARN"
+	DoctorCheckFailed  = "FAIL"
+)
+
+//DoctorCheck is the result of a single environment check.
+type DoctorCheck struct {
+	Name    string
+	Status  string
+	Message string
+}
+
+func checkExecutable(name string, userPath string, isRequired bool) DoctorCheck {
+	path := userPath
+	if strings.TrimSpace(path) == "" {
+		lookedUpPath, err := exec.LookPath(name)
+		if err != nil {
+			status := DoctorCheckWarning
+			if isRequired {
+				status = DoctorCheckFailed
+			}
+			return DoctorCheck{Name: name, Status: status, Message: fmt.Sprintf("%s not found in PATH", name)}
+		}
+		path = lookedUpPath
+	}
+
+	output, err := exec.Command(path, "-version").Output()
+	if err != nil {
+		return DoctorCheck{Name: name, Status: DoctorCheckFailed, Message: fmt.Sprintf("%s -version failed: %s", path, err)}
+	}
+
+	versionLine := strings.TrimSpace(string(bytes.SplitN(output, []byte("\n"), 2)[0]))
+	return DoctorCheck{Name: name, Status: DoctorCheckOK, Message: fmt.Sprintf("%s (%s)", versionLine, path)}
+}
+
+func checkWorkingDirectory() DoctorCheck {
+	currentDirectoryPath, err := os.Getwd()
+	if err != nil {
+		return DoctorCheck{Name: "working directory", Status: DoctorCheckFailed, Message: err.Error()}
+	}
+
+	tempFile, err := ioutil.TempFile(currentDirectoryPath, ".hotstar-dl-doctor-")
+	if err != nil {
+		return DoctorCheck{Name: "working directory", Status: DoctorCheckFailed, Message: fmt.Sprintf("%s is not writable: %s", currentDirectoryPath, err)}
+	}
+	tempFile.Close()
+	os.Remove(tempFile.Name())
+
+	return DoctorCheck{Name: "working directory", Status: DoctorCheckOK, Message: fmt.Sprintf("%s is writable", currentDirectoryPath)}
+}
+
+func checkHostReachable(name string, url string) DoctorCheck {
+	client := &http.Client{Timeout: 10 * time.Second}
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return DoctorCheck{Name: name, Status: DoctorCheckFailed, Message: err.Error()}
+	}
+	for headerName, headerValue := range getRequestHeaders() {
+		request.Header.Set(headerName, headerValue)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return DoctorCheck{Name: name, Status: DoctorCheckFailed, Message: err.Error()}
+	}
+	response.Body.Close()
+
+	return DoctorCheck{Name: name, Status: DoctorCheckOK, Message: fmt.Sprintf("%s responded with %s", url, response.Status)}
+}
+
+//RunDoctorChecks checks the tools, file system and network access needed for downloading.
+func RunDoctorChecks(ffmpegPath string) []DoctorCheck {
+	return []DoctorCheck{
+		checkExecutable("ffmpeg", ffmpegPath, true),
+		checkExecutable("ffprobe", "", false),
+		checkWorkingDirectory(),
+		checkHostReachable("hotstar website", "https://www.hotstar.com"),
+		checkHostReachable("hotstar api", "https://api.hotstar.com"),
+	}
+}
@@ -2,115 +2,338 @@ package utils
 
 import (
 	"fmt"
-	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/cheggaaa/pb/v3"
+	"github.com/pkg/errors"
 )
 
-func downloadDashFile(filepath string, url string, requestHeaders map[string]string) error {
+//signedURLRefreshMargin is the time before the expiry of the signed playback url from when it is refreshed
+const signedURLRefreshMargin = 30 * time.Second
 
-	//Create file
-	out, err := os.Create(filepath)
+//maxConsecutiveURLRefreshes limits the refreshes of the playback urls without any chunk downloaded in between
+const maxConsecutiveURLRefreshes = 3
+
+//maxCorruptSegmentRetries is the count of the times a chunk received corrupt is downloaded again
+const maxCorruptSegmentRetries = 2
+
+var signedURLExpiryRegex = regexp.MustCompile(`(?:^|[?&~=])exp=(\d+)`)
+
+//FormatsRefresher resolves the playback again to get the formats with freshly signed urls.
+type FormatsRefresher func() (map[string]map[string]string, error)
+
+//GetSignedURLExpiry gets the expiry of the token (exp= of hdnea) signing the given CDN url.
+func GetSignedURLExpiry(signedURL string) (time.Time, bool) {
+	match := signedURLExpiryRegex.FindStringSubmatch(signedURL)
+	if match == nil {
+		return time.Time{}, false
+	}
+	exp, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(exp, 0), true
+}
+
+//isSignedURLExpiring tells whether the token signing the given url expires within the given duration
+func isSignedURLExpiring(signedURL string, within time.Duration) bool {
+	expiry, isSigned := GetSignedURLExpiry(signedURL)
+	return isSigned && time.Now().Add(within).After(expiry)
+}
+
+//isExpiredURLError tells whether the CDN rejected the request for the expiry of the signed url
+func isExpiredURLError(err error) bool {
+	statusCode := GetHTTPStatusCode(err)
+	return statusCode == 403 || statusCode == 410
+}
+
+//downloadDashFile downloads the segment at the given url to the given file, which is written only once the segment is verified whole
+func downloadDashFile(filepath string, url string, requestHeaders map[string]string, isInit bool) error {
+	data, err := readDashSegment(url, requestHeaders, isInit)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	return ioutil.WriteFile(filepath, data, 0644)
+}
 
-	//Creating a custom request
-	client := &http.Client{}
-	request, _ := http.NewRequest("GET", url, nil)
+//readDashSegment downloads the segment at the given url into memory, verifying that it is received whole and holds the boxes of an init
+//(or) a media segment
+func readDashSegment(url string, requestHeaders map[string]string, isInit bool) ([]byte, error) {
+	data, err := readWholeSegment(url, requestHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyMP4Segment(data, isInit); err != nil {
+		logTrace("Chunk failed the integrity check", "url", url, "error", err)
+		return nil, err
+	}
+	return data, nil
+}
 
-	//Adding custom headers
+//readWholeSegment downloads the segment at the given url into memory, failing with ErrCorruptSegment when its body is cut short
+func readWholeSegment(url string, requestHeaders map[string]string) ([]byte, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
 	for requestHeaderKey, requestHeaderValue := range requestHeaders {
 		request.Header.Add(requestHeaderKey, requestHeaderValue)
 	}
 
-	//Get data
-	resp, err := client.Do(request)
+	resp, err := cdnClient.Do(request)
 	if err != nil {
-		return err
+		logTrace("Chunk request failed", "url", url, "error", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
+	logTrace("Chunk response", "url", url, "status", resp.StatusCode, "bytes", resp.ContentLength)
+
+	if resp.StatusCode != 200 {
+		return nil, &HTTPError{StatusCode: resp.StatusCode}
+	}
 
-	//Write the body to file
-	_, err = io.Copy(out, resp.Body)
+	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCorruptSegment, err)
+	}
+	if resp.ContentLength >= 0 && int64(len(data)) != resp.ContentLength {
+		return nil, fmt.Errorf("%w: received %d bytes of the %d bytes declared", ErrCorruptSegment, len(data), resp.ContentLength)
+	}
+	return data, nil
+}
+
+func getSegmentURL(playbackURL, streamID string) string {
+	return strings.Replace(playbackURL, "master.mpd", streamID, -1)
+}
+
+//getSegmentPlaybackURLs returns the playback url of the format followed by the ones of the equivalent formats serving the very same
+//segments from other playback sets (or) CDNs
+func getSegmentPlaybackURLs(format map[string]string, equivalentFormats []map[string]string) []string {
+	playbackURLs := []string{format["PLAYBACK-URL"]}
+	for _, equivalentFormat := range equivalentFormats {
+		if equivalentFormat["INIT-URL"] == format["INIT-URL"] && equivalentFormat["STREAM-URL"] == format["STREAM-URL"] &&
+			equivalentFormat["TOTAL-SEGMENTS"] == format["TOTAL-SEGMENTS"] && !containsString(playbackURLs, equivalentFormat["PLAYBACK-URL"]) {
+			playbackURLs = append(playbackURLs, equivalentFormat["PLAYBACK-URL"])
+		}
+	}
+	return playbackURLs
+}
+
+//downloadDashSegment downloads the segment from the first of the playback urls serving it
+func downloadDashSegment(filePath string, segmentID string, isInit bool, playbackURLs []string, requestHeaders map[string]string) error {
+	var err error
+	for _, playbackURL := range playbackURLs {
+		if err = downloadDashFile(filePath, getSegmentURL(playbackURL, segmentID), requestHeaders, isInit); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+//dashSegmentSource downloads the segments of a format, refreshing its signed playback urls when they expire.
+//It is shared by the concurrent segment downloads.
+type dashSegmentSource struct {
+	mutex                sync.Mutex
+	format               map[string]string
+	playbackURLs         []string
+	requestHeaders       map[string]string
+	refreshFormats       FormatsRefresher
+	consecutiveRefreshes int
+	generation           int //count of the refreshes, telling the concurrent downloads whether the urls they used are refreshed already
+}
+
+//current returns the playback urls and a copy of the request headers to download with, along with their generation
+func (source *dashSegmentSource) current() ([]string, map[string]string, int) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	return source.playbackURLs, CopyMap(source.requestHeaders), source.generation
+}
+
+//refreshFrom refreshes the playback urls of the given generation unless another download refreshed them already
+func (source *dashSegmentSource) refreshFrom(generation int) error {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	if source.generation != generation {
+		return nil
+	}
+	if err := source.refresh(); err != nil {
 		return err
 	}
+	source.generation++
+	return nil
+}
+
+//refresh resolves the playback again and switches to the fresh urls of the same representation
+func (source *dashSegmentSource) refresh() error {
+	if source.refreshFormats == nil {
+		return errors.New("The playback urls expired and can't be refreshed")
+	}
+	if source.consecutiveRefreshes >= maxConsecutiveURLRefreshes {
+		return fmt.Errorf("The playback urls keep getting rejected after %d refreshes", source.consecutiveRefreshes)
+	}
+	source.consecutiveRefreshes++
+
+	logInfo("\nRefreshing the expiring playback urls\n")
+
+	freshFormats, err := source.refreshFormats()
+	if err != nil {
+		return errors.Wrap(err, "Error in refreshing the playback urls")
+	}
+
+	freshFormatIDs := make([]string, 0)
+	for formatID, freshFormat := range freshFormats {
+		if freshFormat["INIT-URL"] == source.format["INIT-URL"] && freshFormat["STREAM-URL"] == source.format["STREAM-URL"] && freshFormat["TOTAL-SEGMENTS"] == source.format["TOTAL-SEGMENTS"] {
+			freshFormatIDs = append(freshFormatIDs, formatID)
+		}
+	}
+	if len(freshFormatIDs) == 0 {
+		return errors.New("The representation being downloaded is no longer offered by the refreshed playback")
+	}
+	sort.Strings(freshFormatIDs)
 
+	matchingFormats := make([]map[string]string, 0, len(freshFormatIDs))
+	for _, formatID := range freshFormatIDs {
+		matchingFormats = append(matchingFormats, freshFormats[formatID])
+	}
+
+	source.format = matchingFormats[0]
+	source.playbackURLs = getSegmentPlaybackURLs(matchingFormats[0], matchingFormats)
+	source.requestHeaders["Hotstarauth"] = GenerateHotstarAuth()
 	return nil
 }
 
-func getSegmentURL(playbackURL, streamID string) string {
-	return strings.Replace(playbackURL, "master.mpd", streamID, -1)
+//isInitSegment checks whether the segment is the init segment of the format
+func (source *dashSegmentSource) isInitSegment(segmentID string) bool {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	return segmentID == source.format["INIT-URL"]
+}
+
+//fetch gets the segment with the given function, refreshing the playback urls before they expire (or) once the CDN rejects them.
+//The segments received corrupt are downloaded again a few times.
+func (source *dashSegmentSource) fetch(get func(playbackURLs []string, requestHeaders map[string]string) error) error {
+	playbackURLs, requestHeaders, generation := source.current()
+	if isSignedURLExpiring(playbackURLs[0], signedURLRefreshMargin) {
+		if err := source.refreshFrom(generation); err != nil {
+			logInfo("\n%s\n", err)
+		}
+		playbackURLs, requestHeaders, generation = source.current()
+	}
+
+	err := get(playbackURLs, requestHeaders)
+	for attempt := 1; err != nil && isCorruptSegmentError(err) && attempt <= maxCorruptSegmentRetries; attempt++ {
+		logVerbose("Retrying the corrupt chunk", "attempt", attempt, "error", err)
+		err = get(playbackURLs, requestHeaders)
+	}
+	for err != nil && isExpiredURLError(err) {
+		if refreshErr := source.refreshFrom(generation); refreshErr != nil {
+			return errors.Wrap(err, refreshErr.Error())
+		}
+		playbackURLs, requestHeaders, generation = source.current()
+		err = get(playbackURLs, requestHeaders)
+	}
+
+	if err == nil {
+		source.mutex.Lock()
+		source.consecutiveRefreshes = 0
+		source.mutex.Unlock()
+	}
+	return err
+}
+
+//download downloads the segment to the given file
+func (source *dashSegmentSource) download(filePath string, segmentID string) error {
+	isInit := source.isInitSegment(segmentID)
+	return source.fetch(func(playbackURLs []string, requestHeaders map[string]string) error {
+		return downloadDashSegment(filePath, segmentID, isInit, playbackURLs, requestHeaders)
+	})
 }
 
-func raiseFileDownloadError(err error) {
-	fmt.Println("Error in downloading file. Error:", err)
-	os.Exit(-1)
+//read downloads the segment into memory
+func (source *dashSegmentSource) read(segmentID string) ([]byte, error) {
+	var data []byte
+	isInit := source.isInitSegment(segmentID)
+	err := source.fetch(func(playbackURLs []string, requestHeaders map[string]string) error {
+		var err error
+		for _, playbackURL := range playbackURLs {
+			if data, err = readDashSegment(getSegmentURL(playbackURL, segmentID), requestHeaders, isInit); err == nil {
+				return nil
+			}
+		}
+		return err
+	})
+	return data, err
 }
 
-//DownloadDashFilesBatch downloads the dash chunks for the given video format
-func DownloadDashFilesBatch(currentDirectoryPath, videoID string, vFormatCode string, format map[string]string, requestHeaders map[string]string) ([]string, string) {
+//DownloadDashFilesBatch downloads the dash chunks of the first of the given formats into a temp directory under the given one. A chunk failing to download is fetched from the
+//other formats, when they serve the same chunks from another playback set (or) CDN. The signed playback urls are refreshed with the
+//given refresher (if any) when they expire, continuing from the chunk that failed.
+func DownloadDashFilesBatch(directoryPath, videoID string, vFormatCode string, formats []map[string]string, requestHeaders map[string]string, refreshFormats FormatsRefresher) ([]string, string, error) {
 	var dashFiles []string
+	format := formats[0]
 	tempFolder := fmt.Sprintf("temp_%s_%s", videoID, vFormatCode)
-	tempDir := filepath.Join(currentDirectoryPath, tempFolder)
+	tempDir := filepath.Join(directoryPath, tempFolder)
 
 	if _, dirExistenceErr := os.Stat(tempDir); dirExistenceErr == nil {
-		fmt.Printf("\nTemp %s directory exists from previous run.\n", tempFolder)
+		logInfo("\nTemp %s directory exists from previous run.\n", tempFolder)
 		removeErr := os.RemoveAll(tempDir)
 		if removeErr != nil {
-			fmt.Printf("\nError in removing temp directory %s\n", tempFolder)
-			os.Exit(-1)
-		} else {
-			fmt.Printf("\nTemp directory %s removed\n", tempFolder)
+			return nil, "", fmt.Errorf("Error in removing temp directory %s: %s", tempFolder, removeErr)
 		}
+		logInfo("\nTemp directory %s removed\n", tempFolder)
 	}
 
-	dirCreationErr := os.Mkdir(tempDir, os.ModeDir)
+	dirCreationErr := os.Mkdir(tempDir, 0755)
 
 	if dirCreationErr != nil || os.IsNotExist(dirCreationErr) {
-		fmt.Print("\nError in creating temp directory\n")
-		os.Exit(-1)
+		return nil, "", fmt.Errorf("Error in creating temp directory %s: %s", tempFolder, dirCreationErr)
 	}
 
-	fmt.Printf("\nTemp directory %s created\n", tempFolder)
+	logInfo("\nTemp directory %s created\n", tempFolder)
 
-	totalSegments, _ := strconv.Atoi(format["TOTAL-SEGMENTS"])
+	firstSegment, lastSegment := getDashSegmentRange(format)
 
-	bar := pb.StartNew(totalSegments)
-
-	fmt.Printf("\nDownloading DASH chunks to above directory\n")
+	logInfo("\nDownloading DASH chunks to above directory\n")
 
 	dashFiles = make([]string, 0)
 
-	initSegmentURL := getSegmentURL(format["PLAYBACK-URL"], format["INIT-URL"])
+	source := &dashSegmentSource{
+		format:         format,
+		playbackURLs:   getSegmentPlaybackURLs(format, formats[1:]),
+		requestHeaders: requestHeaders,
+		refreshFormats: refreshFormats,
+	}
+
 	initSegmentURLValues := strings.Split(format["INIT-URL"], "/")
-	initFilePath := filepath.Join(tempFolder, initSegmentURLValues[len(initSegmentURLValues)-1])
+	initFilePath := filepath.Join(tempDir, initSegmentURLValues[len(initSegmentURLValues)-1])
 	dashFiles = append(dashFiles, initFilePath)
-	initFileErr := downloadDashFile(initFilePath, initSegmentURL, requestHeaders)
+	initFileErr := source.download(initFilePath, format["INIT-URL"])
 	if initFileErr != nil {
-		raiseFileDownloadError(initFileErr)
+		return nil, tempDir, fmt.Errorf("Error in downloading init segment to file %s. Error: %s", initFilePath, initFileErr)
 	}
-	for _, segmentNum := range MakeRange(1, totalSegments) {
+	var downloadedBytes int64
+	for _, segmentNum := range MakeRange(firstSegment, lastSegment) {
 		streamURL := strings.Replace(format["STREAM-URL"], "$Number$", fmt.Sprintf("%d", segmentNum), -1)
 		streamURLValues := strings.Split(streamURL, "/")
-		segmentURL := getSegmentURL(format["PLAYBACK-URL"], streamURL)
-		segmentFilePath := filepath.Join(tempFolder, streamURLValues[len(streamURLValues)-1])
+		segmentFilePath := filepath.Join(tempDir, streamURLValues[len(streamURLValues)-1])
 		dashFiles = append(dashFiles, segmentFilePath)
-		segmentFileErr := downloadDashFile(segmentFilePath, segmentURL, requestHeaders)
+		segmentFileErr := source.download(segmentFilePath, streamURL)
 		if segmentFileErr != nil {
-			raiseFileDownloadError(initFileErr)
+			return nil, tempDir, fmt.Errorf("Error in downloading segment %d to file %s. Error: %s", segmentNum, segmentFilePath, segmentFileErr)
 		}
-		bar.Increment()
+		if segmentFileInfo, err := os.Stat(segmentFilePath); err == nil {
+			downloadedBytes += segmentFileInfo.Size()
+		}
+		reportProgress(ProgressEvent{Type: EventSegmentDone, Format: vFormatCode, Segment: segmentNum - firstSegment + 1, TotalSegments: lastSegment - firstSegment + 1, Bytes: downloadedBytes})
 	}
 
-	bar.Finish()
-
-	return dashFiles, tempDir
+	return dashFiles, tempDir, nil
 }
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+)
+
+//Content types reported by the extractors in ContentRef.ContentType
+const (
+	ContentTypeEpisode  = "episode"
+	ContentTypeMovie    = "movie"
+	ContentTypeSports   = "sports"
+	ContentTypeNews     = "news"
+	ContentTypeClip     = "clip"
+	ContentTypeVideo    = "video"
+	ContentTypePlaylist = "playlist"
+)
+
+//hotstarURLPrefix matches the scheme, the Hotstar (or) Disney+ Hotstar host and an optional region path like /in/
+const hotstarURLPrefix = `^(?:https?://)?(?:[\w-]+\.)*(?:hotstar|disneyplushotstar)\.com(?:/[a-z]{2})?`
+
+//contentIDSuffix matches the 10 digit content id ending a path segment
+const contentIDSuffix = `(?P<contentId>\d{10})(?:[/?#]|$)`
+
+//ContentRef is the normalized reference of a Hotstar content identified from an url.
+type ContentRef struct {
+	URL         string
+	Extractor   string
+	ContentID   string
+	ContentType string
+	PlaylistID  string
+}
+
+//IsPlaylist tells whether the content reference points to a playlist (or) to a single video.
+func (contentRef *ContentRef) IsPlaylist() bool {
+	return contentRef.PlaylistID != ""
+}
+
+//Extractor identifies a family of Hotstar urls and extracts the content reference from them.
+type Extractor interface {
+	Name() string
+	Description() string
+	Match(url string) bool
+	Extract(url string) (*ContentRef, error)
+}
+
+type regexExtractor struct {
+	name        string
+	description string
+	contentType string
+	regex       *regexp.Regexp
+}
+
+func (extractor *regexExtractor) Name() string {
+	return extractor.name
+}
+
+func (extractor *regexExtractor) Description() string {
+	return extractor.description
+}
+
+func (extractor *regexExtractor) Match(url string) bool {
+	return extractor.regex.MatchString(url)
+}
+
+func (extractor *regexExtractor) Extract(url string) (*ContentRef, error) {
+	if !extractor.Match(url) {
+		return nil, fmt.Errorf("%s: unsupported url %s", extractor.name, url)
+	}
+
+	match := ReSubMatchMap(extractor.regex, url)
+	contentRef := &ContentRef{
+		URL:         url,
+		Extractor:   extractor.name,
+		ContentType: extractor.contentType,
+	}
+
+	if extractor.contentType == ContentTypePlaylist {
+		contentRef.PlaylistID = match["playlistId"]
+	} else {
+		contentRef.ContentID = match["contentId"]
+	}
+
+	return contentRef, nil
+}
+
+func newRegexExtractor(name, description, contentType, pathPattern string) Extractor {
+	return &regexExtractor{
+		name:        name,
+		description: description,
+		contentType: contentType,
+		regex:       regexp.MustCompile(hotstarURLPrefix + pathPattern),
+	}
+}
+
+//extractors holds the registered extractors in the order they are tried.
+var extractors = []Extractor{
+	newRegexExtractor("hotstar:playlist", "TV show episode lists (trays)", ContentTypePlaylist, `/tv/[^/]+/s-\w+/list/[^/]+/t-(?P<playlistId>\w+)`),
+	newRegexExtractor("hotstar:tv", "TV show episodes", ContentTypeEpisode, `/(?:tv|shows)/(?:[^/?#]+/)+`+contentIDSuffix),
+	newRegexExtractor("hotstar:movie", "Movies", ContentTypeMovie, `/movies/(?:[^/?#]+/)+`+contentIDSuffix),
+	newRegexExtractor("hotstar:sports", "Sports matches and highlights", ContentTypeSports, `/sports/(?:[^/?#]+/)+`+contentIDSuffix),
+	newRegexExtractor("hotstar:news", "News videos", ContentTypeNews, `/news/(?:[^/?#]+/)+`+contentIDSuffix),
+	newRegexExtractor("hotstar:clip", "Clips and trailers", ContentTypeClip, `/(?:clips|trailers)/(?:[^/?#]+/)+`+contentIDSuffix),
+	newRegexExtractor("hotstar:generic", "Any other Hotstar url ending with a content id", ContentTypeVideo, `/(?:[^?#]+?[/-])+`+contentIDSuffix),
+}
+
+//RegisterExtractor registers the given extractor ahead of the built-in ones.
+func RegisterExtractor(extractor Extractor) {
+	extractors = append([]Extractor{extractor}, extractors...)
+}
+
+//GetExtractors returns the registered extractors in the order they are tried.
+func GetExtractors() []Extractor {
+	return append([]Extractor(nil), extractors...)
+}
+
+//FindExtractor returns the first registered extractor matching the given url.
+func FindExtractor(url string) (Extractor, bool) {
+	for _, extractor := range extractors {
+		if extractor.Match(url) {
+			return extractor, true
+		}
+	}
+	return nil, false
+}
+
+//ExtractContentRef extracts the normalized content reference for the given url using the registered extractors.
+func ExtractContentRef(url string) (*ContentRef, error) {
+	extractor, isFound := FindExtractor(url)
+	if !isFound {
+		return nil, fmt.Errorf("Unsupported url %s", url)
+	}
+	return extractor.Extract(url)
+}
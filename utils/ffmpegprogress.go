@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ffmpegVerbose shows the raw output of ffmpeg instead of reporting its progress
+var ffmpegVerbose = false
+
+//SetFfmpegVerbose sets whether the raw output of ffmpeg is shown instead of reporting its progress.
+func SetFfmpegVerbose(verbose bool) {
+	ffmpegVerbose = verbose
+}
+
+//FfmpegProgress is a progress update reported by ffmpeg with -progress.
+type FfmpegProgress struct {
+	OutTime   time.Duration //media time written so far
+	TotalSize int64         //bytes written so far, -1 when unknown
+	Speed     float64       //processing speed relative to the playback, 0 when unknown
+	Done      bool          //the last update, ffmpeg having finished
+}
+
+//ParseFfmpegProgress reads the key=value blocks written by ffmpeg with -progress and reports each one to the given function as it ends.
+func ParseFfmpegProgress(reader io.Reader, onProgress func(progress FfmpegProgress)) error {
+	progress := FfmpegProgress{TotalSize: -1}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		keyValue := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+		key, value := keyValue[0], strings.TrimSpace(keyValue[1])
+
+		switch key {
+		case "out_time_us", "out_time_ms":
+			//out_time_ms is in microseconds too
+			if microseconds, err := strconv.ParseInt(value, 10, 64); err == nil && microseconds >= 0 {
+				progress.OutTime = time.Duration(microseconds) * time.Microsecond
+			}
+		case "out_time":
+			if outTime, isValid := parseFfmpegTime(value); isValid {
+				progress.OutTime = outTime
+			}
+		case "total_size":
+			if totalSize, err := strconv.ParseInt(value, 10, 64); err == nil {
+				progress.TotalSize = totalSize
+			}
+		case "speed":
+			if speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64); err == nil {
+				progress.Speed = speed
+			}
+		case "progress":
+			//the progress key ends a block
+			progress.Done = value == "end"
+			onProgress(progress)
+		}
+	}
+	return scanner.Err()
+}
+
+//parseFfmpegTime parses a time like 00:01:02.500000, invalid when negative (or) N/A as at the start of the output
+func parseFfmpegTime(value string) (time.Duration, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	hours, hoursErr := strconv.Atoi(parts[0])
+	minutes, minutesErr := strconv.Atoi(parts[1])
+	seconds, secondsErr := strconv.ParseFloat(parts[2], 64)
+	if hoursErr != nil || minutesErr != nil || secondsErr != nil || hours < 0 || minutes < 0 || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second)), true
+}
@@ -0,0 +1,738 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+//ErrUnsupportedFragments is returned by MuxFragmentedMP4 for the fragments it can't rewrite, which ffmpeg has to remux instead.
+var ErrUnsupportedFragments = errors.New("Unsupported fragmented MP4")
+
+//ErrCorruptSegment is wrapped by the errors of the DASH segments received partly (or) not holding the boxes of a segment, like the
+//error pages of the CDN. They are downloaded again.
+var ErrCorruptSegment = errors.New("Corrupt segment")
+
+//containerBoxTypes are the boxes holding other boxes which are descended into
+var containerBoxTypes = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true, "mvex": true,
+	"moof": true, "traf": true, "edts": true, "dinf": true, "udta": true, "meta": true, "ilst": true,
+}
+
+//tfhd flags
+const (
+	tfhdBaseDataOffsetPresent = 0x000001
+)
+
+//mp4Box is an ISO base media file format box, holding either its payload (or) its child boxes.
+type mp4Box struct {
+	boxType  string
+	payload  []byte    //payload of the leaf boxes
+	prefix   []byte    //version and flags of the full boxes holding child boxes, like meta
+	children []*mp4Box //child boxes of the container boxes
+}
+
+//parseMP4Boxes parses the boxes in the given data, descending into the container boxes
+func parseMP4Boxes(data []byte) ([]*mp4Box, error) {
+	boxes := make([]*mp4Box, 0)
+	for len(data) != 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("Truncated box header of %d bytes", len(data))
+		}
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		boxType := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			//the box extends to the end of the data
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("Truncated large size of box %s", boxType)
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, fmt.Errorf("Invalid size %d of box %s", size, boxType)
+		}
+
+		box, err := newMP4Box(boxType, data[headerSize:size])
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, box)
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+//newMP4Box builds the box of the given type from its payload, parsing the child boxes of the container boxes
+func newMP4Box(boxType string, payload []byte) (*mp4Box, error) {
+	box := &mp4Box{boxType: boxType}
+	if !containerBoxTypes[boxType] {
+		box.payload = payload
+		return box, nil
+	}
+	if boxType == "meta" {
+		if len(payload) < 4 {
+			return nil, fmt.Errorf("Truncated box %s", boxType)
+		}
+		box.prefix, payload = payload[:4], payload[4:]
+	}
+	children, err := parseMP4Boxes(payload)
+	if err != nil {
+		return nil, err
+	}
+	box.children = children
+	return box, nil
+}
+
+//readMP4Box reads the next box from the reader, io.EOF when the reader has no more boxes
+func readMP4Box(reader io.Reader) (*mp4Box, error) {
+	header := make([]byte, 8, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("Truncated box header")
+		}
+		return nil, err
+	}
+	size := uint64(binary.BigEndian.Uint32(header[0:4]))
+	boxType := string(header[4:8])
+	headerSize := uint64(8)
+
+	var payload []byte
+	switch size {
+	case 0:
+		//the box extends to the end of the file
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+	case 1:
+		header = header[:16]
+		if _, err := io.ReadFull(reader, header[8:16]); err != nil {
+			return nil, fmt.Errorf("Truncated large size of box %s", boxType)
+		}
+		size = binary.BigEndian.Uint64(header[8:16])
+		headerSize = 16
+	}
+	if payload == nil {
+		if size < headerSize {
+			return nil, fmt.Errorf("Invalid size %d of box %s", size, boxType)
+		}
+		payload = make([]byte, size-headerSize)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return nil, fmt.Errorf("Truncated box %s of %d bytes", boxType, size)
+		}
+	}
+	return newMP4Box(boxType, payload)
+}
+
+func (box *mp4Box) size() uint64 {
+	size := uint64(8 + len(box.prefix) + len(box.payload))
+	for _, child := range box.children {
+		size += child.size()
+	}
+	if size > 0xFFFFFFFF {
+		size += 8
+	}
+	return size
+}
+
+func (box *mp4Box) writeTo(writer io.Writer) error {
+	size := box.size()
+	header := make([]byte, 8, 16)
+	if size > 0xFFFFFFFF {
+		binary.BigEndian.PutUint32(header[0:4], 1)
+		copy(header[4:8], box.boxType)
+		header = header[:16]
+		binary.BigEndian.PutUint64(header[8:16], size)
+	} else {
+		binary.BigEndian.PutUint32(header[0:4], uint32(size))
+		copy(header[4:8], box.boxType)
+	}
+
+	for _, data := range [][]byte{header, box.prefix, box.payload} {
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+	}
+	for _, child := range box.children {
+		if err := child.writeTo(writer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//find returns the first box of the given path of box types under the box, nil when absent
+func (box *mp4Box) find(boxTypes ...string) *mp4Box {
+	current := box
+	for _, boxType := range boxTypes {
+		var next *mp4Box
+		for _, child := range current.children {
+			if child.boxType == boxType {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+//findAll returns the child boxes of the given type
+func (box *mp4Box) findAll(boxType string) []*mp4Box {
+	boxes := make([]*mp4Box, 0)
+	for _, child := range box.children {
+		if child.boxType == boxType {
+			boxes = append(boxes, child)
+		}
+	}
+	return boxes
+}
+
+func findMP4Box(boxes []*mp4Box, boxType string) *mp4Box {
+	for _, box := range boxes {
+		if box.boxType == boxType {
+			return box
+		}
+	}
+	return nil
+}
+
+//getFullBoxField returns the offset of the field in the payload of a full box, after the version and flags, for the version of the box
+func getFullBoxField(box *mp4Box, offsetV0 int, offsetV1 int, fieldSize int) (int, error) {
+	if len(box.payload) < 4 {
+		return 0, fmt.Errorf("Truncated box %s", box.boxType)
+	}
+	offset := offsetV0
+	if box.payload[0] == 1 {
+		offset = offsetV1
+	}
+	if len(box.payload) < offset+fieldSize {
+		return 0, fmt.Errorf("Truncated box %s", box.boxType)
+	}
+	return offset, nil
+}
+
+func getTrackID(trak *mp4Box) (uint32, error) {
+	tkhd := trak.find("tkhd")
+	if tkhd == nil {
+		return 0, fmt.Errorf("%w: trak without tkhd", ErrUnsupportedFragments)
+	}
+	offset, err := getFullBoxField(tkhd, 12, 20, 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(tkhd.payload[offset:]), nil
+}
+
+//getTimescale returns the timescale of the media of the track
+func getTimescale(trak *mp4Box) (uint32, error) {
+	mdhd := trak.find("mdia", "mdhd")
+	if mdhd == nil {
+		return 0, fmt.Errorf("%w: trak without mdhd", ErrUnsupportedFragments)
+	}
+	offset, err := getFullBoxField(mdhd, 12, 20, 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(mdhd.payload[offset:]), nil
+}
+
+func setTrackID(trak *mp4Box, trackID uint32) error {
+	tkhd := trak.find("tkhd")
+	offset, err := getFullBoxField(tkhd, 12, 20, 4)
+	if err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(tkhd.payload[offset:], trackID)
+	return nil
+}
+
+//fmp4Track is a track of the muxed file, read from its init segment followed by its media segments. The media segments are read
+//one at a time, either from their own files (or) following the init segment in a single file.
+type fmp4Track struct {
+	files     []string
+	trak      *mp4Box
+	trex      *mp4Box
+	trackID   uint32 //track id in the source files
+	newID     uint32 //track id in the muxed file
+	timescale uint32
+
+	nextFile  int
+	file      *os.File      //file being read, nil when the next one is to be opened
+	reader    *bufio.Reader //reader of the file being read
+	nextMoof  *mp4Box       //fragment read ahead, starting the next media segment
+	pending   []*mp4Box     //fragment boxes of the media segment read next
+	startTime float64       //decode time in seconds of the pending fragments, -1 when unknown
+}
+
+//readNextBox reads the next box of the track from its files, telling when a file ends and io.EOF when all of them are read
+func (track *fmp4Track) readNextBox() (*mp4Box, bool, error) {
+	if track.file == nil {
+		if track.nextFile >= len(track.files) {
+			return nil, false, io.EOF
+		}
+		file, err := os.Open(track.files[track.nextFile])
+		if err != nil {
+			return nil, false, err
+		}
+		track.file, track.reader = file, bufio.NewReader(file)
+		track.nextFile++
+	}
+
+	box, err := readMP4Box(track.reader)
+	if err == nil {
+		return box, false, nil
+	}
+	filePath := track.file.Name()
+	track.close()
+	if err != io.EOF {
+		return nil, false, fmt.Errorf("%w: %s: %s", ErrUnsupportedFragments, filePath, err)
+	}
+	//the end of a file ends the media segment in it
+	return nil, true, nil
+}
+
+func (track *fmp4Track) close() {
+	if track.file != nil {
+		track.file.Close()
+		track.file, track.reader = nil, nil
+	}
+}
+
+//readNextSegment reads the fragments of the next media segment of the track, false when none is left
+func (track *fmp4Track) readNextSegment() (bool, error) {
+	track.pending = nil
+	fragments := make([]*mp4Box, 0, 2)
+	if track.nextMoof != nil {
+		fragments = append(fragments, track.nextMoof)
+		track.nextMoof = nil
+	}
+	for {
+		box, isFileEnd, err := track.readNextBox()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		if isFileEnd {
+			if len(fragments) != 0 {
+				break
+			}
+			continue
+		}
+
+		//only the fragments are kept, the segment type and index boxes don't apply to the muxed file
+		if box.boxType == "moof" && len(fragments) != 0 {
+			track.nextMoof = box
+			break
+		}
+		if box.boxType == "moof" || box.boxType == "mdat" {
+			fragments = append(fragments, box)
+		}
+	}
+	if len(fragments) == 0 {
+		return false, nil
+	}
+
+	track.pending = fragments
+	track.startTime = track.getStartTime(fragments)
+	return true, nil
+}
+
+//getStartTime returns the decode time in seconds of the first fragment, -1 when unknown
+func (track *fmp4Track) getStartTime(fragments []*mp4Box) float64 {
+	moof := findMP4Box(fragments, "moof")
+	if moof == nil || track.timescale == 0 {
+		return -1
+	}
+	tfdt := moof.find("traf", "tfdt")
+	if tfdt == nil {
+		return -1
+	}
+	var decodeTime uint64
+	if len(tfdt.payload) >= 12 && tfdt.payload[0] == 1 {
+		decodeTime = binary.BigEndian.Uint64(tfdt.payload[4:12])
+	} else if len(tfdt.payload) >= 8 && tfdt.payload[0] == 0 {
+		decodeTime = uint64(binary.BigEndian.Uint32(tfdt.payload[4:8]))
+	} else {
+		return -1
+	}
+	return float64(decodeTime) / float64(track.timescale)
+}
+
+//rewriteFragment renumbers the movie fragment and its track for the muxed file
+func (track *fmp4Track) rewriteFragment(moof *mp4Box, sequenceNumber uint32) error {
+	mfhd := moof.find("mfhd")
+	if mfhd == nil || len(mfhd.payload) < 8 {
+		return fmt.Errorf("%w: moof without mfhd", ErrUnsupportedFragments)
+	}
+	binary.BigEndian.PutUint32(mfhd.payload[4:8], sequenceNumber)
+
+	for _, traf := range moof.findAll("traf") {
+		tfhd := traf.find("tfhd")
+		if tfhd == nil || len(tfhd.payload) < 8 {
+			return fmt.Errorf("%w: traf without tfhd", ErrUnsupportedFragments)
+		}
+		//an explicit base data offset points into the original file
+		if flags := binary.BigEndian.Uint32(tfhd.payload[0:4]) & 0xFFFFFF; flags&tfhdBaseDataOffsetPresent != 0 {
+			return fmt.Errorf("%w: tfhd with explicit base data offset", ErrUnsupportedFragments)
+		}
+		if trackID := binary.BigEndian.Uint32(tfhd.payload[4:8]); trackID != track.trackID {
+			return fmt.Errorf("%w: fragment of track %d in the segments of track %d", ErrUnsupportedFragments, trackID, track.trackID)
+		}
+		binary.BigEndian.PutUint32(tfhd.payload[4:8], track.newID)
+	}
+	return nil
+}
+
+//loadTrack reads the init segment of the track, up to the first fragment when the media segments follow it in the same file
+func loadTrack(files []string) (*fmp4Track, []*mp4Box, error) {
+	if len(files) == 0 {
+		return nil, nil, errors.New("No init segment given for the track")
+	}
+
+	track := &fmp4Track{files: files}
+	initBoxes, err := track.readInit()
+	if err != nil {
+		track.close()
+		return nil, nil, err
+	}
+	return track, initBoxes, nil
+}
+
+//readInit reads the boxes of the init segment and the track in it
+func (track *fmp4Track) readInit() ([]*mp4Box, error) {
+	initBoxes := make([]*mp4Box, 0)
+	for {
+		box, isFileEnd, err := track.readNextBox()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == io.EOF || isFileEnd {
+			break
+		}
+		if box.boxType == "moof" {
+			track.nextMoof = box
+			break
+		}
+		initBoxes = append(initBoxes, box)
+	}
+
+	initFilePath := track.files[0]
+	moov := findMP4Box(initBoxes, "moov")
+	if moov == nil {
+		return nil, fmt.Errorf("%w: %s has no moov", ErrUnsupportedFragments, initFilePath)
+	}
+	traks := moov.findAll("trak")
+	if len(traks) != 1 {
+		return nil, fmt.Errorf("%w: %s has %d tracks instead of one", ErrUnsupportedFragments, initFilePath, len(traks))
+	}
+	trex := moov.find("mvex", "trex")
+	if trex == nil || len(trex.payload) < 8 {
+		return nil, fmt.Errorf("%w: %s is not fragmented", ErrUnsupportedFragments, initFilePath)
+	}
+
+	var err error
+	track.trak, track.trex = traks[0], trex
+	if track.trackID, err = getTrackID(track.trak); err != nil {
+		return nil, err
+	}
+	if track.timescale, err = getTimescale(track.trak); err != nil {
+		return nil, err
+	}
+	return initBoxes, nil
+}
+
+//MuxFragmentedMP4 writes the tracks, each given as its init segment followed by its media segments, into a single fragmented MP4
+//file with the given metadata. A single track is concatenated while the fragments of several tracks, like one audio and one video,
+//are interleaved by their decode time. The media segments of a track can also follow its init segment in a single file.
+func MuxFragmentedMP4(outputFilePath string, tracks [][]string, metadata map[string]string) error {
+	if len(tracks) == 0 {
+		return errors.New("No tracks given to mux")
+	}
+
+	fmp4Tracks := make([]*fmp4Track, 0, len(tracks))
+	defer func() {
+		for _, track := range fmp4Tracks {
+			track.close()
+		}
+	}()
+	var ftyp, moov *mp4Box
+	for index, files := range tracks {
+		track, initBoxes, err := loadTrack(files)
+		if err != nil {
+			return err
+		}
+		track.newID = uint32(index + 1)
+		if index == 0 {
+			ftyp = findMP4Box(initBoxes, "ftyp")
+			moov = findMP4Box(initBoxes, "moov")
+		}
+		fmp4Tracks = append(fmp4Tracks, track)
+	}
+
+	if err := buildMuxedMovie(moov, fmp4Tracks, metadata); err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(outputFilePath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(outputFile)
+
+	err = writeMuxedFile(writer, ftyp, moov, fmp4Tracks)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := outputFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outputFilePath)
+	}
+	return err
+}
+
+//buildMuxedMovie rewrites the movie of the first track to hold the tracks of all of them
+func buildMuxedMovie(moov *mp4Box, tracks []*fmp4Track, metadata map[string]string) error {
+	children := make([]*mp4Box, 0, len(moov.children)+len(tracks))
+	for _, child := range moov.children {
+		switch child.boxType {
+		case "trak", "udta":
+			//replaced below
+		case "mvex":
+			mvex := &mp4Box{boxType: "mvex"}
+			for _, mvexChild := range child.children {
+				if mvexChild.boxType != "trex" {
+					mvex.children = append(mvex.children, mvexChild)
+				}
+			}
+			for _, track := range tracks {
+				binary.BigEndian.PutUint32(track.trex.payload[4:8], track.newID)
+				mvex.children = append(mvex.children, track.trex)
+			}
+			children = append(children, mvex)
+		default:
+			children = append(children, child)
+		}
+		if child.boxType == "mvhd" {
+			for _, track := range tracks {
+				if err := setTrackID(track.trak, track.newID); err != nil {
+					return err
+				}
+				children = append(children, track.trak)
+			}
+		}
+	}
+
+	mvhd := moov.find("mvhd")
+	if mvhd == nil {
+		return fmt.Errorf("%w: moov without mvhd", ErrUnsupportedFragments)
+	}
+	offset, err := getFullBoxField(mvhd, 96, 108, 4)
+	if err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(mvhd.payload[offset:], uint32(len(tracks)+1))
+
+	if udta := getMetadataBox(metadata); udta != nil {
+		children = append(children, udta)
+	}
+	moov.children = children
+	return nil
+}
+
+func writeMuxedFile(writer io.Writer, ftyp *mp4Box, moov *mp4Box, tracks []*fmp4Track) error {
+	if ftyp != nil {
+		if err := ftyp.writeTo(writer); err != nil {
+			return err
+		}
+	}
+	if err := moov.writeTo(writer); err != nil {
+		return err
+	}
+
+	for _, track := range tracks {
+		if _, err := track.readNextSegment(); err != nil {
+			return err
+		}
+	}
+
+	sequenceNumber := uint32(1)
+	written := make([]int, len(tracks))
+	for {
+		//the segment starting first is written next, alternating between the tracks when the decode times are unknown
+		next := -1
+		for index, track := range tracks {
+			if track.pending == nil {
+				continue
+			}
+			if next == -1 {
+				next = index
+				continue
+			}
+			nextTrack := tracks[next]
+			if track.startTime >= 0 && nextTrack.startTime >= 0 {
+				if track.startTime < nextTrack.startTime {
+					next = index
+				}
+			} else if written[index] < written[next] {
+				next = index
+			}
+		}
+		if next == -1 {
+			return nil
+		}
+
+		track := tracks[next]
+		for _, fragment := range track.pending {
+			if fragment.boxType == "moof" {
+				if err := track.rewriteFragment(fragment, sequenceNumber); err != nil {
+					return err
+				}
+				sequenceNumber++
+			}
+			if err := fragment.writeTo(writer); err != nil {
+				return err
+			}
+		}
+		written[next]++
+
+		if _, err := track.readNextSegment(); err != nil {
+			return err
+		}
+	}
+}
+
+//metadataAtoms maps the metadata entries to the iTunes metadata atoms, the integer ones marked
+var metadataAtoms = map[string]struct {
+	atom      string
+	isInteger bool
+}{
+	"title":         {"\xa9nam", false},
+	"album":         {"\xa9alb", false},
+	"artist":        {"\xa9ART", false},
+	"album_artist":  {"aART", false},
+	"date":          {"\xa9day", false},
+	"comment":       {"\xa9cmt", false},
+	"synopsis":      {"ldes", false},
+	"genre":         {"\xa9gen", false},
+	"copyright":     {"cprt", false},
+	"show":          {"tvsh", false},
+	"episode_id":    {"tven", false},
+	"season_number": {"tvsn", true},
+}
+
+//getMetadataBox builds the udta box holding the given metadata as iTunes metadata, nil when there is none to add
+func getMetadataBox(metadata map[string]string) *mp4Box {
+	names := make([]string, 0, len(metadata))
+	for name := range metadata {
+		if _, isKnown := metadataAtoms[name]; isKnown {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	ilst := &mp4Box{boxType: "ilst"}
+	for _, name := range names {
+		metadataAtom := metadataAtoms[name]
+		//data box: type indicator (1 for UTF-8 text, 21 for integer) followed by the locale
+		data := make([]byte, 8)
+		if metadataAtom.isInteger {
+			var number uint32
+			if _, err := fmt.Sscanf(metadata[name], "%d", &number); err != nil {
+				continue
+			}
+			binary.BigEndian.PutUint32(data[0:4], 21)
+			data = append(data, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(data[8:12], number)
+		} else {
+			binary.BigEndian.PutUint32(data[0:4], 1)
+			data = append(data, metadata[name]...)
+		}
+		ilst.children = append(ilst.children, &mp4Box{
+			boxType:  metadataAtom.atom,
+			children: []*mp4Box{{boxType: "data", payload: data}},
+		})
+	}
+
+	//handler of the iTunes metadata: version and flags, pre defined, handler type, reserved and empty name
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:12], "mdir")
+	copy(hdlr[12:16], "appl")
+
+	return &mp4Box{
+		boxType: "udta",
+		children: []*mp4Box{{
+			boxType:  "meta",
+			prefix:   make([]byte, 4),
+			children: []*mp4Box{{boxType: "hdlr", payload: hdlr}, ilst},
+		}},
+	}
+}
+
+//VerifyMP4Segment checks that the top level boxes of the DASH segment span it whole, with the ftyp and moov boxes in the init segment
+//(or) the moof and mdat boxes in a media segment. The boxes aren't descended into.
+func VerifyMP4Segment(data []byte, isInit bool) error {
+	boxTypes := make(map[string]bool)
+	for offset := 0; offset < len(data); {
+		remaining := data[offset:]
+		if len(remaining) < 8 {
+			return fmt.Errorf("%w: truncated box header at byte %d", ErrCorruptSegment, offset)
+		}
+		size := uint64(binary.BigEndian.Uint32(remaining[0:4]))
+		boxType := string(remaining[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			//the box extends to the end of the segment
+			size = uint64(len(remaining))
+		case 1:
+			if len(remaining) < 16 {
+				return fmt.Errorf("%w: truncated large size of box %q at byte %d", ErrCorruptSegment, boxType, offset)
+			}
+			size = binary.BigEndian.Uint64(remaining[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(remaining)) {
+			return fmt.Errorf("%w: box %q of %d bytes at byte %d overruns the %d bytes received", ErrCorruptSegment, boxType, size, offset, len(data))
+		}
+		boxTypes[boxType] = true
+		offset += int(size)
+	}
+
+	requiredBoxTypes := []string{"moof", "mdat"}
+	if isInit {
+		requiredBoxTypes = []string{"ftyp", "moov"}
+	}
+	for _, boxType := range requiredBoxTypes {
+		if !boxTypes[boxType] {
+			return fmt.Errorf("%w: no %s box", ErrCorruptSegment, boxType)
+		}
+	}
+	return nil
+}
+
+//isCorruptSegmentError checks whether the segment was received corrupt, (or) partly
+func isCorruptSegmentError(err error) bool {
+	return errors.Is(err, ErrCorruptSegment)
+}
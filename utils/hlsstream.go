@@ -0,0 +1,306 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//maxPlaylistReloadFailures is the count of the consecutive failures to reload the playlist of a live stream before giving up
+const maxPlaylistReloadFailures = 5
+
+//maxHLSRequestRetries is the count of the times a HLS segment (or) key failing with a network error, a server error (or) received cut short
+//is downloaded again
+const maxHLSRequestRetries = 3
+
+//hlsRetryDelay is the delay before the first retry of a HLS segment (or) key, doubled for each retry after it
+const hlsRetryDelay = 250 * time.Millisecond
+
+//loadM3u8MediaPlaylist downloads and parses the media playlist at the given url
+func loadM3u8MediaPlaylist(playlistURL string, requestHeaders map[string]string) (*m3u8MediaPlaylist, error) {
+	playlistBytes, err := MakeGetRequest(playlistURL, requestHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return parseM3u8MediaPlaylist(string(playlistBytes), playlistURL)
+}
+
+//hlsSegmentReader downloads the segments of HLS media playlists, retrying them on the transient errors and decrypting those encrypted
+//with AES-128. The keys are downloaded once each, with the same request headers as the segments. It is shared by the concurrent segment downloads.
+type hlsSegmentReader struct {
+	requestHeaders map[string]string
+	mutex          sync.Mutex
+	keys           map[string][]byte //keys downloaded by their urls
+}
+
+func newHLSSegmentReader(requestHeaders map[string]string) *hlsSegmentReader {
+	return &hlsSegmentReader{requestHeaders: requestHeaders, keys: make(map[string][]byte)}
+}
+
+//get downloads the segment (or) the key at the given url, retrying it on the errors which may be transient
+func (reader *hlsSegmentReader) get(url string) ([]byte, error) {
+	data, err := readWholeSegment(url, reader.requestHeaders)
+	retryDelay := hlsRetryDelay
+	for attempt := 1; err != nil && isTransientError(err) && attempt <= maxHLSRequestRetries; attempt++ {
+		logVerbose("Retrying the HLS request", "attempt", attempt, "url", url, "error", err)
+		time.Sleep(retryDelay)
+		retryDelay *= 2
+		data, err = readWholeSegment(url, reader.requestHeaders)
+	}
+	return data, err
+}
+
+//isTransientError checks whether the request failing with the given error may succeed when made again, like on a network error, a
+//server error (or) a body cut short, unlike on the other HTTP errors
+func isTransientError(err error) bool {
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.StatusCode >= 500
+	}
+	return true
+}
+
+//getKey returns the key at the given url, downloading it the first time
+func (reader *hlsSegmentReader) getKey(keyURI string) ([]byte, error) {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+	if key, isDownloaded := reader.keys[keyURI]; isDownloaded {
+		return key, nil
+	}
+
+	key, err := reader.get(keyURI)
+	if err != nil {
+		return nil, fmt.Errorf("Error in downloading the key %s: %s", keyURI, err)
+	}
+	if len(key) != aes.BlockSize {
+		return nil, fmt.Errorf("The key %s is %d bytes instead of %d", keyURI, len(key), aes.BlockSize)
+	}
+	reader.keys[keyURI] = key
+	return key, nil
+}
+
+//decrypt decrypts the data encrypted with the given key, with the IV of the key (or) the given media sequence number when it has none
+func (reader *hlsSegmentReader) decrypt(data []byte, key *m3u8Key, sequence int) ([]byte, error) {
+	if key == nil {
+		return data, nil
+	}
+	keyBytes, err := reader.getKey(key.URI)
+	if err != nil {
+		return nil, err
+	}
+	iv := key.IV
+	if iv == nil {
+		iv = getSequenceIV(sequence)
+	}
+	return decryptAES128(data, keyBytes, iv)
+}
+
+//readInitSection downloads the init section of the playlist, nil when it has none
+func (reader *hlsSegmentReader) readInitSection(playlist *m3u8MediaPlaylist) ([]byte, error) {
+	if playlist.MapURI == "" {
+		return nil, nil
+	}
+	initData, err := reader.get(playlist.MapURI)
+	if err != nil {
+		return nil, err
+	}
+	sequence := 0
+	if len(playlist.Segments) != 0 {
+		sequence = playlist.Segments[0].Sequence
+	}
+	return reader.decrypt(initData, playlist.MapKey, sequence)
+}
+
+//read downloads the segment
+func (reader *hlsSegmentReader) read(segment m3u8Segment) ([]byte, error) {
+	segmentData, err := reader.get(segment.URI)
+	if err != nil {
+		return nil, err
+	}
+	return reader.decrypt(segmentData, segment.Key, segment.Sequence)
+}
+
+//getSequenceIV returns the IV of a segment whose key has none, its media sequence number as a 128 bit big endian integer
+func getSequenceIV(sequence int) []byte {
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[8:], uint64(sequence))
+	return iv
+}
+
+//decryptAES128 decrypts the data encrypted with AES-128 in CBC mode, removing its PKCS7 padding
+func decryptAES128(data []byte, key []byte, iv []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("The encrypted size %d isn't a multiple of the AES block size", len(data))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data)
+
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, fmt.Errorf("Invalid padding after decryption, the key (or) the IV may be wrong")
+	}
+	for _, paddingByte := range decrypted[len(decrypted)-padding:] {
+		if int(paddingByte) != padding {
+			return nil, fmt.Errorf("Invalid padding after decryption, the key (or) the IV may be wrong")
+		}
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
+
+//DownloadHLSStream downloads the segments of the HLS media playlist at the given url and appends them in order to the given file, after
+//its init section if any. The segments are downloaded concurrently as set with SetConcurrentFragments. Those encrypted with AES-128 are
+//decrypted with their keys, while those encrypted with DRM fail with ErrDRMProtected.
+func DownloadHLSStream(filePath string, formatCode string, playlistURL string, requestHeaders map[string]string) error {
+	playlist, err := loadM3u8MediaPlaylist(playlistURL, requestHeaders)
+	if err != nil {
+		return err
+	}
+	reader := newHLSSegmentReader(requestHeaders)
+
+	//the init section comes first, numbered 0 like the init segment of the DASH formats
+	segmentIDs := make([]string, len(playlist.Segments)+1)
+	for index := range segmentIDs {
+		segmentIDs[index] = strconv.Itoa(index)
+	}
+	read := func(segmentID string) ([]byte, error) {
+		index, _ := strconv.Atoi(segmentID)
+		if index == 0 {
+			return reader.readInitSection(playlist)
+		}
+		return reader.read(playlist.Segments[index-1])
+	}
+	firstSegment := 1
+	if len(playlist.Segments) != 0 {
+		firstSegment = playlist.Segments[0].Sequence
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	logInfo("\nDownloading HLS segments to %s\n", filePath)
+
+	var writtenBytes int64
+	err = streamSegments(file, segmentIDs, firstSegment, concurrentFragments, maxStreamBufferSize, read, func(index int, size int) {
+		writtenBytes += int64(size)
+		if index != 0 {
+			reportProgress(ProgressEvent{Type: EventSegmentDone, Format: formatCode, Segment: index, TotalSegments: len(playlist.Segments), Bytes: writtenBytes})
+		}
+	})
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+//RecordLiveHLS records the live HLS stream of the media playlist at the given url into the given file, until the playlist ends (or) the
+//given channel is closed. The playlist is reloaded for the new segments, which are appended in the order of their media sequence numbers
+//starting from the live edge, (or) from the earliest segment still in the playlist when fromStart is set. The segments encrypted with
+//AES-128 are decrypted like DownloadHLSStream. It returns the count of the segments recorded.
+func RecordLiveHLS(filePath string, formatCode string, playlistURL string, requestHeaders map[string]string, fromStart bool, stop <-chan struct{}) (int, error) {
+	playlist, err := loadM3u8MediaPlaylist(playlistURL, requestHeaders)
+	if err != nil {
+		return 0, err
+	}
+	//only an ended playlist is left without segments
+	if len(playlist.Segments) == 0 {
+		logInfo("\nThe live stream ended\n")
+		return 0, nil
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	logInfo("\nRecording the live stream to %s\n", filePath)
+
+	reader := newHLSSegmentReader(requestHeaders)
+	initData, err := reader.readInitSection(playlist)
+	if err != nil {
+		return 0, fmt.Errorf("Error in downloading the init section %s: %s", playlist.MapURI, err)
+	}
+	if _, err := file.Write(initData); err != nil {
+		return 0, err
+	}
+
+	//the live edge is three segments from the end of the playlist
+	nextSequence := playlist.Segments[0].Sequence
+	if edgeIndex := len(playlist.Segments) - 3; !fromStart && edgeIndex > 0 {
+		nextSequence = playlist.Segments[edgeIndex].Sequence
+	}
+
+	recordedSegments, reloadFailures := 0, 0
+	var writtenBytes int64
+	for {
+		hasNewSegments := false
+		for _, segment := range playlist.Segments {
+			if segment.Sequence < nextSequence {
+				continue
+			}
+			if isStopped(stop) {
+				return recordedSegments, nil
+			}
+			if segment.Sequence > nextSequence {
+				logInfo("\nMissed the segments %d to %d, which left the playlist before being downloaded\n", nextSequence, segment.Sequence-1)
+			}
+
+			segmentData, err := reader.read(segment)
+			if err != nil {
+				return recordedSegments, fmt.Errorf("Error in downloading the segment %d: %s", segment.Sequence, err)
+			}
+			if _, err := file.Write(segmentData); err != nil {
+				return recordedSegments, err
+			}
+
+			recordedSegments++
+			writtenBytes += int64(len(segmentData))
+			reportProgress(ProgressEvent{Type: EventSegmentDone, Format: formatCode, Segment: recordedSegments, Bytes: writtenBytes})
+			nextSequence = segment.Sequence + 1
+			hasNewSegments = true
+		}
+
+		if playlist.IsEnded {
+			logInfo("\nThe live stream ended\n")
+			return recordedSegments, nil
+		}
+
+		//the playlist is reloaded after a target duration, (or) half of it when it had nothing new
+		reloadDelay := time.Duration(playlist.TargetDuration * float64(time.Second))
+		if reloadDelay <= 0 {
+			reloadDelay = 2 * time.Second
+		}
+		if !hasNewSegments {
+			reloadDelay /= 2
+		}
+		select {
+		case <-stop:
+			return recordedSegments, nil
+		case <-time.After(reloadDelay):
+		}
+
+		reloadedPlaylist, err := loadM3u8MediaPlaylist(playlistURL, requestHeaders)
+		if err != nil {
+			if reloadFailures++; reloadFailures >= maxPlaylistReloadFailures {
+				return recordedSegments, fmt.Errorf("Error in reloading the playlist of the live stream: %s", err)
+			}
+			logVerbose("Retrying the reload of the live playlist", "attempt", reloadFailures, "url", playlistURL, "error", err)
+			continue
+		}
+		playlist, reloadFailures = reloadedPlaylist, 0
+	}
+}
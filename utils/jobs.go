@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Options holds the user options applied to every job.
+type Options struct {
+	ListFormats        bool
+	Title              bool
+	Description        bool
+	Format             string
+	FfmpegPath         string
+	OutputFileName     string
+	Metadata           bool
+	PlaylistStartRange string
+	PlaylistEndRange   string
+	SkipErrorKinds     []ErrorKind //kinds of the errors for which a job is skipped instead of failed
+	Paths              Paths
+	KeepFragments      bool          //keep the DASH chunks in the temp path instead of streaming them into a single file
+	Section            *Section      //time range of the videos to download, the whole videos when nil
+	Live               LiveOptions   //how the live streams are recorded
+	Verify             bool          //probe the downloaded videos, failing those not matching the format
+	SleepInterval      time.Duration //time to wait before each video of a playlist after the first
+	MaxSleepInterval   time.Duration //upper bound of a random wait from SleepInterval, no randomness when not above it
+}
+
+//Job is a single video (or) playlist url processed with the user options.
+type Job struct {
+	URL        string
+	ContentRef *ContentRef
+	Metadata   map[string]string
+	Err        error
+	Skipped    bool //the job failed with an error of a kind to skip
+}
+
+//JobSummary holds the outcome of every job run.
+type JobSummary struct {
+	Jobs []*Job
+}
+
+//Succeeded returns the count of jobs completed without errors.
+func (summary *JobSummary) Succeeded() int {
+	return len(summary.Jobs) - len(summary.Failed()) - len(summary.Skipped())
+}
+
+//Failed returns the jobs completed with errors, other than the skipped ones.
+func (summary *JobSummary) Failed() []*Job {
+	failedJobs := make([]*Job, 0)
+	for _, job := range summary.Jobs {
+		if job.Err != nil && !job.Skipped {
+			failedJobs = append(failedJobs, job)
+		}
+	}
+	return failedJobs
+}
+
+//Skipped returns the jobs skipped for an error of a kind to skip.
+func (summary *JobSummary) Skipped() []*Job {
+	skippedJobs := make([]*Job, 0)
+	for _, job := range summary.Jobs {
+		if job.Err != nil && job.Skipped {
+			skippedJobs = append(skippedJobs, job)
+		}
+	}
+	return skippedJobs
+}
+
+//String returns the human readable summary of the jobs run.
+func (summary *JobSummary) String() string {
+	var summaryStr strings.Builder
+	failedJobs := summary.Failed()
+	skippedJobs := summary.Skipped()
+	summaryStr.WriteString(fmt.Sprintf("\nSummary: %d job(s), %d succeeded, %d failed", len(summary.Jobs), summary.Succeeded(), len(failedJobs)))
+	if len(skippedJobs) != 0 {
+		summaryStr.WriteString(fmt.Sprintf(", %d skipped", len(skippedJobs)))
+	}
+	summaryStr.WriteString("\n")
+	for _, job := range failedJobs {
+		summaryStr.WriteString(fmt.Sprintf("  FAILED %s%s\n    %s\n", job.URL, formatErrorKind(job.Err), strings.TrimSpace(job.Err.Error())))
+	}
+	for _, job := range skippedJobs {
+		summaryStr.WriteString(fmt.Sprintf("  SKIPPED %s%s\n", job.URL, formatErrorKind(job.Err)))
+	}
+	return summaryStr.String()
+}
+
+//formatErrorKind formats the kind of the given error to follow the job url, empty if the error isn't classified
+func formatErrorKind(err error) string {
+	if kind := GetErrorKind(err); kind != "" {
+		return fmt.Sprintf(" [%s]", kind)
+	}
+	return ""
+}
+
+//IsDashFormatCode checks whether the given format code is of a DASH audio (or) video format.
+func IsDashFormatCode(formatCode string) bool {
+	return strings.HasPrefix(formatCode, "dash-audio-") || strings.HasPrefix(formatCode, "dash-video-")
+}
+
+//HasValidFormatPrefix checks whether the given format code is either of a HLS (or) DASH format.
+func HasValidFormatPrefix(formatCode string) bool {
+	return strings.HasPrefix(formatCode, "hls-") || IsDashFormatCode(formatCode)
+}
+
+//RunJobs processes each of the given urls, expanding playlists into a job per video, and returns the summary. When the jobs are
+//several, each one gets its own output file named with GetJobOutputFileName.
+func RunJobs(urls []string, options *Options) *JobSummary {
+	summary := &JobSummary{}
+
+	for _, videoOrPlaylistURL := range urls {
+		job := &Job{URL: videoOrPlaylistURL}
+		job.Err = resolveJob(job)
+
+		if job.Err != nil || !job.ContentRef.IsPlaylist() {
+			if job.Err == nil {
+				job.Err = runJob(job, options, len(urls) > 1)
+			}
+			summary.addJob(job, options)
+			continue
+		}
+
+		playlistJobs, err := expandPlaylistJob(job, options)
+		if err != nil {
+			job.Err = err
+			summary.addJob(job, options)
+			continue
+		}
+
+		for index, playlistJob := range playlistJobs {
+			if index != 0 {
+				sleepBetweenVideos(options)
+			}
+			logInfo("\nFor video id, %s\n", playlistJob.ContentRef.ContentID)
+			playlistJob.Err = runJob(playlistJob, options, len(urls) > 1 || len(playlistJobs) > 1)
+			summary.addJob(playlistJob, options)
+			if playlistJob.Skipped {
+				logInfo("Skipping video id, %s: %s\n", playlistJob.ContentRef.ContentID, strings.TrimSpace(playlistJob.Err.Error()))
+			}
+		}
+	}
+
+	return summary
+}
+
+//sleepBetweenVideos waits the sleep interval of the options, (or) a random time up to the max sleep interval when set
+func sleepBetweenVideos(options *Options) {
+	interval := options.SleepInterval
+	if options.MaxSleepInterval > interval {
+		interval += time.Duration(rand.Int63n(int64(options.MaxSleepInterval-interval) + 1))
+	}
+	if interval <= 0 {
+		return
+	}
+	logInfo("Sleeping %s before the next video\n", interval.Round(time.Millisecond))
+	time.Sleep(interval)
+}
+
+//addJob adds the completed job to the summary, reporting its error (if any)
+func (summary *JobSummary) addJob(job *Job, options *Options) {
+	job.Skipped = isSkippedError(job.Err, options)
+	summary.Jobs = append(summary.Jobs, job)
+	if job.Err == nil {
+		return
+	}
+
+	var videoID string
+	if job.ContentRef != nil {
+		videoID = job.ContentRef.ContentID
+	}
+	setProgressJob(job.URL, videoID)
+	reportProgress(ProgressEvent{Type: EventError, Error: strings.TrimSpace(job.Err.Error()), ErrorKind: GetErrorKind(job.Err)})
+	setProgressJob("", "")
+}
+
+//isSkippedError checks whether the given error is of a kind to skip
+func isSkippedError(err error, options *Options) bool {
+	kind := GetErrorKind(err)
+	if kind == "" {
+		return false
+	}
+	for _, skipKind := range options.SkipErrorKinds {
+		if skipKind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveJob(job *Job) error {
+	parsedURL, err := GetParsedVideoURL(job.URL)
+	if err != nil {
+		return err
+	}
+
+	contentRef, err := ExtractContentRef(parsedURL)
+	if err != nil {
+		return fmt.Errorf("Invalid hotstar url %s. Please enter a valid one", job.URL)
+	}
+
+	job.URL = parsedURL
+	job.ContentRef = contentRef
+	return nil
+}
+
+func expandPlaylistJob(job *Job, options *Options) ([]*Job, error) {
+	playlistItems, err := GetPlaylistItems(job.ContentRef.PlaylistID)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedItems, err := SelectPlaylistItems(playlistItems, options.PlaylistStartRange, options.PlaylistEndRange)
+	if err != nil {
+		return nil, err
+	}
+
+	playlistJobs := make([]*Job, 0, len(selectedItems))
+	for _, metadata := range selectedItems {
+		playlistJobs = append(playlistJobs, &Job{
+			URL: GetPlaybackURI2(metadata["id"], uuid.New().String()),
+			ContentRef: &ContentRef{
+				URL:         job.URL,
+				Extractor:   job.ContentRef.Extractor,
+				ContentID:   metadata["id"],
+				ContentType: ContentTypeEpisode,
+			},
+			Metadata: metadata,
+		})
+	}
+
+	return playlistJobs, nil
+}
+
+//GetJobOutputFileName returns the output file name given for one of several jobs, with the video id of the job added before the extension
+//so that the jobs don't share a file. An empty name is kept empty, for the default names.
+func GetJobOutputFileName(outputFileName string, videoID string) string {
+	if outputFileName == "" {
+		return ""
+	}
+	extension := filepath.Ext(outputFileName)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(outputFileName, extension), videoID, extension)
+}
+
+func runJob(job *Job, options *Options, isOneOfSeveral bool) error {
+	setProgressJob(job.URL, job.ContentRef.ContentID)
+	defer setProgressJob("", "")
+
+	if options.ListFormats || options.Title || options.Description {
+		return ListVideoFormats(job.URL, job.ContentRef.ContentID, job.Metadata, options.Title, options.Description)
+	}
+
+	outputFileName := options.OutputFileName
+	if isOneOfSeveral {
+		outputFileName = GetJobOutputFileName(outputFileName, job.ContentRef.ContentID)
+	}
+	return DownloadAudioOrVideo(job.URL, job.ContentRef.ContentID, options.Format, options.FfmpegPath, outputFileName, options.Metadata, IsDashFormatCode(options.Format), options.Paths, options.KeepFragments, options.Section, options.Live, options.Verify)
+}
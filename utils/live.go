@@ -0,0 +1,365 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//LiveOptions are the options of recording the live streams.
+type LiveOptions struct {
+	FromStart    bool          //record from the earliest segment still available instead of the live edge
+	WaitForVideo time.Duration //interval to retry in while the video isn't available yet, 0 to fail at once
+}
+
+//watchInterrupt returns a channel closed on Ctrl-C, which stops the live recordings so they are finalized instead of the process exiting.
+//The function returned stops watching, the later Ctrl-C exiting as usual.
+func watchInterrupt() (<-chan struct{}, func()) {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	interrupted := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-interrupts:
+			//a second Ctrl-C quits without waiting
+			signal.Stop(interrupts)
+			logInfo("\nStopping, press Ctrl-C again to quit at once\n")
+			close(interrupted)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return interrupted, func() {
+		once.Do(func() {
+			signal.Stop(interrupts)
+			close(done)
+		})
+	}
+}
+
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+//isWaitableError checks whether the video may become available later, like a scheduled live stream not started yet (or) a video not
+//published yet. The other errors, like the network errors, aren't waited on.
+func isWaitableError(err error) bool {
+	kind := GetErrorKind(err)
+	return kind == ErrorKindNotStarted || kind == ErrorKindContentRemoved
+}
+
+//waitForVideoFormats gets the formats of the video, retrying in the given interval while the video isn't available yet until Ctrl-C
+func waitForVideoFormats(videoURL string, videoID string, interval time.Duration) (map[string]map[string]string, map[string]string, error) {
+	videoFormats, videoMetadata, err := GetVideoFormats(videoURL, videoID, nil)
+	if err == nil || interval <= 0 || !isWaitableError(err) {
+		return videoFormats, videoMetadata, err
+	}
+
+	stop, stopWatching := watchInterrupt()
+	defer stopWatching()
+	for err != nil && isWaitableError(err) {
+		logInfo("\nThe video isn't available yet, retrying in %s: %s\n", interval, strings.TrimSpace(err.Error()))
+		select {
+		case <-stop:
+			return nil, nil, fmt.Errorf("Stopped waiting for the video: %s", err)
+		case <-time.After(interval):
+		}
+		videoFormats, videoMetadata, err = GetVideoFormats(videoURL, videoID, nil)
+	}
+	return videoFormats, videoMetadata, err
+}
+
+//liveDashTiming is the timing of the segments of a live DASH format. The segment numbered startNumber begins at the availability start
+//time, and each segment is available once it has ended.
+type liveDashTiming struct {
+	availabilityStartTime time.Time
+	segmentDuration       time.Duration
+	timeShiftBufferDepth  time.Duration //0 when all the segments since the start stay available
+	minimumUpdatePeriod   time.Duration
+	startNumber           int
+}
+
+//getLiveDashTiming returns the timing of the live DASH format added by GetDashFormats
+func getLiveDashTiming(format map[string]string) (*liveDashTiming, error) {
+	availabilityStartTime, err := time.Parse(time.RFC3339, format["AVAILABILITY-START-TIME"])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid availability start time %s of the live stream", format["AVAILABILITY-START-TIME"])
+	}
+	segmentDuration, err := strconv.ParseFloat(format["SEGMENT-DURATION"], 64)
+	if err != nil || !(segmentDuration > 0) || math.IsInf(segmentDuration, 0) {
+		return nil, fmt.Errorf("Invalid segment duration %s of the live stream", format["SEGMENT-DURATION"])
+	}
+	timeShiftBufferDepth, _ := strconv.ParseFloat(format["TIME-SHIFT-BUFFER-DEPTH"], 64)
+	minimumUpdatePeriod, _ := strconv.ParseFloat(format["MINIMUM-UPDATE-PERIOD"], 64)
+	startNumber, err := strconv.Atoi(format["START-NUMBER"])
+	if err != nil {
+		startNumber = 1
+	}
+
+	return &liveDashTiming{
+		availabilityStartTime: availabilityStartTime,
+		segmentDuration:       time.Duration(segmentDuration * float64(time.Second)),
+		timeShiftBufferDepth:  time.Duration(timeShiftBufferDepth * float64(time.Second)),
+		minimumUpdatePeriod:   time.Duration(minimumUpdatePeriod * float64(time.Second)),
+		startNumber:           startNumber,
+	}, nil
+}
+
+//getAvailabilityTime returns the time the segment of the given number becomes available, once it has ended
+func (timing *liveDashTiming) getAvailabilityTime(segmentNum int) time.Time {
+	return timing.availabilityStartTime.Add(time.Duration(segmentNum-timing.startNumber+1) * timing.segmentDuration)
+}
+
+//getLatestSegment returns the number of the latest segment available at the given time, less than the start number before the stream starts
+func (timing *liveDashTiming) getLatestSegment(now time.Time) int {
+	elapsedSegments := int(math.Floor(float64(now.Sub(timing.availabilityStartTime)) / float64(timing.segmentDuration)))
+	return timing.startNumber + elapsedSegments - 1
+}
+
+//getEarliestSegment returns the number of the earliest segment still available at the given time
+func (timing *liveDashTiming) getEarliestSegment(now time.Time) int {
+	earliestSegment := timing.startNumber
+	if timing.timeShiftBufferDepth > 0 {
+		if bufferedSegment := timing.getLatestSegment(now) - int(timing.timeShiftBufferDepth/timing.segmentDuration) + 1; bufferedSegment > earliestSegment {
+			earliestSegment = bufferedSegment
+		}
+	}
+	return earliestSegment
+}
+
+//getLiveDashEnd reloads the MPD of the live format, returning the number of its last segment once the stream has ended and the MPD turned
+//static
+func getLiveDashEnd(playbackURL string, format map[string]string, timing *liveDashTiming, requestHeaders map[string]string) (int, bool, error) {
+	mpdBytes, err := MakeGetRequest(playbackURL, requestHeaders)
+	if err != nil {
+		return 0, false, err
+	}
+	for _, formatsList := range GetDashFormats(mpdBytes, playbackURL) {
+		for _, reloadedFormat := range formatsList {
+			if reloadedFormat["INIT-URL"] != format["INIT-URL"] || reloadedFormat["STREAM-URL"] != format["STREAM-URL"] {
+				continue
+			}
+			if reloadedFormat["LIVE"] == "true" {
+				return 0, false, nil
+			}
+			totalSegments, _ := strconv.Atoi(reloadedFormat["TOTAL-SEGMENTS"])
+			return timing.startNumber + totalSegments - 1, true, nil
+		}
+	}
+	return 0, false, fmt.Errorf("The representation being recorded is no longer in the reloaded MPD")
+}
+
+//readLiveDashSegment downloads the segment, retrying for a few segment durations while the CDN doesn't have the segment just announced yet
+func readLiveDashSegment(source *dashSegmentSource, segmentID string, timing *liveDashTiming, stop <-chan struct{}) ([]byte, error) {
+	retryDeadline := time.Now().Add(3 * timing.segmentDuration)
+	for {
+		data, err := source.read(segmentID)
+		if err == nil || GetHTTPStatusCode(err) != 404 || time.Now().After(retryDeadline) {
+			return data, err
+		}
+		select {
+		case <-stop:
+			return nil, err
+		case <-time.After(timing.segmentDuration / 2):
+		}
+	}
+}
+
+//RecordLiveDashStream records the live stream of the first of the given DASH formats into the given file, until the stream ends (or) the
+//given channel is closed. The segments are appended in order as they become available, starting from the live edge, (or) from the
+//earliest segment still available when fromStart is set. The MPD is reloaded every minimum update period to find the end of the stream.
+//The segments are fetched from the other formats and the signed urls refreshed like DownloadDashStream. It returns the count of the
+//segments recorded.
+func RecordLiveDashStream(filePath string, formatCode string, formats []map[string]string, requestHeaders map[string]string, refreshFormats FormatsRefresher, fromStart bool, stop <-chan struct{}) (int, error) {
+	format := formats[0]
+	timing, err := getLiveDashTiming(format)
+	if err != nil {
+		return 0, err
+	}
+
+	source := &dashSegmentSource{
+		format:         format,
+		playbackURLs:   getSegmentPlaybackURLs(format, formats[1:]),
+		requestHeaders: requestHeaders,
+		refreshFormats: refreshFormats,
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	logInfo("\nRecording the live stream to %s\n", filePath)
+
+	//the init segment of a stream yet to start may not be out
+	if delay := time.Until(timing.availabilityStartTime); delay > 0 {
+		logInfo("\nWaiting %s for the live stream to start\n", delay.Round(time.Second))
+		select {
+		case <-stop:
+			return 0, nil
+		case <-time.After(delay):
+		}
+	}
+
+	initData, err := source.read(format["INIT-URL"])
+	if err != nil {
+		return 0, fmt.Errorf("Error in downloading the init segment: %s", err)
+	}
+	if _, err := file.Write(initData); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	nextSegment := timing.getLatestSegment(now)
+	if fromStart {
+		nextSegment = timing.getEarliestSegment(now)
+	}
+	if nextSegment < timing.startNumber {
+		nextSegment = timing.startNumber
+	}
+	lastSegment := math.MaxInt32
+	nextUpdate := now.Add(timing.minimumUpdatePeriod)
+
+	recordedSegments := 0
+	var writtenBytes int64
+	for nextSegment <= lastSegment {
+		if delay := time.Until(timing.getAvailabilityTime(nextSegment)); delay > 0 {
+			select {
+			case <-stop:
+				return recordedSegments, nil
+			case <-time.After(delay):
+			}
+		} else if isStopped(stop) {
+			return recordedSegments, nil
+		}
+
+		segmentData, err := readLiveDashSegment(source, strings.Replace(format["STREAM-URL"], "$Number$", strconv.Itoa(nextSegment), -1), timing, stop)
+		if err != nil {
+			if isStopped(stop) {
+				return recordedSegments, nil
+			}
+			//the segments past the end of the stream are never available
+			playbackURLs, _, _ := source.current()
+			if endSegment, isEnded, endErr := getLiveDashEnd(playbackURLs[0], format, timing, requestHeaders); endErr == nil && isEnded && nextSegment > endSegment {
+				break
+			}
+			return recordedSegments, fmt.Errorf("Error in downloading the segment %d: %s", nextSegment, err)
+		}
+		if _, err := file.Write(segmentData); err != nil {
+			return recordedSegments, err
+		}
+
+		recordedSegments++
+		writtenBytes += int64(len(segmentData))
+		reportProgress(ProgressEvent{Type: EventSegmentDone, Format: formatCode, Segment: recordedSegments, Bytes: writtenBytes})
+		nextSegment++
+
+		if timing.minimumUpdatePeriod > 0 && time.Now().After(nextUpdate) {
+			playbackURLs, currentHeaders, _ := source.current()
+			if endSegment, isEnded, err := getLiveDashEnd(playbackURLs[0], format, timing, currentHeaders); err != nil {
+				logVerbose("Reloading the MPD of the live stream failed", "error", err)
+			} else if isEnded {
+				lastSegment = endSegment
+			}
+			nextUpdate = time.Now().Add(timing.minimumUpdatePeriod)
+		}
+	}
+
+	logInfo("\nThe live stream ended\n")
+	return recordedSegments, nil
+}
+
+//recordLiveDashTracks records the live tracks of the given DASH formats at once into the given track files until the stream ends (or)
+//Ctrl-C is pressed. A track failing stops the others, the tracks recorded so far being kept unless one has recorded nothing.
+func recordLiveDashTracks(videoFormats map[string]map[string]string, formatCodes []string, trackFilePaths []string, requestHeaders map[string]string, refreshFormats FormatsRefresher, live LiveOptions) ([][]string, error) {
+	timing, err := getLiveDashTiming(videoFormats[formatCodes[0]])
+	if err != nil {
+		return nil, err
+	}
+	if startTime := timing.availabilityStartTime; startTime.After(time.Now()) && live.WaitForVideo <= 0 {
+		return nil, fmt.Errorf("The live stream starts at %s. Use --wait-for-video to wait for it", startTime.Local().Format(time.RFC1123))
+	}
+
+	interrupted, stopWatching := watchInterrupt()
+	defer stopWatching()
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopRecording := func() { stopOnce.Do(func() { close(stop) }) }
+	go func() {
+		select {
+		case <-interrupted:
+			stopRecording()
+		case <-stop:
+		}
+	}()
+
+	logInfo("\nRecording the live stream, press Ctrl-C to stop and finalize the recording\n")
+
+	recordedSegments := make([]int, len(formatCodes))
+	errs := make([]error, len(formatCodes))
+	var recording sync.WaitGroup
+	for index, formatCode := range formatCodes {
+		recording.Add(1)
+		go func(index int, formatCode string) {
+			defer recording.Done()
+			//the tracks refresh their own signed urls and the headers along
+			recordedSegments[index], errs[index] = RecordLiveDashStream(trackFilePaths[index], formatCode, getEquivalentFormats(videoFormats, formatCode), CopyMap(requestHeaders), refreshFormats, live.FromStart, stop)
+			if errs[index] != nil {
+				stopRecording()
+			}
+		}(index, formatCode)
+	}
+	recording.Wait()
+	stopRecording()
+
+	dashTracks := make([][]string, 0, len(formatCodes))
+	for index := range formatCodes {
+		if recordedSegments[index] == 0 {
+			if errs[index] != nil {
+				return nil, errs[index]
+			}
+			return nil, fmt.Errorf("Nothing of the live stream was recorded")
+		}
+		if errs[index] != nil {
+			logInfo("\nThe recording of %s stopped early, keeping what was recorded: %s\n", formatCodes[index], errs[index])
+		}
+		dashTracks = append(dashTracks, []string{trackFilePaths[index]})
+	}
+	return dashTracks, nil
+}
+
+//recordLiveHLS records the live HLS stream into a recording part file, remuxed into the given output file by ffmpeg once the stream ends
+//(or) Ctrl-C is pressed
+func recordLiveHLS(videoURL string, ffmpegPath string, videoMetadata map[string]string, streamURL string, live LiveOptions, metadataFlag bool, outputFileName string, formatCode string) error {
+	recordingFilePath := strings.TrimSuffix(outputFileName, ".part") + ".live.part"
+	defer os.Remove(recordingFilePath)
+
+	stop, stopWatching := watchInterrupt()
+	logInfo("\nRecording the live stream, press Ctrl-C to stop and finalize the recording\n")
+	recordedSegments, err := RecordLiveHLS(recordingFilePath, formatCode, streamURL, getStreamRequestHeaders(videoURL), live.FromStart, stop)
+	stopWatching()
+	if recordedSegments == 0 {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("Nothing of the live stream was recorded")
+	}
+	if err != nil {
+		logInfo("\nThe recording stopped early, keeping what was recorded: %s\n", err)
+	}
+
+	return runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, "", [][]string{{recordingFilePath}}, metadataFlag, outputFileName, true, formatCode, 0, nil, nil)
+}
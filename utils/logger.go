@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//LogLevel is the level of detail of the messages logged.
+type LogLevel int
+
+//Log levels, each one logging the messages of the ones before it as well
+const (
+	LogQuiet   LogLevel = iota //errors only
+	LogNormal                  //the steps of the downloads
+	LogVerbose                 //retries, fallbacks, format decisions and ffmpeg command lines
+	LogDebug                   //the api requests and responses
+	LogTrace                   //the CDN requests of every chunk and the request headers
+)
+
+var logLevelNames = []string{"quiet", "normal", "verbose", "debug", "trace"}
+
+func (level LogLevel) String() string {
+	if level < LogQuiet || level > LogTrace {
+		return fmt.Sprintf("level%d", int(level))
+	}
+	return logLevelNames[level]
+}
+
+//ParseLogLevel parses the log level of the given name.
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level, levelName := range logLevelNames {
+		if strings.EqualFold(strings.TrimSpace(name), levelName) {
+			return LogLevel(level), nil
+		}
+	}
+	return LogNormal, fmt.Errorf("Invalid log level %s. Should be one of %s", name, strings.Join(logLevelNames, ", "))
+}
+
+//GetLogLevelNames returns the names of the log levels, from the least detailed.
+func GetLogLevelNames() []string {
+	return append([]string{}, logLevelNames...)
+}
+
+var logger = struct {
+	mutex         sync.Mutex
+	level         LogLevel
+	output        io.Writer //output of the detailed messages, stderr when nil
+	messageOutput io.Writer //output of the normal messages and the listings, stdout when nil
+}{level: LogNormal}
+
+//SetLogLevel sets the level of detail of the messages logged.
+func SetLogLevel(level LogLevel) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.level = level
+}
+
+//SetLogOutput sets the writer of the verbose, debug and trace messages, stderr when nil. The normal messages go to the message output.
+func SetLogOutput(output io.Writer) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.output = output
+}
+
+//SetMessageOutput sets the writer of the normal messages and the listings like the format table, stdout when nil.
+func SetMessageOutput(output io.Writer) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.messageOutput = output
+}
+
+//GetMessageOutput returns the writer of the normal messages and the listings set with SetMessageOutput.
+func GetMessageOutput() io.Writer {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	if logger.messageOutput == nil {
+		return os.Stdout
+	}
+	return logger.messageOutput
+}
+
+func isLogging(level LogLevel) bool {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	return level <= logger.level
+}
+
+//logInfo prints the message of a step of the downloads like fmt.Printf to the message output, unless quiet
+func logInfo(format string, args ...interface{}) {
+	if isLogging(LogNormal) {
+		fmt.Fprintf(GetMessageOutput(), format, args...)
+	}
+}
+
+func logVerbose(message string, fields ...interface{}) {
+	logFields(LogVerbose, message, fields...)
+}
+
+func logDebug(message string, fields ...interface{}) {
+	logFields(LogDebug, message, fields...)
+}
+
+func logTrace(message string, fields ...interface{}) {
+	logFields(LogTrace, message, fields...)
+}
+
+//logFields logs the message at the given level followed by the given key value pairs, redacting the secrets in them
+func logFields(level LogLevel, message string, fields ...interface{}) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	if level > logger.level {
+		return
+	}
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "[%s] %s", level, message)
+	for index := 0; index+1 < len(fields); index += 2 {
+		fmt.Fprintf(&line, " %v=%s", fields[index], formatLogValue(fields[index+1]))
+	}
+	line.WriteString("\n")
+
+	output := logger.output
+	if output == nil {
+		output = os.Stderr
+	}
+	io.WriteString(output, line.String())
+}
+
+//formatLogValue formats the value of a field, quoted when it has spaces (or) is empty
+func formatLogValue(value interface{}) string {
+	var text string
+	switch typedValue := value.(type) {
+	case map[string]string:
+		text = formatLogHeaders(typedValue)
+	case []string:
+		text = strings.Join(typedValue, " ")
+	default:
+		text = fmt.Sprint(value)
+	}
+	text = Redact(text)
+	if text == "" || strings.ContainsAny(text, " \t\n\"") {
+		return fmt.Sprintf("%q", text)
+	}
+	return text
+}
+
+//formatLogHeaders formats the headers sorted by name, with the values of the secret ones redacted
+func formatLogHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		value := headers[name]
+		if secretHeaders[strings.ToLower(name)] {
+			value = redactedValue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s: %s", name, value))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+const redactedValue = "REDACTED"
+
+//secretHeaders are the lower case names of the headers whose values are redacted
+var secretHeaders = map[string]bool{
+	"x-hs-usertoken": true,
+	"useridentity":   true,
+	"hotstarauth":    true,
+	"authorization":  true,
+	"cookie":         true,
+	"set-cookie":     true,
+}
+
+//secretRegex matches the signed url tokens and the user tokens in urls, headers and command lines
+var secretRegex = regexp.MustCompile(`(?i)((?:hdnea|hdntl|hmac|token|usertoken|useridentity|x-hs-usertoken|hotstarauth|authorization|cookie)\s*[=:]\s*"?)([^&\s",;]+)`)
+
+//Redact replaces the secrets like the hdnea signed url tokens and the X-HS-UserToken user tokens in the given text.
+func Redact(text string) string {
+	return secretRegex.ReplaceAllString(text, "${1}"+redactedValue)
+}
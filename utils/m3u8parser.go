@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"encoding/hex"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -90,3 +92,197 @@ func ParseM3u8Content(m3u8Content string, playbackURL string, playbackURLData st
 
 	return urlFormats
 }
+
+//GetM3u8Duration returns the duration in seconds of the media playlist, the sum of the durations of its segments.
+func GetM3u8Duration(m3u8Content string) float64 {
+	var duration float64
+	for _, line := range strings.Split(m3u8Content, "\n") {
+		if !strings.HasPrefix(line, "#EXTINF:") {
+			continue
+		}
+		segmentDuration := strings.TrimSpace(strings.SplitN(strings.TrimPrefix(line, "#EXTINF:"), ",", 2)[0])
+		if seconds, err := strconv.ParseFloat(segmentDuration, 64); err == nil {
+			duration += seconds
+		}
+	}
+	return duration
+}
+
+//IsLiveM3u8 checks whether the media playlist is of a live stream, still getting segments added as it has no EXT-X-ENDLIST.
+func IsLiveM3u8(m3u8Content string) bool {
+	return strings.Contains(m3u8Content, "#EXTINF:") && !strings.Contains(m3u8Content, "#EXT-X-ENDLIST") && !strings.Contains(m3u8Content, "#EXT-X-PLAYLIST-TYPE:VOD")
+}
+
+//m3u8Key is the AES-128 key the segments of a HLS media playlist are encrypted with
+type m3u8Key struct {
+	URI string //absolute url of the key
+	IV  []byte //initialization vector, nil to derive it from the media sequence number of the segment
+}
+
+//m3u8Segment is a segment of a HLS media playlist
+type m3u8Segment struct {
+	URI      string //absolute url of the segment
+	Duration float64
+	Sequence int      //media sequence number of the segment
+	Key      *m3u8Key //key the segment is encrypted with, nil for the clear segments
+}
+
+//m3u8MediaPlaylist is a HLS media playlist, with the urls made absolute
+type m3u8MediaPlaylist struct {
+	TargetDuration float64
+	MapURI         string   //url of the init section of the fMP4 segments, empty for the MPEG-TS segments
+	MapKey         *m3u8Key //key the init section is encrypted with, nil when it is clear
+	Segments       []m3u8Segment
+	IsEnded        bool //no more segments are added
+}
+
+var m3u8URIRegex = regexp.MustCompile(`URI="([^"]*)"`)
+
+var m3u8AttributeRegex = regexp.MustCompile(`([A-Z0-9\-]+)=("[^"]*"|[^",]*)`)
+
+//parseM3u8Attributes parses the attribute list of the tag, with the quotes of the quoted values removed
+func parseM3u8Attributes(tag string) map[string]string {
+	attributes := make(map[string]string)
+	attributeList := tag[strings.Index(tag, ":")+1:]
+	for _, attribute := range m3u8AttributeRegex.FindAllStringSubmatch(attributeList, -1) {
+		attributes[attribute[1]] = strings.Trim(attribute[2], `"`)
+	}
+	return attributes
+}
+
+//getM3u8Key returns the AES-128 key of the EXT-X-KEY tag, nil for METHOD=NONE. The keys which aren't clear keys, like SAMPLE-AES (or) the
+//key formats of Widevine, PlayReady and FairPlay, fail with ErrDRMProtected.
+func getM3u8Key(tag string, baseURL *url.URL) (*m3u8Key, error) {
+	attributes := parseM3u8Attributes(tag)
+	method, keyFormat := attributes["METHOD"], attributes["KEYFORMAT"]
+	switch {
+	case method == "NONE":
+		return nil, nil
+	case method != "AES-128":
+		return nil, fmt.Errorf("%w: the HLS segments are encrypted with %s", ErrDRMProtected, method)
+	case keyFormat != "" && keyFormat != "identity":
+		return nil, fmt.Errorf("%w: the HLS segments are encrypted with the key format %s", ErrDRMProtected, keyFormat)
+	case attributes["URI"] == "":
+		return nil, fmt.Errorf("The AES-128 key of the HLS segments has no URI")
+	}
+
+	key := &m3u8Key{URI: resolveM3u8URI(attributes["URI"], baseURL)}
+	if ivHex := attributes["IV"]; ivHex != "" {
+		iv, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(ivHex, "0x"), "0X"))
+		if err != nil || len(iv) != 16 {
+			return nil, fmt.Errorf("Invalid IV %s of the AES-128 key %s", ivHex, key.URI)
+		}
+		key.IV = iv
+	}
+	return key, nil
+}
+
+//parseM3u8MediaPlaylist parses the media playlist at the given url, numbering its segments from its EXT-X-MEDIA-SEQUENCE
+func parseM3u8MediaPlaylist(m3u8Content string, playlistURL string) (*m3u8MediaPlaylist, error) {
+	baseURL, err := url.Parse(playlistURL)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist := &m3u8MediaPlaylist{}
+	sequence := 0
+	var segmentDuration float64
+	//the EXT-X-KEY tags in a row are the alternative keys of the segments following, the clear key being used when there is one
+	var key *m3u8Key
+	var keyErr error
+	isNewKeyGroup := true
+	for _, line := range strings.Split(strings.Replace(m3u8Content, "\r", "", -1), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			playlist.TargetDuration, _ = strconv.ParseFloat(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"), 64)
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			if uriMatch := m3u8URIRegex.FindStringSubmatch(line); uriMatch != nil {
+				playlist.MapURI = resolveM3u8URI(uriMatch[1], baseURL)
+				playlist.MapKey = key
+			}
+		case strings.HasPrefix(line, "#EXT-X-KEY:"):
+			if isNewKeyGroup {
+				key, keyErr, isNewKeyGroup = nil, nil, false
+			}
+			if tagKey, err := getM3u8Key(line, baseURL); err != nil {
+				keyErr = err
+			} else if tagKey != nil {
+				key = tagKey
+			}
+		case line == "#EXT-X-ENDLIST":
+			playlist.IsEnded = true
+		case strings.HasPrefix(line, "#EXTINF:"):
+			segmentDuration, _ = strconv.ParseFloat(strings.TrimSpace(strings.SplitN(strings.TrimPrefix(line, "#EXTINF:"), ",", 2)[0]), 64)
+		case strings.HasPrefix(line, "#"):
+		default:
+			if key == nil && keyErr != nil {
+				return nil, keyErr
+			}
+			isNewKeyGroup = true
+			playlist.Segments = append(playlist.Segments, m3u8Segment{URI: resolveM3u8URI(line, baseURL), Duration: segmentDuration, Sequence: sequence, Key: key})
+			sequence++
+			segmentDuration = 0
+		}
+	}
+
+	if len(playlist.Segments) == 0 && !playlist.IsEnded {
+		return nil, fmt.Errorf("The HLS playlist %s has no segments", playlistURL)
+	}
+	return playlist, nil
+}
+
+//isEncryptedM3u8 checks whether the segments of the media playlist are encrypted with AES-128, failing with ErrDRMProtected for those
+//encrypted with DRM (or) with the error in parsing the keys
+func isEncryptedM3u8(m3u8Content string, playlistURL string) (bool, error) {
+	if !strings.Contains(m3u8Content, "#EXT-X-KEY:") {
+		return false, nil
+	}
+	playlist, err := parseM3u8MediaPlaylist(m3u8Content, playlistURL)
+	if err != nil {
+		return false, err
+	}
+	for _, segment := range playlist.Segments {
+		if segment.Key != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//getM3u8Hosts returns the hosts with their ports of the playlist at the given url and of the urls in it, like its segments and keys
+func getM3u8Hosts(playlistURL string, m3u8Content string) []string {
+	baseURL, err := url.Parse(playlistURL)
+	if err != nil {
+		return nil
+	}
+
+	hosts := make([]string, 0)
+	isAdded := make(map[string]bool)
+	addHost := func(uri string) {
+		if parsedURL, err := url.Parse(uri); err == nil && parsedURL.Host != "" {
+			if host := getProxyHost(parsedURL.Host, parsedURL.Scheme); !isAdded[host] {
+				hosts = append(hosts, host)
+				isAdded[host] = true
+			}
+		}
+	}
+
+	addHost(playlistURL)
+	for _, line := range strings.Split(strings.Replace(m3u8Content, "\r", "", -1), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#"):
+			if uriMatch := m3u8URIRegex.FindStringSubmatch(line); uriMatch != nil {
+				addHost(resolveM3u8URI(uriMatch[1], baseURL))
+			}
+		default:
+			addHost(resolveM3u8URI(line, baseURL))
+		}
+	}
+	return hosts
+}
@@ -1,30 +1,77 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 )
 
-//GetMasterPlaybackURLs gets master playback urls from playback uri page contents.
-func GetMasterPlaybackURLs(playbackURIPageContents []byte) ([]string, error) {
-	var result map[string]interface{}
-	json.Unmarshal(playbackURIPageContents, &result)
+//PlaybackSet is a playBackSet of the playback api response, a master playback url along with the tags of the streams in it.
+type PlaybackSet struct {
+	PlaybackURL     string
+	TagsCombination string
+	CDNType         string
+	TokenAlgorithm  string
+}
+
+//Tags returns the tags of the playback set like package, encryption, ladder, container (or) video_codec.
+func (playbackSet *PlaybackSet) Tags() map[string]string {
+	return ParsePlaybackSetTags(playbackSet.TagsCombination)
+}
+
+//ParsePlaybackSetTags parses the tags combination of a playBackSet like "container:ts;encryption:plain;ladder:phone;package:hls".
+func ParsePlaybackSetTags(tagsCombination string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(tagsCombination, ";") {
+		if tagParts := strings.SplitN(tag, ":", 2); len(tagParts) == 2 {
+			tags[tagParts[0]] = tagParts[1]
+		}
+	}
+	return tags
+}
+
+//GetPlaybackSets gets the playBackSets from playback uri page contents.
+func GetPlaybackSets(playbackURIPageContents []byte) ([]PlaybackSet, error) {
+	var result playbackResponse
+	if err := decodeResponse(playbackEndpoint, playbackURIPageContents, &result); err != nil {
+		return make([]PlaybackSet, 0), err
+	}
+
+	if err := result.validate(); err != nil {
+		return make([]PlaybackSet, 0), err
+	}
 
-	message := result["message"].(string)
+	message := *result.Message
 
 	if strings.Contains(message, "success") {
-		data := result["data"].(map[string]interface{})
-		playbackSets := data["playBackSets"].([]interface{})
-		masterPlaybackUrls := make([]string, 0)
-		for _, v := range playbackSets {
-			playbackSet := v.(map[string]interface{})
-			masterPlaybackUrls = append(masterPlaybackUrls, playbackSet["playbackUrl"].(string))
+		playbackSets := make([]PlaybackSet, 0, len(result.Data.PlayBackSets))
+		for _, playbackSet := range result.Data.PlayBackSets {
+			playbackSets = append(playbackSets, PlaybackSet{
+				PlaybackURL:     *playbackSet.PlaybackURL,
+				TagsCombination: playbackSet.TagsCombination,
+				CDNType:         playbackSet.CDNType,
+				TokenAlgorithm:  playbackSet.TokenAlgorithm,
+			})
 		}
-		return masterPlaybackUrls, nil
+		return playbackSets, nil
+	}
+
+	if apiError := newAPIError(playbackEndpoint, 0, result.ErrorCode, message, false); apiError != nil {
+		return make([]PlaybackSet, 0), apiError
+	}
+
+	return make([]PlaybackSet, 0), fmt.Errorf("Error: %s", message)
+}
+
+//GetMasterPlaybackURLs gets master playback urls from playback uri page contents.
+func GetMasterPlaybackURLs(playbackURIPageContents []byte) ([]string, error) {
+	playbackSets, err := GetPlaybackSets(playbackURIPageContents)
+
+	masterPlaybackUrls := make([]string, 0, len(playbackSets))
+	for _, playbackSet := range playbackSets {
+		masterPlaybackUrls = append(masterPlaybackUrls, playbackSet.PlaybackURL)
 	}
 
-	return make([]string, 0), fmt.Errorf("Error: %s", message)
+	return masterPlaybackUrls, err
 }
 
 /*
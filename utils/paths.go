@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//Path types of the paths given as TYPE:PATH
+const (
+	PathHome   = "home"
+	PathTemp   = "temp"
+	PathOutput = "output"
+)
+
+//Paths are the directories the downloads are written in. The empty ones default to the home directory, which defaults to the
+//working directory. The relative ones are taken from the home directory.
+type Paths struct {
+	Home   string //base directory of the other paths
+	Temp   string //directory of the part files and the DASH chunks while downloading
+	Output string //directory of the completed files
+}
+
+//ParsePaths parses the paths given as TYPE:PATH with the types home, temp (or) output. A path without a type is the home path.
+func ParsePaths(values []string) (Paths, error) {
+	var paths Paths
+	for _, value := range values {
+		pathType, path := PathHome, value
+		if separatorIndex := strings.Index(value, ":"); separatorIndex != -1 {
+			//a path like C:\Videos has no type
+			switch prefix := strings.ToLower(value[:separatorIndex]); prefix {
+			case PathHome, PathTemp, PathOutput:
+				pathType, path = prefix, value[separatorIndex+1:]
+			}
+		}
+		if strings.TrimSpace(path) == "" {
+			return Paths{}, fmt.Errorf("Empty %s path in %s", pathType, value)
+		}
+
+		switch pathType {
+		case PathHome:
+			paths.Home = path
+		case PathTemp:
+			paths.Temp = path
+		case PathOutput:
+			paths.Output = path
+		}
+	}
+	return paths, nil
+}
+
+//resolve returns the absolute paths with the defaults filled in, creating the temp and the output directories
+func (paths Paths) resolve() (Paths, error) {
+	home := paths.Home
+	if home == "" {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return Paths{}, err
+		}
+		home = workingDirectory
+	}
+	home, err := filepath.Abs(home)
+	if err != nil {
+		return Paths{}, err
+	}
+
+	resolved := Paths{Home: home, Temp: home, Output: home}
+	for _, path := range []struct {
+		value    string
+		resolved *string
+	}{{paths.Temp, &resolved.Temp}, {paths.Output, &resolved.Output}} {
+		if path.value == "" {
+			continue
+		}
+		if filepath.IsAbs(path.value) {
+			*path.resolved = filepath.Clean(path.value)
+		} else {
+			*path.resolved = filepath.Join(home, path.value)
+		}
+	}
+
+	for _, directory := range []string{resolved.Home, resolved.Temp, resolved.Output} {
+		if err := os.MkdirAll(directory, 0755); err != nil {
+			return Paths{}, fmt.Errorf("Error in creating directory %s: %s", directory, err)
+		}
+	}
+	return resolved, nil
+}
+
+//getOutputFilePath returns the path of the output file of the given name, taken from the output directory unless absolute
+func (paths Paths) getOutputFilePath(outputFileName string) string {
+	if filepath.IsAbs(outputFileName) {
+		return outputFileName
+	}
+	return filepath.Join(paths.Output, outputFileName)
+}
+
+//getPartFilePath returns the path in the temp directory of the part file written while downloading the given output file
+func (paths Paths) getPartFilePath(outputFilePath string) string {
+	return filepath.Join(paths.Temp, filepath.Base(outputFilePath)+".part")
+}
+
+//moveFile moves the completed file into place, copying it when the temp and the output directories are on different devices.
+//The copy goes to a part file next to the destination first, so the destination only appears once complete.
+func moveFile(sourcePath string, destinationPath string) error {
+	if err := os.Rename(sourcePath, destinationPath); err == nil {
+		return nil
+	}
+
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	copyPath := destinationPath + ".part"
+	destination, err := os.Create(copyPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(destination, source)
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(copyPath, destinationPath)
+	}
+	if err != nil {
+		os.Remove(copyPath)
+		return fmt.Errorf("Error in moving %s to %s: %s", sourcePath, destinationPath, err)
+	}
+
+	source.Close()
+	return os.Remove(sourcePath)
+}
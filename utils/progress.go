@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+//ProgressEventType is the type of a progress event emitted while downloading.
+type ProgressEventType string
+
+//Progress event types, in the order they are emitted for a download
+const (
+	EventResolved       ProgressEventType = "resolved"        //the formats and the metadata of the video are fetched
+	EventFormatSelected ProgressEventType = "format-selected" //the format to download is chosen
+	EventSegmentDone    ProgressEventType = "segment-done"    //a DASH chunk is downloaded
+	EventBytes          ProgressEventType = "bytes"           //ffmpeg wrote more of the video
+	EventMergeStarted   ProgressEventType = "merge-started"   //the downloaded DASH tracks are being merged
+	EventFinished       ProgressEventType = "finished"        //the video is downloaded
+	EventError          ProgressEventType = "error"           //the video failed to download
+)
+
+//ProgressEvent is a step (or) a progress update of a download. The fields not applying to the type of the event are left empty.
+type ProgressEvent struct {
+	Type          ProgressEventType `json:"event"`
+	Time          time.Time         `json:"time"`
+	URL           string            `json:"url,omitempty"`
+	VideoID       string            `json:"videoId,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Format        string            `json:"format,omitempty"`
+	Segment       int               `json:"segment,omitempty"`       //number of the DASH chunk done, from 1
+	TotalSegments int               `json:"totalSegments,omitempty"` //count of the DASH chunks of the format
+	Bytes         int64             `json:"bytes,omitempty"`         //bytes downloaded (or) written so far for the format
+	OutTime       float64           `json:"outTime,omitempty"`       //seconds of the video written so far by ffmpeg
+	Duration      float64           `json:"duration,omitempty"`      //seconds of the whole video, when known
+	Speed         float64           `json:"speed,omitempty"`         //ffmpeg processing speed relative to the playback
+	Path          string            `json:"path,omitempty"`          //path of the completed file
+	Error         string            `json:"error,omitempty"`
+	ErrorKind     ErrorKind         `json:"errorKind,omitempty"`
+}
+
+//ProgressReporter receives the progress events of the downloads.
+type ProgressReporter interface {
+	Report(event ProgressEvent)
+}
+
+//progressReporter receives the events of the downloads, the terminal progress bars by default
+var progressReporter ProgressReporter = NewTerminalProgress()
+
+//progressJob is the url and the video id of the job being run, added to its events
+var progressJob struct {
+	mutex   sync.Mutex
+	url     string
+	videoID string
+}
+
+//SetProgressReporter sets the reporter receiving the progress events of the downloads. Nil reports nothing.
+func SetProgressReporter(reporter ProgressReporter) {
+	if reporter == nil {
+		reporter = QuietProgress{}
+	}
+	progressReporter = reporter
+}
+
+//setProgressJob sets the job whose url and video id are added to the events reported from now on
+func setProgressJob(url string, videoID string) {
+	progressJob.mutex.Lock()
+	defer progressJob.mutex.Unlock()
+	progressJob.url, progressJob.videoID = url, videoID
+}
+
+func reportProgress(event ProgressEvent) {
+	progressJob.mutex.Lock()
+	event.URL, event.VideoID = progressJob.url, progressJob.videoID
+	progressJob.mutex.Unlock()
+	event.Time = time.Now()
+	progressReporter.Report(event)
+}
+
+//QuietProgress ignores the progress events.
+type QuietProgress struct{}
+
+//Report ignores the event.
+func (QuietProgress) Report(event ProgressEvent) {}
+
+//MultiProgress reports the progress events to each of its reporters.
+type MultiProgress []ProgressReporter
+
+//Report reports the event to each reporter.
+func (reporters MultiProgress) Report(event ProgressEvent) {
+	for _, reporter := range reporters {
+		reporter.Report(event)
+	}
+}
+
+//JSONProgress writes the progress events as newline delimited JSON.
+type JSONProgress struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+//NewJSONProgress creates the reporter writing the events to the given writer, one JSON object per line.
+func NewJSONProgress(writer io.Writer) *JSONProgress {
+	return &JSONProgress{encoder: json.NewEncoder(writer)}
+}
+
+//Report writes the event as a line of JSON.
+func (progress *JSONProgress) Report(event ProgressEvent) {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	progress.encoder.Encode(event)
+}
+
+//TerminalProgress shows the DASH chunks done and the progress of ffmpeg on progress bars.
+type TerminalProgress struct {
+	mutex  sync.Mutex
+	bar    *pb.ProgressBar
+	format string //format of the shown bar
+}
+
+//NewTerminalProgress creates the reporter showing the progress bars.
+func NewTerminalProgress() *TerminalProgress {
+	return &TerminalProgress{}
+}
+
+//Report updates the progress bar, finishing it once the download moves on to another step.
+func (progress *TerminalProgress) Report(event ProgressEvent) {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	switch event.Type {
+	case EventSegmentDone:
+		if progress.bar == nil || progress.format != event.Format || progress.bar.Current() >= int64(event.Segment) {
+			progress.finishBar()
+			progress.bar, progress.format = pb.StartNew(event.TotalSegments), event.Format
+		}
+		progress.bar.SetCurrent(int64(event.Segment))
+	case EventBytes:
+		if progress.bar == nil || progress.format != event.Format {
+			progress.finishBar()
+			progress.bar, progress.format = newFfmpegProgressBar(event.Duration), event.Format
+		}
+		updateFfmpegProgressBar(progress.bar, event)
+	default:
+		progress.finishBar()
+	}
+}
+
+func (progress *TerminalProgress) finishBar() {
+	if progress.bar != nil {
+		progress.bar.Finish()
+		progress.bar, progress.format = nil, ""
+	}
+}
+
+//newFfmpegProgressBar starts the bar of the progress of ffmpeg in milliseconds of the video, showing the percentage and the ETA when
+//the duration is known
+func newFfmpegProgressBar(duration float64) *pb.ProgressBar {
+	bar := pb.New64(int64(duration * 1000))
+	if duration > 0 {
+		bar.SetTemplateString(`{{percent .}} {{bar .}} {{rtime . "ETA %s"}} {{string . "size"}} {{string . "speed"}}`)
+	} else {
+		bar.SetTemplateString(`{{string . "time"}} {{string . "size"}} {{string . "speed"}}`)
+	}
+	return bar.Start()
+}
+
+func updateFfmpegProgressBar(bar *pb.ProgressBar, event ProgressEvent) {
+	outTime := event.OutTime
+	if event.Duration > 0 && outTime > event.Duration {
+		outTime = event.Duration
+	}
+	bar.SetCurrent(int64(outTime * 1000))
+	bar.Set("time", (time.Duration(outTime) * time.Second).String())
+	if event.Bytes > 0 {
+		bar.Set("size", fmt.Sprintf("%.1f MiB", float64(event.Bytes)/(1<<20)))
+	}
+	if event.Speed > 0 {
+		bar.Set("speed", fmt.Sprintf("%.2fx", event.Speed))
+	}
+}
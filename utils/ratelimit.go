@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//rateLimiter is a token bucket of bytes, holding up to a second worth of the rate
+type rateLimiter struct {
+	mutex      sync.Mutex
+	rate       float64 //bytes per second
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	return &rateLimiter{rate: float64(bytesPerSecond), tokens: float64(bytesPerSecond), lastRefill: time.Now()}
+}
+
+//burst returns the most bytes taken at once, those of a second
+func (limiter *rateLimiter) burst() int {
+	if limiter.rate < 1 {
+		return 1
+	}
+	return int(limiter.rate)
+}
+
+//wait takes the given count of bytes from the bucket, sleeping until the bucket has refilled enough
+func (limiter *rateLimiter) wait(size int) {
+	limiter.mutex.Lock()
+	now := time.Now()
+	limiter.tokens += now.Sub(limiter.lastRefill).Seconds() * limiter.rate
+	if limiter.tokens > limiter.rate {
+		limiter.tokens = limiter.rate
+	}
+	limiter.lastRefill = now
+	limiter.tokens -= float64(size)
+	delay := time.Duration(-limiter.tokens / limiter.rate * float64(time.Second))
+	limiter.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+//downloadLimiter limits the rate of all the downloads together, nil when unlimited
+var downloadLimiter struct {
+	mutex   sync.Mutex
+	limiter *rateLimiter
+}
+
+//SetLimitRate sets the most bytes per second downloaded across all the DASH chunks and the HLS streams being fetched. 0 is unlimited.
+func SetLimitRate(bytesPerSecond int64) {
+	downloadLimiter.mutex.Lock()
+	defer downloadLimiter.mutex.Unlock()
+	if bytesPerSecond <= 0 {
+		downloadLimiter.limiter = nil
+		return
+	}
+	downloadLimiter.limiter = newRateLimiter(bytesPerSecond)
+}
+
+func getDownloadLimiter() *rateLimiter {
+	downloadLimiter.mutex.Lock()
+	defer downloadLimiter.mutex.Unlock()
+	return downloadLimiter.limiter
+}
+
+var rateRegex = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([kmg]?)(?:i?b)?(?:/s)?$`)
+
+//ParseRate parses a rate in bytes per second like 500K (or) 4.2M, with the suffixes K, M and G in multiples of 1024.
+func ParseRate(value string) (int64, error) {
+	matches := rateRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0, fmt.Errorf("Invalid rate %s. Should be a number of bytes per second like 500K (or) 4.2M", value)
+	}
+	rate, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid rate %s: %s", value, err)
+	}
+	switch strings.ToUpper(matches[2]) {
+	case "K":
+		rate *= 1 << 10
+	case "M":
+		rate *= 1 << 20
+	case "G":
+		rate *= 1 << 30
+	}
+	if rate < 1 {
+		return 0, fmt.Errorf("Invalid rate %s. Should be at least 1 byte per second", value)
+	}
+	return int64(rate), nil
+}
+
+//limitedReader reads through the rate limiter, at most a burst at a time
+type limitedReader struct {
+	reader  io.Reader
+	limiter *rateLimiter
+}
+
+func (reader *limitedReader) Read(buffer []byte) (int, error) {
+	if burst := reader.limiter.burst(); len(buffer) > burst {
+		buffer = buffer[:burst]
+	}
+	count, err := reader.reader.Read(buffer)
+	if count > 0 {
+		reader.limiter.wait(count)
+	}
+	return count, err
+}
+
+//limitedBody is a response body read through the rate limiter
+type limitedBody struct {
+	limitedReader
+	body io.Closer
+}
+
+func (body *limitedBody) Close() error {
+	return body.body.Close()
+}
+
+//limitedTransport reads the response bodies through the download rate limiter when set
+type limitedTransport struct {
+	base http.RoundTripper
+}
+
+func (transport limitedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := transport.base.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	if limiter := getDownloadLimiter(); limiter != nil {
+		response.Body = &limitedBody{limitedReader: limitedReader{reader: response.Body, limiter: limiter}, body: response.Body}
+	}
+	return response, nil
+}
+
+//requestPacing spaces out the api requests by the interval set, shared by the api clients with (or) without the proxy
+var requestPacing struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time //earliest time of the next request
+}
+
+//SetSleepRequests sets the time to wait between the requests to the Hotstar website and apis. 0 doesn't wait.
+func SetSleepRequests(interval time.Duration) {
+	requestPacing.mutex.Lock()
+	defer requestPacing.mutex.Unlock()
+	requestPacing.interval = interval
+	requestPacing.next = time.Time{}
+}
+
+//pacedTransport waits the interval set with SetSleepRequests since the previous request before sending each request
+type pacedTransport struct {
+	base http.RoundTripper
+}
+
+func (transport pacedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestPacing.mutex.Lock()
+	now := time.Now()
+	delay := requestPacing.next.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	requestPacing.next = now.Add(delay + requestPacing.interval)
+	requestPacing.mutex.Unlock()
+
+	if delay > 0 {
+		logVerbose("Sleeping before the request", "url", request.URL, "delay", delay)
+		time.Sleep(delay)
+	}
+	return transport.base.RoundTrip(request)
+}
+
+//startLimitedProxy starts a local HTTP proxy passing the downloads of ffmpeg through the rate limiter. It connects only to the given
+//hosts of the stream, like example.com:443, refusing the requests to the others. It returns the url of the proxy and the function
+//stopping it.
+func startLimitedProxy(limiter *rateLimiter, allowedHosts []string) (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("Error in starting the rate limiting proxy: %s", err)
+	}
+
+	isAllowedHost := make(map[string]bool)
+	for _, host := range allowedHosts {
+		isAllowedHost[host] = true
+	}
+
+	//the connections are counted under the mutex, so that none is added once stopping
+	var mutex sync.Mutex
+	var connections sync.WaitGroup
+	isStopping := false
+	go func() {
+		for {
+			connection, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			if isStopping {
+				mutex.Unlock()
+				connection.Close()
+				return
+			}
+			connections.Add(1)
+			mutex.Unlock()
+			go func() {
+				defer connections.Done()
+				serveLimitedProxyConnection(connection, limiter, isAllowedHost)
+			}()
+		}
+	}()
+
+	stop := func() {
+		mutex.Lock()
+		isStopping = true
+		mutex.Unlock()
+		listener.Close()
+		connections.Wait()
+	}
+	return "http://" + listener.Addr().String(), stop, nil
+}
+
+//getProxyHost returns the host of the request with its port, the default one of the scheme when it has none
+func getProxyHost(host string, scheme string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if scheme == "https" {
+		return net.JoinHostPort(host, "443")
+	}
+	return net.JoinHostPort(host, "80")
+}
+
+//serveLimitedProxyConnection tunnels the CONNECT requests of the https urls (or) forwards the plain http requests, to the allowed hosts only
+func serveLimitedProxyConnection(connection net.Conn, limiter *rateLimiter, isAllowedHost map[string]bool) {
+	defer connection.Close()
+	reader := bufio.NewReader(connection)
+	for {
+		request, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+
+		if request.Method == http.MethodConnect {
+			if host := getProxyHost(request.Host, "https"); !isAllowedHost[host] {
+				logDebug("Proxied connection refused", "host", host)
+				io.WriteString(connection, "HTTP/1.1 403 Forbidden\r\n\r\n")
+				return
+			}
+			tunnelLimitedProxyConnection(connection, reader, request.Host, limiter)
+			return
+		}
+
+		if host := getProxyHost(request.URL.Host, request.URL.Scheme); !isAllowedHost[host] {
+			logDebug("Proxied request refused", "url", request.URL)
+			io.WriteString(connection, "HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+			return
+		}
+
+		request.RequestURI = ""
+		request.Header.Del("Proxy-Connection")
+		response, err := http.DefaultTransport.RoundTrip(request)
+		if err != nil {
+			logDebug("Proxied request failed", "url", request.URL, "error", err)
+			return
+		}
+		response.Body = &limitedBody{limitedReader: limitedReader{reader: response.Body, limiter: limiter}, body: response.Body}
+		err = response.Write(connection)
+		response.Body.Close()
+		if err != nil || response.Close {
+			return
+		}
+	}
+}
+
+func tunnelLimitedProxyConnection(connection net.Conn, reader *bufio.Reader, host string, limiter *rateLimiter) {
+	remote, err := net.DialTimeout("tcp", host, 30*time.Second)
+	if err != nil {
+		logDebug("Proxied connection failed", "host", host, "error", err)
+		io.WriteString(connection, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	defer remote.Close()
+	if _, err := io.WriteString(connection, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		return
+	}
+
+	go func() {
+		io.Copy(remote, reader)
+		remote.Close()
+	}()
+	io.Copy(connection, &limitedReader{reader: remote, limiter: limiter})
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//DefaultRegion is the Hotstar region the requests are made for when none is selected
+const DefaultRegion = "in"
+
+var regionRegex = regexp.MustCompile(`^[a-z]{2}$`)
+
+//region is the Hotstar region sent in the country headers and the api paths
+var region = DefaultRegion
+
+//SetRegion sets the Hotstar region, given as a two letter country code like in (or) us, the requests are made for.
+//Empty region restores the default one.
+func SetRegion(country string) error {
+	country = strings.ToLower(strings.TrimSpace(country))
+	if country == "" {
+		region = DefaultRegion
+		return nil
+	}
+	if !regionRegex.MatchString(country) {
+		return fmt.Errorf("Invalid region %s. Should be a two letter country code like in (or) us", country)
+	}
+	region = country
+	return nil
+}
+
+//GetRegion returns the Hotstar region the requests are made for.
+func GetRegion() string {
+	return region
+}
+
+//getCountryCode returns the country code of the region as sent in the X-Country-Code header
+func getCountryCode() string {
+	return strings.ToUpper(region)
+}
+
+//getRefreshTokenURL returns the url of the refresh token api of the region
+func getRefreshTokenURL() string {
+	return fmt.Sprintf(refreshTokenURL, region, region)
+}
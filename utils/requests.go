@@ -1,17 +1,84 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"time"
 )
 
+//HTTPError is returned for the responses with status code other than 200.
+type HTTPError struct {
+	StatusCode int
+}
+
+func (httpError *HTTPError) Error() string {
+	return fmt.Sprintf("Invalid response code: %d", httpError.StatusCode)
+}
+
+//GetHTTPStatusCode returns the status code of the HTTP error wrapped in the given error, 0 if none.
+func GetHTTPStatusCode(err error) int {
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.StatusCode
+	}
+	return 0
+}
+
+//cdnClient is the client of the playlists and the DASH chunks, read at the rate set with SetLimitRate
+var cdnClient = &http.Client{Transport: limitedTransport{base: http.DefaultTransport}}
+
+//apiClient is the client of the metadata and api requests, sent through the geo verification proxy when set and spaced out by the
+//interval set with SetSleepRequests
+var apiClient = newAPIClient(http.DefaultTransport)
+
+func newAPIClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{Transport: pacedTransport{base: transport}}
+}
+
+//SetGeoVerificationProxy sets the proxy the metadata and api requests are sent through while the CDN downloads go direct.
+//Empty proxy url sends them direct as well.
+func SetGeoVerificationProxy(proxyURL string) error {
+	if proxyURL == "" {
+		apiClient = newAPIClient(http.DefaultTransport)
+		return nil
+	}
+
+	parsedProxyURL, err := url.Parse(proxyURL)
+	if err != nil || parsedProxyURL.Scheme == "" || parsedProxyURL.Host == "" {
+		return fmt.Errorf("Invalid geo verification proxy %s. Should be of form scheme://host:port", proxyURL)
+	}
+
+	apiClient = newAPIClient(&http.Transport{Proxy: http.ProxyURL(parsedProxyURL)})
+	return nil
+}
+
 //MakeGetRequest makes GET request for given url with given headers and returns web page contents as bytes with errors if any.
 func MakeGetRequest(url string, headers map[string]string) ([]byte, error) {
+	return MakeRequest("GET", url, headers, nil)
+}
 
-	//fmt.Println("MakeGetRequest url: ", url)
+//MakeRequest makes request of given method for given url with given headers and body and returns response contents as bytes with errors if any.
+func MakeRequest(method string, url string, headers map[string]string, body io.Reader) ([]byte, error) {
+	return doRequest(cdnClient, method, url, headers, body)
+}
 
-	request, err := http.NewRequest("GET", url, nil)
+//makeAPIGetRequest makes GET request to the Hotstar website (or) api through the geo verification proxy if any
+func makeAPIGetRequest(url string, headers map[string]string) ([]byte, error) {
+	return makeAPIRequest("GET", url, headers, nil)
+}
+
+//makeAPIRequest makes request to the Hotstar website (or) api through the geo verification proxy if any
+func makeAPIRequest(method string, url string, headers map[string]string, body io.Reader) ([]byte, error) {
+	return doRequest(apiClient, method, url, headers, body)
+}
+
+func doRequest(client *http.Client, method string, url string, headers map[string]string, body io.Reader) ([]byte, error) {
+
+	request, err := http.NewRequest(method, url, body)
 
 	if err != nil {
 		return nil, err
@@ -22,9 +89,14 @@ func MakeGetRequest(url string, headers map[string]string) ([]byte, error) {
 		request.Header.Set(headerName, headerValue)
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	logDebug("HTTP request", "method", method, "url", url)
+	logTrace("HTTP request headers", "url", url, "headers", headers)
+	startTime := time.Now()
+
+	response, err := client.Do(request)
 
 	if err != nil {
+		logDebug("HTTP request failed", "method", method, "url", url, "error", err)
 		return nil, err
 	}
 
@@ -32,8 +104,10 @@ func MakeGetRequest(url string, headers map[string]string) ([]byte, error) {
 
 	bodyBytes, err := ioutil.ReadAll(response.Body)
 
+	logDebug("HTTP response", "method", method, "url", url, "status", response.StatusCode, "bytes", len(bodyBytes), "duration", time.Since(startTime).Round(time.Millisecond))
+
 	if response.StatusCode != 200 {
-		return bodyBytes, fmt.Errorf("Invalid response code: %d", response.StatusCode)
+		return bodyBytes, &HTTPError{StatusCode: response.StatusCode}
 	}
 
 	if err != nil {
@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//names of the Hotstar endpoints used in the response errors
+const (
+	appStateEndpoint     = "APP_STATE of the video page"
+	playbackEndpoint     = "playback api"
+	trayEndpoint         = "tray api"
+	guestTokenEndpoint   = "guest token api"
+	refreshTokenEndpoint = "refresh token api"
+)
+
+//ResponseError is returned for the responses of the Hotstar endpoints which can't be decoded (or) miss a required field.
+type ResponseError struct {
+	Endpoint string
+	Field    string //the missing (or) malformed field, empty when the response isn't valid JSON
+	Err      error
+}
+
+func (responseError *ResponseError) Error() string {
+	if responseError.Field == "" {
+		return fmt.Sprintf("Invalid response from %s: %s", responseError.Endpoint, responseError.Err)
+	}
+	if responseError.Err == nil {
+		return fmt.Sprintf("Invalid response from %s: missing field %s", responseError.Endpoint, responseError.Field)
+	}
+	return fmt.Sprintf("Invalid response from %s: field %s: %s", responseError.Endpoint, responseError.Field, responseError.Err)
+}
+
+func (responseError *ResponseError) Unwrap() error {
+	return responseError.Err
+}
+
+func missingFieldError(endpoint string, field string) error {
+	return &ResponseError{Endpoint: endpoint, Field: field}
+}
+
+//decodeResponse decodes the JSON response of the given endpoint
+func decodeResponse(endpoint string, contentBytes []byte, result interface{}) error {
+	if err := json.Unmarshal(contentBytes, result); err != nil {
+		if typeError, isTypeError := err.(*json.UnmarshalTypeError); isTypeError && typeError.Field != "" {
+			return &ResponseError{Endpoint: endpoint, Field: typeError.Field, Err: fmt.Errorf("expected %s but got %s", typeError.Type, typeError.Value)}
+		}
+		return &ResponseError{Endpoint: endpoint, Err: err}
+	}
+	return nil
+}
+
+//flexString decodes a JSON string (or) number, like the content ids sent either way
+type flexString string
+
+func (value *flexString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*value = flexString(str)
+		return nil
+	}
+
+	var number json.Number
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&number); err != nil {
+		return fmt.Errorf("expected string (or) number but got %s", data)
+	}
+	*value = flexString(number.String())
+	return nil
+}
+
+//stringList decodes a JSON string (or) list of strings, like the genres sent either way
+type stringList []string
+
+func (values *stringList) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*values = stringList{str}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected string (or) list of strings but got %s", data)
+	}
+	*values = stringList(list)
+	return nil
+}
+
+//ContentMetadata is the metadata of a video as sent in the APP_STATE of its page and in the tray items.
+type ContentMetadata struct {
+	ContentID     *flexString `json:"contentId"`
+	Title         *string     `json:"title"`
+	Description   *string     `json:"description"`
+	BroadcastDate *float64    `json:"broadcastDate"`
+	ChannelName   *string     `json:"channelName"`
+	DrmProtected  *bool       `json:"drmProtected"`
+	Actors        stringList  `json:"actors"`
+	Genre         stringList  `json:"genre"`
+	ShowName      *string     `json:"showName"`
+	EpisodeNo     *int64      `json:"episodeNo"`
+	SeasonNo      *int64      `json:"seasonNo"`
+	PlaybackURI   string      `json:"playbackUri"`
+}
+
+//MetadataMap gives the metadata present as the ffmpeg metadata entries.
+func (content *ContentMetadata) MetadataMap() map[string]string {
+	metaDataMap := make(map[string]string)
+	if content.Title != nil {
+		metaDataMap["title"] = *content.Title
+		metaDataMap["album"] = *content.Title
+	}
+	if content.BroadcastDate != nil {
+		metaDataMap["date"] = GetDateStr(*content.BroadcastDate)
+	}
+	if content.ChannelName != nil {
+		metaDataMap["copyright"] = *content.ChannelName
+	}
+	if content.DrmProtected != nil {
+		metaDataMap["drmProtected"] = fmt.Sprintf("%v", *content.DrmProtected)
+	}
+	if content.Actors != nil {
+		actors := strings.Join(content.Actors, ",\n")
+		metaDataMap["artist"] = actors
+		metaDataMap["album_artist"] = actors
+	}
+	if content.Description != nil {
+		metaDataMap["comment"] = *content.Description
+		metaDataMap["synopsis"] = *content.Description
+	}
+	if content.Genre != nil {
+		metaDataMap["genre"] = strings.Join(content.Genre, ",\n")
+	}
+	if content.ShowName != nil {
+		metaDataMap["show"] = *content.ShowName
+	}
+	if content.EpisodeNo != nil {
+		metaDataMap["episode_id"] = fmt.Sprintf("%d", *content.EpisodeNo)
+	}
+	if content.SeasonNo != nil {
+		metaDataMap["season_number"] = fmt.Sprintf("%d", *content.SeasonNo)
+	}
+	if content.ContentID != nil {
+		metaDataMap["id"] = string(*content.ContentID)
+	}
+	return metaDataMap
+}
+
+//appStatePage is a page of the APP_STATE embedded in the video page
+type appStatePage struct {
+	InitialState *struct {
+		ContentData *struct {
+			Content *ContentMetadata `json:"content"`
+		} `json:"contentData"`
+	} `json:"initialState"`
+}
+
+func (page *appStatePage) validate() (*ContentMetadata, error) {
+	if page.InitialState == nil {
+		return nil, missingFieldError(appStateEndpoint, "initialState")
+	}
+	if page.InitialState.ContentData == nil {
+		return nil, missingFieldError(appStateEndpoint, "initialState.contentData")
+	}
+	if page.InitialState.ContentData.Content == nil {
+		return nil, missingFieldError(appStateEndpoint, "initialState.contentData.content")
+	}
+	return page.InitialState.ContentData.Content, nil
+}
+
+//playbackResponse is the response of the playback api
+type playbackResponse struct {
+	Message   *string `json:"message"`
+	ErrorCode string  `json:"errorCode"`
+	Data      *struct {
+		PlayBackSets []struct {
+			PlaybackURL     *string `json:"playbackUrl"`
+			TagsCombination string  `json:"tagsCombination"`
+			CDNType         string  `json:"playbackCDNType"`
+			TokenAlgorithm  string  `json:"tokenAlgorithm"`
+		} `json:"playBackSets"`
+	} `json:"data"`
+}
+
+func (response *playbackResponse) validate() error {
+	if response.Message == nil {
+		return missingFieldError(playbackEndpoint, "message")
+	}
+	if !strings.Contains(*response.Message, "success") {
+		return nil
+	}
+	if response.Data == nil {
+		return missingFieldError(playbackEndpoint, "data")
+	}
+	if response.Data.PlayBackSets == nil {
+		return missingFieldError(playbackEndpoint, "data.playBackSets")
+	}
+	for index, playbackSet := range response.Data.PlayBackSets {
+		if playbackSet.PlaybackURL == nil || *playbackSet.PlaybackURL == "" {
+			return missingFieldError(playbackEndpoint, fmt.Sprintf("data.playBackSets[%d].playbackUrl", index))
+		}
+	}
+	return nil
+}
+
+//trayResponse is the response of the tray api listing the videos of a playlist
+type trayResponse struct {
+	StatusCodeValue *int   `json:"statusCodeValue"`
+	StatusCode      string `json:"statusCode"`
+	Message         string `json:"message"`
+	Body            *struct {
+		Results *struct {
+			Assets *struct {
+				Items []ContentMetadata `json:"items"`
+			} `json:"assets"`
+		} `json:"results"`
+	} `json:"body"`
+}
+
+func (response *trayResponse) validate() error {
+	if response.StatusCodeValue == nil {
+		return missingFieldError(trayEndpoint, "statusCodeValue")
+	}
+	if *response.StatusCodeValue != 200 {
+		return &ResponseError{Endpoint: trayEndpoint, Field: "statusCodeValue", Err: fmt.Errorf("status code %d", *response.StatusCodeValue)}
+	}
+	if response.Body == nil {
+		return missingFieldError(trayEndpoint, "body")
+	}
+	if response.Body.Results == nil {
+		return missingFieldError(trayEndpoint, "body.results")
+	}
+	if response.Body.Results.Assets == nil {
+		return missingFieldError(trayEndpoint, "body.results.assets")
+	}
+	if response.Body.Results.Assets.Items == nil {
+		return missingFieldError(trayEndpoint, "body.results.assets.items")
+	}
+	for index, item := range response.Body.Results.Assets.Items {
+		if item.ContentID == nil || *item.ContentID == "" {
+			return missingFieldError(trayEndpoint, fmt.Sprintf("body.results.assets.items[%d].contentId", index))
+		}
+	}
+	return nil
+}
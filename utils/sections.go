@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Section is a time range of a video to download.
+type Section struct {
+	Start time.Duration
+	End   time.Duration //0 for the end of the video
+}
+
+//ParseSection parses a time range like *00:10:00-00:15:30, with the times as [HH:]MM:SS[.ms] (or) seconds. The end can be left out (or)
+//be inf for the end of the video.
+func ParseSection(value string) (*Section, error) {
+	invalidSectionErr := fmt.Errorf("Invalid section %s. Should be a time range like *00:10:00-00:15:30", value)
+	times := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(value), "*"), "-", 2)
+	if len(times) != 2 {
+		return nil, invalidSectionErr
+	}
+
+	start, isValid := parseSectionTime(times[0])
+	if !isValid {
+		return nil, invalidSectionErr
+	}
+	section := &Section{Start: start}
+	if end := strings.TrimSpace(times[1]); end != "" && end != "inf" {
+		if section.End, isValid = parseSectionTime(end); !isValid {
+			return nil, invalidSectionErr
+		}
+		if section.End <= section.Start {
+			return nil, fmt.Errorf("Invalid section %s. The end should be after the start", value)
+		}
+	}
+	return section, nil
+}
+
+//parseSectionTime parses a time as [HH:]MM:SS[.ms] (or) seconds
+func parseSectionTime(value string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	var seconds float64
+	for _, part := range parts {
+		partValue, err := strconv.ParseFloat(part, 64)
+		if err != nil || partValue < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + partValue
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+//Duration returns the length of the section, 0 when it runs to the end of the video
+func (section *Section) Duration() time.Duration {
+	if section.End == 0 {
+		return 0
+	}
+	return section.End - section.Start
+}
+
+//getDurationIn returns the length of the section of a video of the given duration, running to its end (if known) when the section has
+//no end. 0 when unknown.
+func (section *Section) getDurationIn(videoDuration time.Duration) time.Duration {
+	if duration := section.Duration(); duration != 0 {
+		return duration
+	}
+	if videoDuration > section.Start {
+		return videoDuration - section.Start
+	}
+	return 0
+}
+
+//getFileNameSuffix returns the suffix added to the default output file names, telling the sections apart from the whole video
+func (section *Section) getFileNameSuffix() string {
+	end := "end"
+	if section.End != 0 {
+		end = section.End.String()
+	}
+	return fmt.Sprintf("_%s-%s", section.Start, end)
+}
+
+//getSectionInputArgs returns the ffmpeg input options trimming the input to the section, seeking the given offset into the input
+func (section *Section) getSectionInputArgs(offset time.Duration) []string {
+	inputArgs := []string{"-ss", formatFfmpegSeconds(offset)}
+	if duration := section.Duration(); duration != 0 {
+		inputArgs = append(inputArgs, "-t", formatFfmpegSeconds(duration))
+	}
+	return inputArgs
+}
+
+func formatFfmpegSeconds(duration time.Duration) string {
+	return strconv.FormatFloat(duration.Seconds(), 'f', 3, 64)
+}
+
+//GetDashSectionFormat returns a copy of the DASH format limited to the segments covering the section, along with the start time of the
+//first of them. The segments are numbered from 1, each lasting the SEGMENT-DURATION of the format. The format is returned whole when its
+//segment duration is unknown.
+func GetDashSectionFormat(format map[string]string, section *Section) (map[string]string, time.Duration, error) {
+	segmentDuration, err := strconv.ParseFloat(format["SEGMENT-DURATION"], 64)
+	if err != nil || !(segmentDuration > 0) || math.IsInf(segmentDuration, 0) {
+		return format, 0, nil
+	}
+	totalSegments, _ := strconv.Atoi(format["TOTAL-SEGMENTS"])
+
+	firstSegment := int(section.Start.Seconds()/segmentDuration) + 1
+	lastSegment := totalSegments
+	if section.End != 0 {
+		if endSegment := int(math.Ceil(section.End.Seconds() / segmentDuration)); endSegment < lastSegment {
+			lastSegment = endSegment
+		}
+	}
+	if firstSegment > lastSegment {
+		return nil, 0, fmt.Errorf("The section starting at %s is beyond the end of the video", section.Start)
+	}
+
+	sectionFormat := CopyMap(format)
+	sectionFormat["FIRST-SEGMENT"] = strconv.Itoa(firstSegment)
+	sectionFormat["LAST-SEGMENT"] = strconv.Itoa(lastSegment)
+	return sectionFormat, time.Duration(float64(firstSegment-1) * segmentDuration * float64(time.Second)), nil
+}
+
+//GetSectionM3u8 returns the HLS media playlist limited to the segments covering the section, with their urls made absolute against the
+//given playlist url, along with the start time of the first of them. The keys and the init sections in effect carry over to it.
+func GetSectionM3u8(m3u8Content string, playlistURL string, section *Section) (string, time.Duration, error) {
+	baseURL, err := url.Parse(playlistURL)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var header, segmentTags []string
+	var lastKey, lastMap string
+	var sectionM3u8 strings.Builder
+	var segmentStart, firstSegmentStart, segmentDuration float64
+	mediaSequence, segmentCount, selectedCount := 0, 0, 0
+	isInHeader := true
+
+	for _, line := range strings.Split(strings.Replace(m3u8Content, "\r", "", -1), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line == "#EXT-X-ENDLIST":
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			mediaSequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+		case strings.HasPrefix(line, "#EXT-X-KEY:"), strings.HasPrefix(line, "#EXT-X-MAP:"):
+			line = resolveM3u8TagURI(line, baseURL)
+			if strings.HasPrefix(line, "#EXT-X-KEY:") {
+				lastKey = line
+			} else {
+				lastMap = line
+			}
+			segmentTags = append(segmentTags, line)
+		case strings.HasPrefix(line, "#EXTINF:"):
+			isInHeader = false
+			segmentDuration, _ = strconv.ParseFloat(strings.TrimSpace(strings.SplitN(strings.TrimPrefix(line, "#EXTINF:"), ",", 2)[0]), 64)
+			segmentTags = append(segmentTags, line)
+		case strings.HasPrefix(line, "#"):
+			if isInHeader {
+				header = append(header, line)
+			} else {
+				segmentTags = append(segmentTags, line)
+			}
+		default:
+			segmentEnd := segmentStart + segmentDuration
+			isSelected := segmentEnd > section.Start.Seconds() && (section.End == 0 || segmentStart < section.End.Seconds())
+			if isSelected {
+				if selectedCount == 0 {
+					firstSegmentStart = segmentStart
+					sectionM3u8.WriteString("#EXTM3U\n")
+					for _, headerLine := range header {
+						if headerLine != "#EXTM3U" {
+							sectionM3u8.WriteString(headerLine + "\n")
+						}
+					}
+					//the sequence number of the first segment keeps deriving the same initialization vectors of its key
+					fmt.Fprintf(&sectionM3u8, "#EXT-X-MEDIA-SEQUENCE:%d\n", mediaSequence+segmentCount)
+					for _, persistentTag := range []string{lastMap, lastKey} {
+						if persistentTag != "" && !containsString(segmentTags, persistentTag) {
+							sectionM3u8.WriteString(persistentTag + "\n")
+						}
+					}
+				}
+				for _, segmentTag := range segmentTags {
+					sectionM3u8.WriteString(segmentTag + "\n")
+				}
+				sectionM3u8.WriteString(resolveM3u8URI(line, baseURL) + "\n")
+				selectedCount++
+			}
+			segmentTags = nil
+			segmentStart, segmentDuration = segmentEnd, 0
+			segmentCount++
+		}
+	}
+
+	if selectedCount == 0 {
+		return "", 0, fmt.Errorf("The section starting at %s is beyond the end of the video", section.Start)
+	}
+	sectionM3u8.WriteString("#EXT-X-ENDLIST\n")
+	return sectionM3u8.String(), time.Duration(firstSegmentStart * float64(time.Second)), nil
+}
+
+func resolveM3u8URI(uri string, baseURL *url.URL) string {
+	reference, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return baseURL.ResolveReference(reference).String()
+}
+
+//resolveM3u8TagURI makes the URI attribute of the tag absolute
+func resolveM3u8TagURI(tag string, baseURL *url.URL) string {
+	uriStart := strings.Index(tag, `URI="`)
+	if uriStart == -1 {
+		return tag
+	}
+	uriStart += len(`URI="`)
+	uriLength := strings.Index(tag[uriStart:], `"`)
+	if uriLength == -1 {
+		return tag
+	}
+	return tag[:uriStart] + resolveM3u8URI(tag[uriStart:uriStart+uriLength], baseURL) + tag[uriStart+uriLength:]
+}
+
+//serveSectionM3u8 serves the playlist of the section to ffmpeg over http, unlike a local file keeping the headers given to ffmpeg on
+//the requests of the segments. It returns the url of the playlist and the function stopping the server.
+func serveSectionM3u8(m3u8Content string) (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("Error in serving the playlist of the section: %s", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		io.WriteString(writer, m3u8Content)
+	})}
+	go server.Serve(listener)
+	return fmt.Sprintf("http://%s/section.m3u8", listener.Addr()), func() { server.Close() }, nil
+}
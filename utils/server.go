@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+//Statuses of the jobs submitted to the server
+const (
+	ServerJobQueued    = "queued"
+	ServerJobRunning   = "running"
+	ServerJobCompleted = "completed"
+	ServerJobFailed    = "failed"
+	ServerJobSkipped   = "skipped"
+)
+
+//ServerJob is a download job submitted to the server.
+type ServerJob struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Format    string    `json:"format,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	ErrorKind ErrorKind `json:"errorKind,omitempty"`
+}
+
+//maxServerRequestSize limits the size of the bodies of the requests to the server
+const maxServerRequestSize = 64 << 10
+
+//Server accepts download jobs over HTTP and runs them one after another with the given options. The jobs share the settings of the
+//process, like the client profile, the region and the credentials, so only their url and format can be given with each job.
+type Server struct {
+	options *Options
+	mux     *http.ServeMux
+	mutex   sync.Mutex
+	jobs    []*ServerJob
+	queue   chan *ServerJob
+}
+
+//NewServer creates the server running the submitted jobs with the given options.
+func NewServer(options *Options) *Server {
+	server := &Server{
+		options: options,
+		mux:     http.NewServeMux(),
+		queue:   make(chan *ServerJob, 100),
+	}
+	server.mux.HandleFunc("/api/extract", server.handleExtract)
+	server.mux.HandleFunc("/api/jobs", server.handleJobs)
+	go server.runQueue()
+	return server
+}
+
+func (server *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	request.Body = http.MaxBytesReader(writer, request.Body, maxServerRequestSize)
+	server.mux.ServeHTTP(writer, request)
+}
+
+func writeJSON(writer http.ResponseWriter, statusCode int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	json.NewEncoder(writer).Encode(value)
+}
+
+func writeJSONError(writer http.ResponseWriter, statusCode int, err error) {
+	writeJSON(writer, statusCode, map[string]string{"error": err.Error()})
+}
+
+func (server *Server) handleExtract(writer http.ResponseWriter, request *http.Request) {
+	contentRef, err := ExtractContentRef(request.URL.Query().Get("url"))
+	if err != nil {
+		writeJSONError(writer, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(writer, http.StatusOK, contentRef)
+}
+
+func (server *Server) handleJobs(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		server.mutex.Lock()
+		jobs := make([]ServerJob, 0, len(server.jobs))
+		for _, job := range server.jobs {
+			jobs = append(jobs, *job)
+		}
+		server.mutex.Unlock()
+		writeJSON(writer, http.StatusOK, jobs)
+
+	case http.MethodPost:
+		var job ServerJob
+		if err := json.NewDecoder(request.Body).Decode(&job); err != nil {
+			writeJSONError(writer, http.StatusBadRequest, fmt.Errorf("Invalid job: %s", err))
+			return
+		}
+		if _, err := ExtractContentRef(job.URL); err != nil {
+			writeJSONError(writer, http.StatusBadRequest, err)
+			return
+		}
+		if job.Format != "" && !HasValidFormatPrefix(job.Format) {
+			writeJSONError(writer, http.StatusBadRequest, fmt.Errorf("Invalid format %s specified", job.Format))
+			return
+		}
+
+		server.mutex.Lock()
+		job.ID = len(server.jobs) + 1
+		job.Status = ServerJobQueued
+		job.Error = ""
+		job.ErrorKind = ""
+		server.jobs = append(server.jobs, &job)
+		acceptedJob := job
+		server.mutex.Unlock()
+
+		select {
+		case server.queue <- &job:
+			writeJSON(writer, http.StatusAccepted, acceptedJob)
+		default:
+			server.setJobStatus(&job, ServerJobFailed, errors.New("job queue is full"))
+			writeJSONError(writer, http.StatusServiceUnavailable, fmt.Errorf("job queue is full"))
+		}
+
+	default:
+		writeJSONError(writer, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed", request.Method))
+	}
+}
+
+func (server *Server) setJobStatus(job *ServerJob, status string, err error) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	job.Status = status
+	job.Error = ""
+	job.ErrorKind = ""
+	if err != nil {
+		job.Error = err.Error()
+		job.ErrorKind = GetErrorKind(err)
+	}
+}
+
+func (server *Server) runQueue() {
+	for job := range server.queue {
+		server.setJobStatus(job, ServerJobRunning, nil)
+
+		jobOptions := *server.options
+		if job.Format != "" {
+			jobOptions.Format = job.Format
+		}
+
+		summary := RunJobs([]string{job.URL}, &jobOptions)
+		if failedJobs := summary.Failed(); len(failedJobs) != 0 {
+			server.setJobStatus(job, ServerJobFailed, failedJobs[0].Err)
+		} else if skippedJobs := summary.Skipped(); len(skippedJobs) == len(summary.Jobs) {
+			server.setJobStatus(job, ServerJobSkipped, skippedJobs[0].Err)
+		} else {
+			server.setJobStatus(job, ServerJobCompleted, nil)
+		}
+	}
+}
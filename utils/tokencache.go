@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+//lockStaleAge is the age after which a lock left behind by a crashed run is removed
+const lockStaleAge = 30 * time.Second
+
+//lockTouchInterval is the interval the held lock is touched in, so that it doesn't turn stale however long the credentials take to refresh
+const lockTouchInterval = lockStaleAge / 3
+
+//lockTimeout is the time to wait for the lock held by another run, long enough for the lock left behind by a crashed run to turn stale
+const lockTimeout = lockStaleAge + 15*time.Second
+
+//TokenCache persists the credentials on disk so that they are reused across runs until they are about to expire.
+type TokenCache struct {
+	Path string
+}
+
+//GuestCacheKey is the cache key of the guest credentials
+const GuestCacheKey = "guest"
+
+//cachedCredentials is the on-disk form of the credentials
+type cachedCredentials struct {
+	UserToken string    `json:"userToken"`
+	DeviceID  string    `json:"deviceId"`
+	Expiry    time.Time `json:"expiry"`
+	IsGuest   bool      `json:"isGuest"`
+}
+
+//tokenCache is the cache used by getCredentials, nil when caching is disabled
+var tokenCache *TokenCache
+
+//GetCacheKey returns the key the credentials obtained from the given user token are cached under.
+//The token given by the user is hashed so that its refreshed tokens are found again on the next run without storing it.
+func GetCacheKey(userToken string) string {
+	if userToken == "" {
+		return GuestCacheKey
+	}
+	hash := sha256.Sum256([]byte(userToken))
+	return "user-" + hex.EncodeToString(hash[:8])
+}
+
+//GetCacheDir returns the directory holding the cached files, following the XDG base directory spec.
+func GetCacheDir() (string, error) {
+	if xdgCacheHome := os.Getenv("XDG_CACHE_HOME"); xdgCacheHome != "" {
+		return filepath.Join(xdgCacheHome, "hotstar-dl"), nil
+	}
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, "hotstar-dl"), nil
+}
+
+//NewTokenCache creates the token cache in the given directory.
+func NewTokenCache(cacheDir string) *TokenCache {
+	return &TokenCache{Path: filepath.Join(cacheDir, "credentials.json")}
+}
+
+//SetTokenCache sets the cache used for the credentials. nil disables caching.
+func SetTokenCache(cache *TokenCache) {
+	tokenCache = cache
+}
+
+func (cache *TokenCache) lockPath() string {
+	return cache.Path + ".lock"
+}
+
+//lock acquires the cache lock file shared by the concurrent runs and returns the function releasing it. The lock file holds the PID
+//of the run along with the time it was locked, so that the run removes only its own lock.
+func (cache *TokenCache) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(cache.Path), 0700); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		owner := fmt.Sprintf("%d %d", os.Getpid(), time.Now().UnixNano())
+		lockFile, err := os.OpenFile(cache.lockPath(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = lockFile.WriteString(owner)
+			if closeErr := lockFile.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(cache.lockPath())
+				return nil, err
+			}
+			stopTouching := cache.touchLock(owner)
+			return func() {
+				stopTouching()
+				cache.unlock(owner)
+			}, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		//remove the lock left behind by a crashed run
+		if info, statErr := os.Stat(cache.lockPath()); statErr == nil && time.Since(info.ModTime()) > lockStaleAge {
+			cache.removeStaleLock(owner)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock %s held by another run", cache.lockPath())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+//touchLock updates the modification time of the lock file held by the given owner in the background until the returned function is
+//called, telling the other runs that the lock isn't left behind by a crashed run
+func (cache *TokenCache) touchLock(owner string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockTouchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if content, err := ioutil.ReadFile(cache.lockPath()); err == nil && string(content) == owner {
+					now := time.Now()
+					os.Chtimes(cache.lockPath(), now, now)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+//unlock removes the lock file when it is still held by the given owner, leaving it when it was taken over as stale by another run
+func (cache *TokenCache) unlock(owner string) {
+	if content, err := ioutil.ReadFile(cache.lockPath()); err == nil && string(content) == owner {
+		os.Remove(cache.lockPath())
+	}
+}
+
+//removeStaleLock moves the stale lock file away atomically, so that only one of the runs waiting for it removes it. When another run
+//replaced it with its fresh lock meanwhile, the fresh lock is put back.
+func (cache *TokenCache) removeStaleLock(owner string) {
+	ownerHash := sha256.Sum256([]byte(owner))
+	stalePath := fmt.Sprintf("%s.stale-%x", cache.lockPath(), ownerHash[:8])
+	if err := os.Rename(cache.lockPath(), stalePath); err != nil {
+		return
+	}
+	defer os.Remove(stalePath)
+	if info, err := os.Stat(stalePath); err == nil && time.Since(info.ModTime()) <= lockStaleAge {
+		os.Link(stalePath, cache.lockPath())
+	}
+}
+
+func (cache *TokenCache) readAll() (map[string]cachedCredentials, error) {
+	entries := make(map[string]cachedCredentials)
+
+	contentBytes, err := ioutil.ReadFile(cache.Path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(contentBytes, &entries); err != nil {
+		//a corrupt cache is as good as no cache
+		return make(map[string]cachedCredentials), nil
+	}
+
+	return entries, nil
+}
+
+//Load loads the credentials cached under the given key, nil when there are none.
+func (cache *TokenCache) Load(key string) (*Credentials, error) {
+	entries, err := cache.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	cached, isPresent := entries[key]
+	if !isPresent || cached.UserToken == "" {
+		return nil, nil
+	}
+
+	return &Credentials{UserToken: cached.UserToken, DeviceID: cached.DeviceID, Expiry: cached.Expiry, IsGuest: cached.IsGuest}, nil
+}
+
+//Save caches the given credentials under the given key, replacing the cache file atomically.
+func (cache *TokenCache) Save(key string, credentials *Credentials) error {
+	entries, err := cache.readAll()
+	if err != nil {
+		return err
+	}
+
+	entries[key] = cachedCredentials{
+		UserToken: credentials.UserToken,
+		DeviceID:  credentials.DeviceID,
+		Expiry:    credentials.Expiry,
+		IsGuest:   credentials.IsGuest,
+	}
+
+	contentBytes, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cache.Path), 0700); err != nil {
+		return err
+	}
+
+	tempPath := cache.Path + ".tmp" + strconv.Itoa(os.Getpid())
+	if err := ioutil.WriteFile(tempPath, contentBytes, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, cache.Path)
+}
+
+//Clear removes the cached credentials.
+func (cache *TokenCache) Clear() error {
+	if err := os.Remove(cache.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//GetOrRefresh returns the credentials cached under the given key while they are not about to expire, otherwise the ones obtained
+//with the given function from the cached ones (nil when none) are cached and returned.
+//The cache is locked meanwhile so that concurrent runs share a single refresh.
+func (cache *TokenCache) GetOrRefresh(key string, refresh func(cached *Credentials) (*Credentials, error)) (*Credentials, error) {
+	unlock, err := cache.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	cached, err := cache.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && !cached.IsExpiring(tokenRefreshMargin) {
+		return cached, nil
+	}
+
+	credentials, err := refresh(cached)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Save(key, credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
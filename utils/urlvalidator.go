@@ -2,53 +2,51 @@ package utils
 
 import (
 	"fmt"
-	"log"
 	"net/url"
-	"os"
-	"regexp"
 )
 
 //IsValidHotstarURL validates if the given video url is a valid Hotstar url or not.
 func IsValidHotstarURL(videoOrPlaylistURL string) (bool, string, bool) {
-	var videoURLRegex = regexp.MustCompile(`(https?://)?(www|uk\.)?hotstar\.com/(?:.+?[/-])+(?P<videoId>\d{10})`)
-	var playlistURLRegex = regexp.MustCompile(`(https?://)?(www|uk\.)?hotstar\.com/tv/[^/]+/s-\w+/list/[^/]+/t-(?P<playlistId>\w+)`)
-
-	if videoURLRegex.MatchString(videoOrPlaylistURL) {
-		match := ReSubMatchMap(videoURLRegex, videoOrPlaylistURL)
-		return true, match["videoId"], false
-	} else if playlistURLRegex.MatchString(videoOrPlaylistURL) {
-		match := ReSubMatchMap(playlistURLRegex, videoOrPlaylistURL)
-		return true, match["playlistId"], true
+	contentRef, err := ExtractContentRef(videoOrPlaylistURL)
+	if err != nil {
+		return false, "", false
+	}
+
+	if contentRef.IsPlaylist() {
+		return true, contentRef.PlaylistID, true
 	}
 
-	return false, "", false
+	return true, contentRef.ContentID, false
 }
 
 //GetParsedVideoURL parses given video url for proper url scheme.
-func GetParsedVideoURL(videoURL string) string {
+func GetParsedVideoURL(videoURL string) (string, error) {
 	parsedURL, err := url.Parse(videoURL)
 
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	switch parsedURL.Scheme {
 	case "":
-		//fmt.Println("Replacing empty url scheme with https")
-		parsedURL.Scheme = "https"
+		logDebug("Replacing empty url scheme with https", "url", videoURL)
+		//reparse so that the host isn't treated as a part of the path
+		parsedURL, err = url.Parse("https://" + videoURL)
+		if err != nil {
+			return "", err
+		}
 	case "https":
 		//do nothing
 	case "http":
-		//fmt.Println("Replacing http url scheme with https")
+		logDebug("Replacing http url scheme with https", "url", videoURL)
 		parsedURL.Scheme = "https"
 	default:
-		fmt.Println("Invalid url scheme please enter valid one")
-		os.Exit(-1)
+		return "", fmt.Errorf("Invalid url scheme %s please enter valid one", parsedURL.Scheme)
 	}
 
 	videoURL = fmt.Sprintf("%v", parsedURL)
 
-	fmt.Println("Parsed video url is", parsedURL)
+	logInfo("Parsed video url is %s\n", parsedURL)
 
-	return videoURL
+	return videoURL, nil
 }
@@ -0,0 +1,458 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ErrVerificationFailed is wrapped by the errors of the downloads whose file doesn't match the format downloaded.
+var ErrVerificationFailed = errors.New("Verification of the download failed")
+
+//MediaStream is an audio (or) a video stream of a media file.
+type MediaStream struct {
+	Type   string //video (or) audio
+	Codec  string //codec name as of ffprobe, like h264 (or) aac
+	Width  int    //0 for the audio streams (or) when unknown
+	Height int
+}
+
+//MediaInfo is the duration and the streams of a media file.
+type MediaInfo struct {
+	Duration time.Duration //0 when unknown
+	Streams  []MediaStream //nil when unknown
+}
+
+//codecNames maps the codecs of the manifests (RFC 6381) and the MP4 sample entries to the codec names of ffprobe
+var codecNames = map[string]string{
+	"avc1": "h264", "avc3": "h264",
+	"hvc1": "hevc", "hev1": "hevc", "dvh1": "hevc", "dvhe": "hevc",
+	"vp09": "vp9", "av01": "av1",
+	"mp4a": "aac", "ec-3": "eac3", "ac-3": "ac3", "opus": "opus", "Opus": "opus",
+}
+
+//codecTypes tells the video codecs apart from the audio ones
+var codecTypes = map[string]string{
+	"h264": "video", "hevc": "video", "vp9": "video", "av1": "video",
+	"aac": "audio", "eac3": "audio", "ac3": "audio", "opus": "audio",
+}
+
+//getCodecName returns the ffprobe codec name of the codec like avc1.640028, the codec itself when unknown
+func getCodecName(codec string) string {
+	fourcc := strings.SplitN(strings.TrimSpace(codec), ".", 2)[0]
+	if codecName, isKnown := codecNames[fourcc]; isKnown {
+		return codecName
+	}
+	return fourcc
+}
+
+//GetExpectedMediaInfo returns the streams expected in the file downloaded from the given formats, from their codecs and resolution,
+//along with the given duration. The streams are left unknown when a codec isn't recognized.
+func GetExpectedMediaInfo(formats []map[string]string, duration time.Duration) *MediaInfo {
+	expected := &MediaInfo{Duration: duration}
+	streams := make([]MediaStream, 0, 2)
+	for _, format := range formats {
+		//the DASH codecs follow the container, while the HLS ones are a quoted list
+		codecs := strings.Trim(format["CODECS"], `"`)
+		if containerEnd := strings.Index(codecs, " container, "); containerEnd != -1 {
+			codecs = codecs[containerEnd+len(" container, "):]
+		}
+		if codecs == "" {
+			return expected
+		}
+
+		for _, codec := range strings.Split(codecs, ",") {
+			stream := MediaStream{Codec: getCodecName(codec), Type: codecTypes[getCodecName(codec)]}
+			if stream.Type == "" {
+				logVerbose("Not verifying the streams of the unknown codec", "codec", codec)
+				return expected
+			}
+			if stream.Type == "video" {
+				fmt.Sscanf(format["RESOLUTION"], "%dx%d", &stream.Width, &stream.Height)
+			}
+			streams = append(streams, stream)
+		}
+	}
+	expected.Streams = streams
+	return expected
+}
+
+//getStreamsOfType returns the streams of the given type
+func getStreamsOfType(streams []MediaStream, streamType string) []MediaStream {
+	streamsOfType := make([]MediaStream, 0, len(streams))
+	for _, stream := range streams {
+		if stream.Type == streamType {
+			streamsOfType = append(streamsOfType, stream)
+		}
+	}
+	return streamsOfType
+}
+
+//VerifyMediaInfo compares the media file probed against the expected one, the durations differing by up to the given tolerance. The
+//unknown duration, streams, codecs and resolutions aren't compared.
+func VerifyMediaInfo(expected *MediaInfo, actual *MediaInfo, tolerance time.Duration) error {
+	mismatches := make([]string, 0)
+
+	if expected.Streams != nil {
+		for _, streamType := range []string{"video", "audio"} {
+			expectedStreams, actualStreams := getStreamsOfType(expected.Streams, streamType), getStreamsOfType(actual.Streams, streamType)
+			if len(expectedStreams) != len(actualStreams) {
+				mismatches = append(mismatches, fmt.Sprintf("%d %s streams instead of %d", len(actualStreams), streamType, len(expectedStreams)))
+				continue
+			}
+			for index, expectedStream := range expectedStreams {
+				actualStream := actualStreams[index]
+				if expectedStream.Codec != "" && actualStream.Codec != "" && expectedStream.Codec != actualStream.Codec {
+					mismatches = append(mismatches, fmt.Sprintf("%s codec %s instead of %s", streamType, actualStream.Codec, expectedStream.Codec))
+				}
+				if expectedStream.Width != 0 && actualStream.Width != 0 && (expectedStream.Width != actualStream.Width || expectedStream.Height != actualStream.Height) {
+					mismatches = append(mismatches, fmt.Sprintf("resolution %dx%d instead of %dx%d", actualStream.Width, actualStream.Height, expectedStream.Width, expectedStream.Height))
+				}
+			}
+		}
+	}
+
+	if expected.Duration != 0 {
+		drift := actual.Duration - expected.Duration
+		if drift < 0 {
+			drift = -drift
+		}
+		if drift > tolerance {
+			mismatches = append(mismatches, fmt.Sprintf("duration %s instead of %s", actual.Duration.Round(time.Millisecond), expected.Duration.Round(time.Millisecond)))
+		}
+	}
+
+	if len(mismatches) != 0 {
+		return fmt.Errorf("%w: %s", ErrVerificationFailed, strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+//getDurationTolerance returns how far the duration of a download may drift from the one expected, a couple of seconds (or) 1% of long
+//videos along with a segment whose duration rounds the expected one
+func getDurationTolerance(duration time.Duration, segmentDuration time.Duration) time.Duration {
+	tolerance := 2 * time.Second
+	if percent := duration / 100; percent > tolerance {
+		tolerance = percent
+	}
+	return tolerance + segmentDuration
+}
+
+//ffprobeOutput is the part of the JSON output of ffprobe with -show_format -show_streams used
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+//ParseFfprobeOutput parses the JSON output of ffprobe -show_format -show_streams, keeping the audio and the video streams.
+func ParseFfprobeOutput(data []byte) (*MediaInfo, error) {
+	var output ffprobeOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("Error in parsing the output of ffprobe: %s", err)
+	}
+
+	mediaInfo := &MediaInfo{Streams: make([]MediaStream, 0, len(output.Streams))}
+	if seconds, err := strconv.ParseFloat(output.Format.Duration, 64); err == nil {
+		mediaInfo.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	for _, stream := range output.Streams {
+		if stream.CodecType == "video" || stream.CodecType == "audio" {
+			mediaInfo.Streams = append(mediaInfo.Streams, MediaStream{Type: stream.CodecType, Codec: stream.CodecName, Width: stream.Width, Height: stream.Height})
+		}
+	}
+	return mediaInfo, nil
+}
+
+//getFfprobePath returns the path of ffprobe, next to the given ffmpeg (or) in the PATH, empty when not found
+func getFfprobePath(ffmpegPath string) string {
+	if ffmpegPath != "" {
+		ffprobePath := filepath.Join(filepath.Dir(ffmpegPath), strings.Replace(filepath.Base(ffmpegPath), "ffmpeg", "ffprobe", 1))
+		if ffprobePath != ffmpegPath && isPathExists(ffprobePath) {
+			return ffprobePath
+		}
+	}
+	ffprobePath, _ := exec.LookPath("ffprobe")
+	return ffprobePath
+}
+
+//probeWithFfprobe probes the media file with ffprobe
+func probeWithFfprobe(ffprobePath string, filePath string) (*MediaInfo, error) {
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(ffprobePath, "-v", "error", "-print_format", "json", "-show_format", "-show_streams", filePath)
+	command.Stdout, command.Stderr = &stdout, &stderr
+	logVerbose("Running ffprobe", "args", strings.Join(command.Args, " "))
+	if err := command.Run(); err != nil {
+		return nil, fmt.Errorf("Error in probing %s with ffprobe: %s %s", filePath, err, strings.TrimSpace(stderr.String()))
+	}
+	return ParseFfprobeOutput(stdout.Bytes())
+}
+
+//readMP4BoxHeader reads the header of the next top level box of the file, returning its type and the size of its payload
+func readMP4BoxHeader(file *os.File) (string, int64, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(file, header[:8]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", 0, errors.New("Truncated box header")
+		}
+		return "", 0, err
+	}
+	size := int64(binary.BigEndian.Uint32(header[0:4]))
+	boxType := string(header[4:8])
+	headerSize := int64(8)
+	switch size {
+	case 0:
+		//the box extends to the end of the file
+		position, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", 0, err
+		}
+		fileInfo, err := file.Stat()
+		if err != nil {
+			return "", 0, err
+		}
+		return boxType, fileInfo.Size() - position, nil
+	case 1:
+		if _, err := io.ReadFull(file, header[8:16]); err != nil {
+			return "", 0, fmt.Errorf("Truncated large size of box %s", boxType)
+		}
+		size = int64(binary.BigEndian.Uint64(header[8:16]))
+		headerSize = 16
+	}
+	if size < headerSize {
+		return "", 0, fmt.Errorf("Invalid size %d of box %s", size, boxType)
+	}
+	return boxType, size - headerSize, nil
+}
+
+//mp4TrackInfo is a track of the MP4 file probed
+type mp4TrackInfo struct {
+	stream                MediaStream
+	timescale             uint32
+	duration              uint64 //in the timescale, summed from the fragments when the movie is fragmented
+	defaultSampleDuration uint32 //of the samples of the fragments, from trex
+}
+
+//getMP4TrackInfo reads the stream, the timescale and the duration of the track
+func getMP4TrackInfo(trak *mp4Box) (*mp4TrackInfo, error) {
+	track := &mp4TrackInfo{}
+	var err error
+	if track.timescale, err = getTimescale(trak); err != nil {
+		return nil, err
+	}
+	mdhd := trak.find("mdia", "mdhd")
+	if len(mdhd.payload) >= 32 && mdhd.payload[0] == 1 {
+		track.duration = binary.BigEndian.Uint64(mdhd.payload[24:32])
+	} else if len(mdhd.payload) >= 20 {
+		track.duration = uint64(binary.BigEndian.Uint32(mdhd.payload[16:20]))
+	}
+
+	if hdlr := trak.find("mdia", "hdlr"); hdlr != nil && len(hdlr.payload) >= 12 {
+		switch string(hdlr.payload[8:12]) {
+		case "vide":
+			track.stream.Type = "video"
+		case "soun":
+			track.stream.Type = "audio"
+		}
+	}
+	//the first sample entry names the codec, the visual ones holding the width and the height
+	if stsd := trak.find("mdia", "minf", "stbl", "stsd"); stsd != nil && len(stsd.payload) >= 16 {
+		track.stream.Codec = getCodecName(string(stsd.payload[12:16]))
+		if track.stream.Type == "video" && len(stsd.payload) >= 44 {
+			track.stream.Width = int(binary.BigEndian.Uint16(stsd.payload[40:42]))
+			track.stream.Height = int(binary.BigEndian.Uint16(stsd.payload[42:44]))
+		}
+	}
+	return track, nil
+}
+
+//getTrafDuration returns the track id and the duration of the samples of the track fragment
+func getTrafDuration(traf *mp4Box, tracks map[uint32]*mp4TrackInfo) (uint32, uint64) {
+	tfhd := traf.find("tfhd")
+	if tfhd == nil || len(tfhd.payload) < 8 {
+		return 0, 0
+	}
+	trackID := binary.BigEndian.Uint32(tfhd.payload[4:8])
+	var defaultSampleDuration uint32
+	if track, isKnown := tracks[trackID]; isKnown {
+		defaultSampleDuration = track.defaultSampleDuration
+	}
+	tfhdFlags := binary.BigEndian.Uint32(tfhd.payload[0:4]) & 0xFFFFFF
+	if tfhdFlags&0x08 != 0 {
+		offset := 8
+		if tfhdFlags&tfhdBaseDataOffsetPresent != 0 {
+			offset += 8
+		}
+		if tfhdFlags&0x02 != 0 {
+			offset += 4
+		}
+		if len(tfhd.payload) >= offset+4 {
+			defaultSampleDuration = binary.BigEndian.Uint32(tfhd.payload[offset:])
+		}
+	}
+
+	var duration uint64
+	for _, trun := range traf.findAll("trun") {
+		if len(trun.payload) < 8 {
+			continue
+		}
+		trunFlags := binary.BigEndian.Uint32(trun.payload[0:4]) & 0xFFFFFF
+		sampleCount := int(binary.BigEndian.Uint32(trun.payload[4:8]))
+		if trunFlags&0x100 == 0 {
+			duration += uint64(sampleCount) * uint64(defaultSampleDuration)
+			continue
+		}
+		offset := 8
+		if trunFlags&0x01 != 0 {
+			offset += 4
+		}
+		if trunFlags&0x04 != 0 {
+			offset += 4
+		}
+		//each sample holds its duration followed by its optional size, flags and composition time offset
+		sampleSize := 4
+		for _, flag := range []uint32{0x200, 0x400, 0x800} {
+			if trunFlags&flag != 0 {
+				sampleSize += 4
+			}
+		}
+		for sample := 0; sample < sampleCount && offset+4 <= len(trun.payload); sample++ {
+			duration += uint64(binary.BigEndian.Uint32(trun.payload[offset:]))
+			offset += sampleSize
+		}
+	}
+	return trackID, duration
+}
+
+//ProbeMP4 probes the MP4 file natively, reading only its moov and moof boxes. The durations of the tracks of a fragmented movie are
+//summed from its fragments.
+func ProbeMP4(filePath string) (*MediaInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tracks := make(map[uint32]*mp4TrackInfo)
+	trackIDs := make([]uint32, 0, 2)
+	fragmentDurations := make(map[uint32]uint64)
+	for {
+		boxType, payloadSize, err := readMP4BoxHeader(file)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error in probing %s: %s", filePath, err)
+		}
+		if boxType != "moov" && boxType != "moof" {
+			if _, err := file.Seek(payloadSize, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		payload := make([]byte, payloadSize)
+		if _, err := io.ReadFull(file, payload); err != nil {
+			return nil, fmt.Errorf("Error in probing %s: truncated box %s", filePath, boxType)
+		}
+		box, err := newMP4Box(boxType, payload)
+		if err != nil {
+			return nil, fmt.Errorf("Error in probing %s: %s", filePath, err)
+		}
+
+		if boxType == "moof" {
+			for _, traf := range box.findAll("traf") {
+				trackID, duration := getTrafDuration(traf, tracks)
+				fragmentDurations[trackID] += duration
+			}
+			continue
+		}
+		for _, trak := range box.findAll("trak") {
+			trackID, err := getTrackID(trak)
+			if err != nil {
+				return nil, err
+			}
+			track, err := getMP4TrackInfo(trak)
+			if err != nil {
+				return nil, err
+			}
+			tracks[trackID] = track
+			trackIDs = append(trackIDs, trackID)
+		}
+		if mvex := box.find("mvex"); mvex != nil {
+			for _, trex := range mvex.findAll("trex") {
+				if len(trex.payload) < 16 {
+					continue
+				}
+				if track, isKnown := tracks[binary.BigEndian.Uint32(trex.payload[4:8])]; isKnown {
+					track.defaultSampleDuration = binary.BigEndian.Uint32(trex.payload[12:16])
+				}
+			}
+		}
+	}
+	if len(trackIDs) == 0 {
+		return nil, fmt.Errorf("Error in probing %s: no tracks found", filePath)
+	}
+
+	mediaInfo := &MediaInfo{Streams: make([]MediaStream, 0, len(trackIDs))}
+	for _, trackID := range trackIDs {
+		track := tracks[trackID]
+		if track.stream.Type == "" {
+			continue
+		}
+		mediaInfo.Streams = append(mediaInfo.Streams, track.stream)
+		duration := track.duration
+		if duration == 0 {
+			duration = fragmentDurations[trackID]
+		}
+		if track.timescale != 0 {
+			if trackDuration := time.Duration(float64(duration) / float64(track.timescale) * float64(time.Second)); trackDuration > mediaInfo.Duration {
+				mediaInfo.Duration = trackDuration
+			}
+		}
+	}
+	return mediaInfo, nil
+}
+
+//verifyDownload probes the downloaded file with ffprobe, (or) natively for the MP4 files when ffprobe isn't found, and compares it
+//against the formats downloaded and the duration expected (if known)
+func verifyDownload(ffmpegPath string, filePath string, formats []map[string]string, duration time.Duration, segmentDuration time.Duration) error {
+	logInfo("\nVerifying the downloaded file\n")
+
+	var mediaInfo *MediaInfo
+	var err error
+	if ffprobePath := getFfprobePath(ffmpegPath); ffprobePath != "" {
+		mediaInfo, err = probeWithFfprobe(ffprobePath, filePath)
+	} else if outputFormat := getFfmpegOutputFormat(strings.TrimSuffix(filePath, ".part")); outputFormat == "mp4" || outputFormat == "mov" {
+		mediaInfo, err = ProbeMP4(filePath)
+	} else {
+		logInfo("Skipping the verification, ffprobe is needed to probe the %s files\n", outputFormat)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrVerificationFailed, err)
+	}
+
+	expected := GetExpectedMediaInfo(formats, duration)
+	logVerbose("Verifying the download", "expected", fmt.Sprintf("%+v", *expected), "probed", fmt.Sprintf("%+v", *mediaInfo))
+	if err := VerifyMediaInfo(expected, mediaInfo, getDurationTolerance(duration, segmentDuration)); err != nil {
+		return err
+	}
+	logInfo("Verified the duration and the streams of the downloaded file\n")
+	return nil
+}
@@ -2,30 +2,32 @@ package utils
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
+	"time"
 
 	"github.com/pkg/errors"
-
-	"github.com/google/uuid"
 )
 
 //GetVideoFormats gets all available video formats for given video url.
 func GetVideoFormats(videoURL string, videoID string, meta map[string]string) (map[string]map[string]string, map[string]string, error) {
-	//TODO: show retry info upon debug level
-
 	var videoMetadata = meta
 	var playbackURI = videoURL
 	var requestHeaders = getRequestHeaders()
 
+	credentials, err := getCredentials(videoURL)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "\nGetVideoFormats: Error in retrieving user token\n")
+	}
+
 	if meta == nil && !strings.Contains(videoURL, "api.hotstar.com") {
 		videoURLContent, videoURLDownloadError := getVideoURL(videoURL, requestHeaders)
 		if videoURLDownloadError != nil {
@@ -33,7 +35,7 @@ func GetVideoFormats(videoURL string, videoID string, meta map[string]string) (m
 		}
 
 		var playbackURIError error
-		playbackURI, videoMetadata, playbackURIError = getPlayback(videoURLContent, videoURL, videoID, uuid.New().String())
+		playbackURI, videoMetadata, playbackURIError = getPlayback(videoURLContent, videoURL, videoID, credentials.DeviceID)
 		if playbackURIError != nil {
 			return nil, nil, errors.Wrapf(playbackURIError, "\nGetVideoFormats: Error occurred in retrieving playbackURI\n")
 		}
@@ -41,33 +43,21 @@ func GetVideoFormats(videoURL string, videoID string, meta map[string]string) (m
 
 	if drmProtected, isDrmKeyAvailable := videoMetadata["drmProtected"]; isDrmKeyAvailable {
 		if drmProtected == "true" {
-			return nil, nil, errors.New("The content is DRM Protected")
+			return nil, nil, ErrDRMProtected
 		}
 	}
 
-	resultToken, err := getRefreshToken(videoURL)
-	if err != nil {
-		fmt.Printf("Error in retrieving JWT token : %s", err)
-		os.Exit(-1)
-	}
-
-	requestHeaders["X-HS-UserToken"] = resultToken
-
-	playbackURIContentBytes, playbackURIContentError := getPlaybackURIContent(playbackURI, requestHeaders)
-	if playbackURIContentError != nil {
-		return nil, nil, errors.Wrapf(playbackURIContentError, "\nGetVideoFormats: Error occurred in retrieving playbackURIContent\n")
-	}
+	requestHeaders["X-HS-UserToken"] = credentials.UserToken
 
-	masterPlaybackURLs, masterPlaybackURLError := getMasterPlaybackURL(playbackURIContentBytes)
-	if masterPlaybackURLError != nil {
-		return nil, nil, errors.Wrapf(masterPlaybackURLError, "\nGetVideoFormats: Error occurred in retrieving masterPlaybackURLs\n")
+	playbackSets, playbackSetsError := requestPlaybackSets(playbackURI, requestHeaders, credentials)
+	if playbackSetsError != nil {
+		return nil, nil, playbackSetsError
 	}
 
 	requestHeaders["Referer"] = videoURL
 	requestHeaders["Origin"] = "https://www.hotstar.com"
-	requestHeaders["Host"] = "hses4.hotstar.com"
 
-	videoFormatsTemp, audioDashFormatsTemp, videoDashFormatsTemp, videoFormatsError := getTempVideoFormats(masterPlaybackURLs, requestHeaders)
+	videoFormatsTemp, audioDashFormatsTemp, videoDashFormatsTemp, videoFormatsError := getTempVideoFormats(playbackSets, requestHeaders)
 
 	if videoFormatsError != nil {
 		return nil, nil, errors.Wrapf(videoFormatsError, "\nGetVideoFormats: Error occurred in retrieving videoFormats\n")
@@ -76,28 +66,83 @@ func GetVideoFormats(videoURL string, videoID string, meta map[string]string) (m
 	return getAggregatedFormats(videoFormatsTemp, audioDashFormatsTemp, videoDashFormatsTemp), videoMetadata, nil
 }
 
+//requestPlaybackSets requests the playback once for every desired config and merges the playBackSets
+func requestPlaybackSets(playbackURI string, requestHeaders map[string]string, credentials *Credentials) ([]PlaybackSet, error) {
+	desiredConfigs, err := getDesiredConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	playbackSets := make([]PlaybackSet, 0)
+	playbackURLs := make([]string, 0)
+	var firstError error
+	for _, desiredConfig := range desiredConfigs {
+		configPlaybackURI := playbackURI
+		if len(desiredConfigs) > 1 {
+			configPlaybackURI = setDesiredConfig(playbackURI, desiredConfig)
+		}
+
+		logVerbose("Requesting the playback", "desiredConfig", desiredConfig)
+		playbackURIContentBytes, playbackURIContentError := getPlaybackURIContent(configPlaybackURI, requestHeaders)
+		if playbackURIContentError != nil {
+			if classifiedErr := classifyHTTPError(playbackEndpoint, playbackURIContentError, playbackURIContentBytes, credentials.IsGuest); GetErrorKind(classifiedErr) != "" {
+				err = classifiedErr
+			} else {
+				err = errors.Wrapf(playbackURIContentError, "\nGetVideoFormats: Error occurred in retrieving playbackURIContent\n")
+			}
+		} else {
+			var configPlaybackSets []PlaybackSet
+			configPlaybackSets, err = GetPlaybackSets(playbackURIContentBytes)
+			if err == nil {
+				for _, playbackSet := range configPlaybackSets {
+					if !containsString(playbackURLs, playbackSet.PlaybackURL) {
+						playbackURLs = append(playbackURLs, playbackSet.PlaybackURL)
+						playbackSets = append(playbackSets, playbackSet)
+					}
+				}
+				continue
+			}
+			if GetErrorKind(err) == "" {
+				err = errors.Wrapf(err, "\nGetVideoFormats: Error occurred in retrieving masterPlaybackURLs\n")
+			}
+		}
+
+		//the other desired configs are refused alike, except the ones of a higher plan, keeping the playback sets merged already
+		if kind := GetErrorKind(err); kind != "" && kind != ErrorKindSubscriptionRequired && len(playbackSets) == 0 {
+			return nil, err
+		}
+
+		if len(desiredConfigs) > 1 {
+			logInfo("Skipping desired config %s: %s\n", desiredConfig, strings.TrimSpace(err.Error()))
+		}
+		if firstError == nil {
+			firstError = err
+		}
+	}
+
+	if len(playbackSets) == 0 && firstError != nil {
+		return nil, firstError
+	}
+
+	return playbackSets, nil
+}
+
 //ListVideoFormats lists video formats (or) title (or) description of the video for given video url.
-func ListVideoFormats(videoURL string, videoID string, metadata map[string]string, titleFlag bool, descriptionFlag bool) {
+func ListVideoFormats(videoURL string, videoID string, metadata map[string]string, titleFlag bool, descriptionFlag bool) error {
 	videoFormats, videoMetadata, err := GetVideoFormats(videoURL, videoID, metadata)
 
 	if err != nil {
-		//log.Fatal(fmt.Errorf("Error occurred : %s", err))
-		fmt.Printf("Error: %s", err)
-		os.Exit(-1)
+		return err
 	}
 
 	if titleFlag || descriptionFlag {
 		if titleFlag {
-			fmt.Println(videoMetadata["title"])
+			fmt.Fprintln(GetMessageOutput(), videoMetadata["title"])
 		}
 		if descriptionFlag {
-			fmt.Println(videoMetadata["synopsis"])
-		}
-		if metadata == nil {
-			os.Exit(0)
-		} else {
-			return
+			fmt.Fprintln(GetMessageOutput(), videoMetadata["synopsis"])
 		}
+		return nil
 	}
 
 	i := 0
@@ -110,8 +155,8 @@ func ListVideoFormats(videoURL string, videoID string, metadata map[string]strin
 	sort.Strings(videoFormatsSortedKeys)
 
 	//NewWriter(io.Writer, minWidth, tabWidth, padding, padchar, flags)
-	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0) //tabwriter.Debug
-	fmt.Fprintln(tw, "format code\textension\tresolution\tbandwidth\tcodec & frame rate\t")
+	tw := tabwriter.NewWriter(GetMessageOutput(), 0, 0, 3, ' ', 0) //tabwriter.Debug
+	fmt.Fprintln(tw, "format code\textension\tresolution\tbandwidth\tcodec & frame rate\ttags\t")
 
 	for _, formateID := range videoFormatsSortedKeys {
 
@@ -119,25 +164,23 @@ func ListVideoFormats(videoURL string, videoID string, metadata map[string]strin
 
 		if mimeType, isMimeTypePresent := formatInfo["MIME-TYPE"]; isMimeTypePresent {
 			if mimeType == "video/mp4" {
-				fmt.Fprintf(tw, "%s\t%s\tmp4\t%s\t%s\t%s fps\t%s\n", formateID, formatInfo["RESOLUTION"], formatInfo["K-FORM"], formatInfo["CODECS"], formatInfo["FRAME-RATE"], formatInfo["STREAM"])
+				fmt.Fprintf(tw, "%s\t%s\tmp4\t%s\t%s\t%s fps\t%s\t%s\n", formateID, formatInfo["RESOLUTION"], formatInfo["K-FORM"], formatInfo["CODECS"], formatInfo["FRAME-RATE"], formatInfo["STREAM"], formatInfo["TAGS"])
 			} else if mimeType == "audio/mp4" {
-				fmt.Fprintf(tw, "%s\tm4a\t%s\t%s\t%s\t%s\n", formateID, formatInfo["STREAM"], formatInfo["K-FORM"], formatInfo["CODECS"], formatInfo["SAMPLING-RATE"])
+				fmt.Fprintf(tw, "%s\tm4a\t%s\t%s\t%s\t%s\t%s\n", formateID, formatInfo["STREAM"], formatInfo["K-FORM"], formatInfo["CODECS"], formatInfo["SAMPLING-RATE"], formatInfo["TAGS"])
 			} else {
 				//Handle undefined mime types for dash formats
 			}
 		} else {
 			if frameRate, isFrameRatePresent := formatInfo["FRAME-RATE"]; isFrameRatePresent {
-				fmt.Fprintf(tw, "%s\tmp4\t%s\t%s\t%s  %s fps\n", formateID, formatInfo["RESOLUTION"], formatInfo["K-FORM"], formatInfo["CODECS"], frameRate)
+				fmt.Fprintf(tw, "%s\tmp4\t%s\t%s\t%s  %s fps\t%s\n", formateID, formatInfo["RESOLUTION"], formatInfo["K-FORM"], formatInfo["CODECS"], frameRate, formatInfo["TAGS"])
 			} else {
-				fmt.Fprintf(tw, "%s\tmp4\t%s\t%s\t%s\n", formateID, formatInfo["RESOLUTION"], formatInfo["K-FORM"], formatInfo["CODECS"])
+				fmt.Fprintf(tw, "%s\tmp4\t%s\t%s\t%s\t%s\n", formateID, formatInfo["RESOLUTION"], formatInfo["K-FORM"], formatInfo["CODECS"], formatInfo["TAGS"])
 			}
 		}
 	}
 	tw.Flush()
 
-	if metadata == nil {
-		os.Exit(0)
-	}
+	return nil
 }
 
 func isPathExists(path string) bool {
@@ -149,25 +192,29 @@ func isPathExists(path string) bool {
 	return !info.IsDir()
 }
 
-func getFfmpegArgs(videoURL string, videoMetadata map[string]string, streamURL string, dashFiles []string, metadataFlag bool, outputFileName string, isDashFile bool) []string {
+//getFfmpegArgs returns the arguments of ffmpeg, with the given input options applying to each of the inputs
+func getFfmpegArgs(videoURL string, videoMetadata map[string]string, streamURL string, dashTracks [][]string, metadataFlag bool, outputFileName string, isDashFile bool, inputArgs []string) []string {
 
 	ffmpegArgs := make([]string, 0)
 	if !isDashFile {
 		ffmpegArgs = append(ffmpegArgs, "-headers")
 		ffmpegArgs = append(ffmpegArgs, fmt.Sprintf("Referer: %s", videoURL))
-	}
-	ffmpegArgs = append(ffmpegArgs, "-i")
-	if isDashFile {
-		input := "concat:"
-		for index, filePath := range dashFiles {
-			if index != 0 {
-				input = fmt.Sprintf("%s|", input)
+		ffmpegArgs = append(ffmpegArgs, inputArgs...)
+		ffmpegArgs = append(ffmpegArgs, "-i")
+		ffmpegArgs = append(ffmpegArgs, streamURL)
+	} else {
+		for _, dashFiles := range dashTracks {
+			ffmpegArgs = append(ffmpegArgs, inputArgs...)
+			ffmpegArgs = append(ffmpegArgs, "-i")
+			ffmpegArgs = append(ffmpegArgs, "concat:"+strings.Join(dashFiles, "|"))
+		}
+		//keep the audio and the video of the separate inputs
+		if len(dashTracks) > 1 {
+			for index := range dashTracks {
+				ffmpegArgs = append(ffmpegArgs, "-map")
+				ffmpegArgs = append(ffmpegArgs, fmt.Sprintf("%d", index))
 			}
-			input = fmt.Sprintf("%s%s", input, filePath)
 		}
-		ffmpegArgs = append(ffmpegArgs, input)
-	} else {
-		ffmpegArgs = append(ffmpegArgs, streamURL)
 	}
 
 	if metadataFlag {
@@ -177,29 +224,65 @@ func getFfmpegArgs(videoURL string, videoMetadata map[string]string, streamURL s
 			ffmpegArgs = append(ffmpegArgs, metaData)
 		}
 	} else {
-		fmt.Println("Skipping adding metadata for video file")
+		logInfo("Skipping adding metadata for video file\n")
 	}
 
 	ffmpegArgs = append(ffmpegArgs, "-c")
 	ffmpegArgs = append(ffmpegArgs, "copy")
+	//the output is a part file, whose extension doesn't tell the format
+	ffmpegArgs = append(ffmpegArgs, "-f")
+	ffmpegArgs = append(ffmpegArgs, getFfmpegOutputFormat(strings.TrimSuffix(outputFileName, ".part")))
 	ffmpegArgs = append(ffmpegArgs, "-y")
 	ffmpegArgs = append(ffmpegArgs, outputFileName)
 
 	return ffmpegArgs
 }
 
-func runFfmpegCommand(videoURL string, ffmpegPath string, videoMetadata map[string]string, streamURL string, dashFiles []string, metadataFlag bool, outputFileName string, isDashFile bool) {
+//ffmpegOutputFormats maps the output file extensions to the ffmpeg muxers, the others written as mp4
+var ffmpegOutputFormats = map[string]string{
+	".mkv":  "matroska",
+	".webm": "webm",
+	".ts":   "mpegts",
+	".mov":  "mov",
+}
+
+func getFfmpegOutputFormat(outputFileName string) string {
+	if outputFormat, isKnown := ffmpegOutputFormats[strings.ToLower(filepath.Ext(outputFileName))]; isKnown {
+		return outputFormat
+	}
+	return "mp4"
+}
+
+//runFfmpegCommand runs ffmpeg with the given input options (if any), reporting its progress on the given format against the given
+//duration of the media (if known) unless its raw output is asked for with SetFfmpegVerbose. The HLS stream is fetched from the given
+//hosts only when its rate is limited.
+func runFfmpegCommand(videoURL string, ffmpegPath string, videoMetadata map[string]string, streamURL string, dashTracks [][]string, metadataFlag bool, outputFileName string, isDashFile bool, formatCode string, duration time.Duration, inputArgs []string, streamHosts []string) error {
+
+	var stderrBuf bytes.Buffer
 
-	var stdoutBuf, stderrBuf bytes.Buffer
+	ffmpegArgs := getFfmpegArgs(videoURL, videoMetadata, streamURL, dashTracks, metadataFlag, outputFileName, isDashFile, inputArgs)
+	if !ffmpegVerbose {
+		//the progress is read from stdout, leaving only the errors on stderr
+		ffmpegArgs = append([]string{"-hide_banner", "-loglevel", "error", "-nostats", "-progress", "pipe:1"}, ffmpegArgs...)
+	}
 
-	ffmpegArgs := getFfmpegArgs(videoURL, videoMetadata, streamURL, dashFiles, metadataFlag, outputFileName, isDashFile)
+	if limiter := getDownloadLimiter(); limiter != nil && !isDashFile {
+		//ffmpeg fetches the HLS stream itself, through the proxy limiting its rate along with the other downloads
+		proxyURL, stopProxy, err := startLimitedProxy(limiter, streamHosts)
+		if err != nil {
+			return err
+		}
+		defer stopProxy()
+		ffmpegArgs = append([]string{"-http_proxy", proxyURL}, ffmpegArgs...)
+	}
 
+	logVerbose("Running ffmpeg", "command", append([]string{ffmpegPath}, ffmpegArgs...))
 	ffmpegCmd := exec.Command(ffmpegPath, ffmpegArgs...)
 
 	if isDashFile {
-		fmt.Println("\nStarting ffmpeg to merge downloaded DASH audio/video...")
+		logInfo("\nStarting ffmpeg to merge downloaded DASH audio/video...\n")
 	} else {
-		fmt.Println("Starting ffmpeg to download video...")
+		logInfo("Starting ffmpeg to download video...\n")
 	}
 
 	stdoutIn, _ := ffmpegCmd.StdoutPipe()
@@ -207,32 +290,66 @@ func runFfmpegCommand(videoURL string, ffmpegPath string, videoMetadata map[stri
 
 	var errStdout, errStderr error
 
-	stdout := io.MultiWriter(os.Stdout, &stdoutBuf)
-	stderr := io.MultiWriter(os.Stderr, &stderrBuf)
-
 	err := ffmpegCmd.Start()
 
 	if err != nil {
-		log.Fatalf("ffmpegCmd.Start() failed with '%s'\n", err)
+		return fmt.Errorf("ffmpegCmd.Start() failed with '%s'", err)
 	}
 
+	var copying sync.WaitGroup
+	copying.Add(2)
+
 	go func() {
-		_, errStdout = io.Copy(stdout, stdoutIn)
+		defer copying.Done()
+		if ffmpegVerbose {
+			_, errStdout = io.Copy(GetMessageOutput(), stdoutIn)
+			return
+		}
+		errStdout = ParseFfmpegProgress(stdoutIn, func(progress FfmpegProgress) {
+			var totalSize int64
+			if progress.TotalSize > 0 {
+				totalSize = progress.TotalSize
+			}
+			reportProgress(ProgressEvent{
+				Type:     EventBytes,
+				Format:   formatCode,
+				Bytes:    totalSize,
+				OutTime:  progress.OutTime.Seconds(),
+				Duration: duration.Seconds(),
+				Speed:    progress.Speed,
+			})
+		})
 	}()
 
 	go func() {
+		defer copying.Done()
+		stderr := io.Writer(&stderrBuf)
+		if ffmpegVerbose {
+			stderr = io.MultiWriter(os.Stderr, &stderrBuf)
+		}
 		_, errStderr = io.Copy(stderr, stderrIn)
 	}()
 
+	//the pipes are read to the end before waiting, which closes them
+	copying.Wait()
 	err = ffmpegCmd.Wait()
+
 	if err != nil {
-		log.Fatalf("ffmpegCmd.Run() failed with %s\n", err)
+		if !ffmpegVerbose && stderrBuf.Len() != 0 {
+			return fmt.Errorf("ffmpegCmd.Run() failed with %s: %s", err, strings.TrimSpace(stderrBuf.String()))
+		}
+		return fmt.Errorf("ffmpegCmd.Run() failed with %s", err)
+	}
+
+	if !ffmpegVerbose && stderrBuf.Len() != 0 {
+		fmt.Fprintln(os.Stderr, strings.TrimSpace(stderrBuf.String()))
 	}
 
 	if errStdout != nil || errStderr != nil {
-		log.Fatal("failed to capture stdout or stderr\n")
+		return errors.New("failed to capture stdout or stderr")
 	}
 
+	return nil
 }
 
 func getBestOrLeastResolutionFormat(videoFormats map[string]map[string]string, bestOrLeast string) string {
@@ -251,80 +368,346 @@ func getBestOrLeastResolutionFormat(videoFormats map[string]map[string]string, b
 	return ""
 }
 
-func downloadDashAudioOrVideo(videoURL string, videoFormats map[string]map[string]string, vFormat string, outputFileName string, videoID string, videoMetadata map[string]string, currentDirectoryPath string, ffmpegPath string, metadataFlag bool) {
-	format := videoFormats[vFormat]
+//getDashFormatCodes returns the DASH formats to download for the given format, which can combine a video and an audio format
+//like dash-video-1500+dash-audio-128. The video format comes first.
+func getDashFormatCodes(videoFormats map[string]map[string]string, vFormat string) ([]string, error) {
+	formatCodes := strings.Split(vFormat, "+")
+	if len(formatCodes) > 2 {
+		return nil, fmt.Errorf("Invalid format %s. Only a video and an audio format can be combined like dash-video-1500+dash-audio-128", vFormat)
+	}
+	for _, formatCode := range formatCodes {
+		if _, isValidFormat := videoFormats[formatCode]; !isValidFormat || !IsDashFormatCode(formatCode) {
+			return nil, fmt.Errorf("The specified video format %s is not available. Specify existing format from the list", formatCode)
+		}
+	}
+	if len(formatCodes) == 2 {
+		if strings.HasPrefix(formatCodes[0], "dash-audio-") {
+			formatCodes[0], formatCodes[1] = formatCodes[1], formatCodes[0]
+		}
+		if !strings.HasPrefix(formatCodes[0], "dash-video-") || !strings.HasPrefix(formatCodes[1], "dash-audio-") {
+			return nil, fmt.Errorf("Invalid format %s. Only a video and an audio format can be combined like dash-video-1500+dash-audio-128", vFormat)
+		}
+	}
+	return formatCodes, nil
+}
+
+//downloadDashTrack downloads the chunks of the given DASH format, falling back to the same format of the other playback sets. The
+//chunks are streamed into the given track file, (or) kept in their own files in the temp directory for debugging. Only the chunks
+//covering the section are downloaded when given.
+func downloadDashTrack(videoFormats map[string]map[string]string, formatCode string, videoID string, trackFilePath string, paths Paths, keepFragments bool, section *Section, requestHeaders map[string]string, refreshFormats FormatsRefresher) ([]string, error) {
+	var err error
+	equivalentFormats := getEquivalentFormats(videoFormats, formatCode)
+	if section != nil {
+		for index := range equivalentFormats {
+			if equivalentFormats[index], _, err = GetDashSectionFormat(equivalentFormats[index], section); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for index, equivalentFormat := range equivalentFormats {
+		if index != 0 {
+			logInfo("\nFalling back to the same format from playback set %s\n", equivalentFormat["TAGS"])
+		}
+		formats := append([]map[string]string{equivalentFormat}, equivalentFormats...)
+		if keepFragments {
+			var dashFiles []string
+			if dashFiles, _, err = DownloadDashFilesBatch(paths.Temp, videoID, formatCode, formats, requestHeaders, refreshFormats); err == nil {
+				return dashFiles, nil
+			}
+		} else if err = DownloadDashStream(trackFilePath, formatCode, formats, requestHeaders, refreshFormats); err == nil {
+			return []string{trackFilePath}, nil
+		}
+		logInfo("\n%s\n", err)
+	}
+	return nil, err
+}
+
+//muxDashTracks merges the downloaded DASH tracks into the output file natively, falling back to ffmpeg (if any) for the
+//fragments which can't be merged natively
+func muxDashTracks(videoURL string, ffmpegPath string, videoMetadata map[string]string, dashTracks [][]string, metadataFlag bool, outputFilePath string) error {
+	var metadata map[string]string
+	if metadataFlag {
+		metadata = videoMetadata
+	} else {
+		logInfo("Skipping adding metadata for video file\n")
+	}
+
+	logInfo("\nMerging downloaded DASH audio/video...\n")
+	reportProgress(ProgressEvent{Type: EventMergeStarted, Path: outputFilePath})
+	err := MuxFragmentedMP4(outputFilePath, dashTracks, metadata)
+	if err == nil || ffmpegPath == "" || !errors.Is(err, ErrUnsupportedFragments) {
+		return err
+	}
+
+	logInfo("\n%s. Falling back to ffmpeg\n", err)
+	return runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, "", dashTracks, metadataFlag, outputFilePath, true, "", 0, nil, nil)
+}
+
+//trimDashSection trims the merged chunks of the section to its exact start and end, seeking from the start of the earliest chunk
+func trimDashSection(videoURL string, ffmpegPath string, videoMetadata map[string]string, videoFormats map[string]map[string]string, formatCodes []string, section *Section, metadataFlag bool, muxedFilePath string, outputFilePath string) error {
+	chunksStart := section.Start
+	for _, formatCode := range formatCodes {
+		if _, trackStart, err := GetDashSectionFormat(videoFormats[formatCode], section); err == nil && trackStart < chunksStart {
+			chunksStart = trackStart
+		}
+	}
+	logInfo("\nTrimming the section %s\n", strings.TrimPrefix(section.getFileNameSuffix(), "_"))
+	return runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, "", [][]string{{muxedFilePath}}, metadataFlag, outputFilePath, true, strings.Join(formatCodes, "+"), section.Duration(), section.getSectionInputArgs(section.Start-chunksStart), nil)
+}
+
+//verifyDashDownload verifies the file downloaded from the DASH formats against the duration of their chunks, cut to the section (if any).
+//The duration of the recordings of the live streams isn't known ahead.
+func verifyDashDownload(ffmpegPath string, filePath string, videoFormats map[string]map[string]string, formatCodes []string, section *Section, isLive bool) error {
+	formats := make([]map[string]string, 0, len(formatCodes))
+	var duration, segmentDuration time.Duration
+	for _, formatCode := range formatCodes {
+		format := videoFormats[formatCode]
+		formats = append(formats, format)
+		if isLive {
+			continue
+		}
+		seconds, _ := strconv.ParseFloat(format["SEGMENT-DURATION"], 64)
+		chunkDuration := time.Duration(seconds * float64(time.Second))
+		totalSegments, _ := strconv.Atoi(format["TOTAL-SEGMENTS"])
+		if formatDuration := chunkDuration * time.Duration(totalSegments); formatDuration > duration {
+			duration = formatDuration
+		}
+		//the last chunk may be cut short of the chunk duration, unlike the end of the sections trimmed exactly
+		if chunkDuration > segmentDuration && (section == nil || section.End == 0) {
+			segmentDuration = chunkDuration
+		}
+	}
+	if section != nil {
+		duration = section.getDurationIn(duration)
+	}
+	return verifyDownload(ffmpegPath, filePath, formats, duration, segmentDuration)
+}
+
+//getSectionFileNameSuffix returns the suffix of the default output file names of the section, empty for the whole video
+func getSectionFileNameSuffix(section *Section) string {
+	if section == nil {
+		return ""
+	}
+	return section.getFileNameSuffix()
+}
+
+func downloadDashAudioOrVideo(videoURL string, videoFormats map[string]map[string]string, vFormat string, outputFileName string, videoID string, videoMetadata map[string]string, paths Paths, keepFragments bool, section *Section, live LiveOptions, verify bool, ffmpegPath string, metadataFlag bool) error {
+	formatCodes, err := getDashFormatCodes(videoFormats, vFormat)
+	if err != nil {
+		return err
+	}
+	isLive := videoFormats[formatCodes[0]]["LIVE"] == "true"
+	if isLive && section != nil {
+		return errors.New("The sections can't be downloaded from a live stream")
+	}
+	if isLive && keepFragments {
+		logInfo("The live stream is recorded without the chunks, ignoring --keep-fragments\n")
+		keepFragments = false
+	}
 	if outputFileName == "" {
-		outputFileName = fmt.Sprintf("%s_%s__DASH_AV.mp4", videoID, strings.Replace(videoMetadata["title"], " ", "_", -1))
+		outputFileName = fmt.Sprintf("%s_%s__DASH_AV%s.mp4", videoID, strings.Replace(videoMetadata["title"], " ", "_", -1), getSectionFileNameSuffix(section))
 	}
-	outputFilePath := filepath.Join(currentDirectoryPath, outputFileName)
+	outputFilePath := paths.getOutputFilePath(outputFileName)
+	reportProgress(ProgressEvent{Type: EventFormatSelected, Format: strings.Join(formatCodes, "+")})
+	logVerbose("Selected DASH formats", "formats", formatCodes, "output", outputFilePath)
 
 	if isPathExists(outputFilePath) {
-		fmt.Printf("File %s already present in %s", outputFileName, currentDirectoryPath)
-		os.Exit(0)
+		logInfo("File %s already present in %s\n", filepath.Base(outputFilePath), filepath.Dir(outputFilePath))
+		reportProgress(ProgressEvent{Type: EventFinished, Path: outputFilePath})
+		return nil
 	}
 
-	requestHeaders := getRequestHeaders()
+	requestHeaders := getStreamRequestHeaders(videoURL)
 
-	requestHeaders["Referer"] = videoURL
-	requestHeaders["Origin"] = "https://www.hotstar.com"
-	requestHeaders["Host"] = "hses4.hotstar.com"
-
-	dashFiles, tempDashFileDir := DownloadDashFilesBatch(currentDirectoryPath, videoID, vFormat, format, requestHeaders)
-	runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, "", dashFiles, metadataFlag, outputFileName, true)
-	removeErr := os.RemoveAll(tempDashFileDir)
-	if removeErr != nil {
-		fmt.Println("Error in removing temp directory")
-		os.Exit(-1)
+	refreshFormats := func() (map[string]map[string]string, error) {
+		freshFormats, _, err := GetVideoFormats(videoURL, videoID, nil)
+		return freshFormats, err
+	}
+
+	//each track is streamed into its own part file in the temp directory
+	partFilePath := paths.getPartFilePath(outputFilePath)
+	dashTracks := make([][]string, 0, len(formatCodes))
+	trackFilePaths := make([]string, 0, len(formatCodes)+1)
+	defer func() {
+		for _, trackFilePath := range trackFilePaths {
+			os.Remove(trackFilePath)
+		}
+	}()
+	if isLive {
+		for _, formatCode := range formatCodes {
+			trackFilePaths = append(trackFilePaths, strings.TrimSuffix(partFilePath, ".part")+"."+formatCode+".part")
+		}
+		if dashTracks, err = recordLiveDashTracks(videoFormats, formatCodes, trackFilePaths, requestHeaders, refreshFormats, live); err != nil {
+			return err
+		}
 	} else {
-		fmt.Printf("\nTemp directory %s removed\n", tempDashFileDir)
-		os.Exit(0)
+		for _, formatCode := range formatCodes {
+			trackFilePath := strings.TrimSuffix(partFilePath, ".part") + "." + formatCode + ".part"
+			if !keepFragments {
+				trackFilePaths = append(trackFilePaths, trackFilePath)
+			}
+			dashFiles, err := downloadDashTrack(videoFormats, formatCode, videoID, trackFilePath, paths, keepFragments, section, requestHeaders, refreshFormats)
+			if err != nil {
+				return err
+			}
+			dashTracks = append(dashTracks, dashFiles)
+		}
+	}
+
+	//the tracks are remuxed even when single, dropping the segment index boxes scattered in the streamed file
+	if section == nil {
+		err = muxDashTracks(videoURL, ffmpegPath, videoMetadata, dashTracks, metadataFlag, partFilePath)
+	} else {
+		//the chunks cover the section whole, trimmed precisely by ffmpeg once merged
+		muxedFilePath := strings.TrimSuffix(partFilePath, ".part") + ".untrimmed.part"
+		trackFilePaths = append(trackFilePaths, muxedFilePath)
+		if err = muxDashTracks(videoURL, ffmpegPath, videoMetadata, dashTracks, metadataFlag, muxedFilePath); err == nil {
+			err = trimDashSection(videoURL, ffmpegPath, videoMetadata, videoFormats, formatCodes, section, metadataFlag, muxedFilePath, partFilePath)
+		}
+	}
+	if err == nil && verify {
+		err = verifyDashDownload(ffmpegPath, partFilePath, videoFormats, formatCodes, section, isLive)
 	}
+	if err == nil {
+		err = moveFile(partFilePath, outputFilePath)
+	}
+	if err != nil {
+		os.Remove(partFilePath)
+		return err
+	}
+	if keepFragments {
+		for _, dashFiles := range dashTracks {
+			logInfo("\nKept the DASH chunks in %s\n", filepath.Dir(dashFiles[0]))
+		}
+	}
+	reportProgress(ProgressEvent{Type: EventFinished, Path: outputFilePath})
+	return nil
 }
 
-func downloadVideo(videoURL string, vFormat string, videoFormats map[string]map[string]string, outputFileName string, videoID string, videoMetadata map[string]string, currentDirectoryPath string, ffmpegPath string, metadataFlag bool) {
+func downloadVideo(videoURL string, vFormat string, videoFormats map[string]map[string]string, outputFileName string, videoID string, videoMetadata map[string]string, paths Paths, section *Section, live LiveOptions, verify bool, ffmpegPath string, metadataFlag bool) error {
 	//Check if vFormat fallback is enabled by empty value passed
 	if len(strings.TrimSpace(vFormat)) == 0 {
-		fmt.Println("Missing format flag falling back to best formats for video")
+		logInfo("Missing format flag falling back to best formats for video\n")
 		vFormat = getBestOrLeastResolutionFormat(videoFormats, "BEST_RESOLUTION")
 		if len(strings.TrimSpace(vFormat)) != 0 {
-			fmt.Println("Best format for video is, ", videoFormats[vFormat]["RESOLUTION"])
+			logInfo("Best format for video is, %s\n", videoFormats[vFormat]["RESOLUTION"])
 		} else {
 			vFormat = getBestOrLeastResolutionFormat(videoFormats, "LEAST_RESOLUTION")
-			fmt.Println("Best formats for the video isn't available falling back to least resolution, ", videoFormats[vFormat]["RESOLUTION"])
+			logInfo("Best formats for the video isn't available falling back to least resolution, %s\n", videoFormats[vFormat]["RESOLUTION"])
 
 		}
 	}
 
-	if videoFormat, isValidFormat := videoFormats[vFormat]; isValidFormat {
+	if _, isValidFormat := videoFormats[vFormat]; !isValidFormat {
+		return fmt.Errorf("The specified video format %s is not available. Specify existing format from the list", vFormat)
+	}
 
-		if streamURL, isStreamURLAvailable := videoFormat["STREAM-URL"]; isStreamURLAvailable {
+	if outputFileName == "" {
+		outputFileName = fmt.Sprintf("%s-%s%s.mp4", videoID, strings.Replace(videoMetadata["title"], " ", "_", -1), getSectionFileNameSuffix(section))
+	}
+
+	outputFilePath := paths.getOutputFilePath(outputFileName)
+	reportProgress(ProgressEvent{Type: EventFormatSelected, Format: vFormat})
+	logVerbose("Selected format", "format", vFormat, "resolution", videoFormats[vFormat]["RESOLUTION"], "tags", videoFormats[vFormat]["TAGS"], "output", outputFilePath)
 
-			if outputFileName == "" {
-				outputFileName = fmt.Sprintf("%s-%s.mp4", videoID, strings.Replace(videoMetadata["title"], " ", "_", -1))
+	if isPathExists(outputFilePath) {
+		logInfo("File %s already present in %s\n", filepath.Base(outputFilePath), filepath.Dir(outputFilePath))
+		reportProgress(ProgressEvent{Type: EventFinished, Path: outputFilePath})
+		return nil
+	}
+
+	//ffmpeg writes to the part file, moved into place once complete
+	partFilePath := paths.getPartFilePath(outputFilePath)
+	var err error
+	for index, videoFormat := range getEquivalentFormats(videoFormats, vFormat) {
+		streamURL, isStreamURLAvailable := videoFormat["STREAM-URL"]
+		if !isStreamURLAvailable {
+			err = errors.New("The STREAM-URL is not available. Please try again")
+			continue
+		}
+		if index != 0 {
+			logInfo("Falling back to the same format from playback set %s\n", videoFormat["TAGS"])
+		}
+		var playlistBytes []byte
+		if playlistBytes, err = MakeGetRequest(streamURL, getStreamRequestHeaders(videoURL)); err != nil {
+			err = errors.Wrap(err, "Error in retrieving the HLS playlist")
+			continue
+		}
+		//the segments encrypted with AES-128 are decrypted natively, while those encrypted with DRM can't be downloaded at all
+		isEncrypted, encryptionErr := isEncryptedM3u8(string(playlistBytes), streamURL)
+		//the duration of the recordings of the live streams isn't known ahead
+		var duration time.Duration
+		switch {
+		case encryptionErr != nil:
+			return encryptionErr
+		case IsLiveM3u8(string(playlistBytes)) && section != nil:
+			return errors.New("The sections can't be downloaded from a live stream")
+		case IsLiveM3u8(string(playlistBytes)):
+			err = recordLiveHLS(videoURL, ffmpegPath, videoMetadata, streamURL, live, metadataFlag, partFilePath, vFormat)
+		case section != nil:
+			duration = section.getDurationIn(time.Duration(GetM3u8Duration(string(playlistBytes)) * float64(time.Second)))
+			err = downloadHLSSection(videoURL, ffmpegPath, videoMetadata, streamURL, string(playlistBytes), section, metadataFlag, partFilePath, vFormat)
+		case isEncrypted:
+			duration = time.Duration(GetM3u8Duration(string(playlistBytes)) * float64(time.Second))
+			err = downloadEncryptedHLS(videoURL, ffmpegPath, videoMetadata, streamURL, metadataFlag, partFilePath, vFormat, duration)
+		default:
+			duration = time.Duration(GetM3u8Duration(string(playlistBytes)) * float64(time.Second))
+			err = runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, streamURL, nil, metadataFlag, partFilePath, false, vFormat, duration, nil, getM3u8Hosts(streamURL, string(playlistBytes)))
+		}
+		if err == nil && verify {
+			//the other playback sets serve the same stream, so the download isn't retried from them
+			if err = verifyDownload(ffmpegPath, partFilePath, []map[string]string{videoFormat}, duration, 0); err != nil {
+				os.Remove(partFilePath)
+				return err
 			}
+		}
+		if err == nil {
+			if err = moveFile(partFilePath, outputFilePath); err == nil {
+				reportProgress(ProgressEvent{Type: EventFinished, Path: outputFilePath})
+				return nil
+			}
+		}
+	}
 
-			outputFilePath := filepath.Join(currentDirectoryPath, outputFileName)
+	os.Remove(partFilePath)
+	return err
+}
 
-			if isPathExists(outputFilePath) {
-				fmt.Printf("File %s already present in %s", outputFileName, currentDirectoryPath)
-				os.Exit(0)
-			}
+//downloadHLSSection downloads the segments of the HLS stream covering the section, trimmed to its exact start and end by ffmpeg
+func downloadHLSSection(videoURL string, ffmpegPath string, videoMetadata map[string]string, streamURL string, m3u8Content string, section *Section, metadataFlag bool, outputFileName string, formatCode string) error {
+	sectionM3u8, segmentsStart, err := GetSectionM3u8(m3u8Content, streamURL, section)
+	if err != nil {
+		return err
+	}
 
-			runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, streamURL, nil, metadataFlag, outputFileName, false)
-			os.Exit(0)
-		} else {
-			fmt.Println("The STREAM-URL is not available. Please try again")
-			os.Exit(-3)
-		}
+	sectionM3u8URL, stopServing, err := serveSectionM3u8(sectionM3u8)
+	if err != nil {
+		return err
+	}
+	defer stopServing()
 
-	} else {
-		fmt.Printf("The specified video format %s is not available. Specify existing format from the list", vFormat)
-		os.Exit(-4)
+	duration := section.getDurationIn(time.Duration(GetM3u8Duration(m3u8Content) * float64(time.Second)))
+	return runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, sectionM3u8URL, nil, metadataFlag, outputFileName, false, formatCode, duration, section.getSectionInputArgs(section.Start-segmentsStart), getM3u8Hosts(sectionM3u8URL, sectionM3u8))
+}
+
+//downloadEncryptedHLS downloads the segments of the HLS stream encrypted with AES-128 into a segments part file, decrypting them natively,
+//remuxed into the given output file by ffmpeg
+func downloadEncryptedHLS(videoURL string, ffmpegPath string, videoMetadata map[string]string, streamURL string, metadataFlag bool, outputFileName string, formatCode string, duration time.Duration) error {
+	segmentsFilePath := strings.TrimSuffix(outputFileName, ".part") + ".hls.part"
+	defer os.Remove(segmentsFilePath)
+
+	if err := DownloadHLSStream(segmentsFilePath, formatCode, streamURL, getStreamRequestHeaders(videoURL)); err != nil {
+		return err
 	}
+	return runFfmpegCommand(videoURL, ffmpegPath, videoMetadata, "", [][]string{{segmentsFilePath}}, metadataFlag, outputFileName, true, formatCode, duration, nil, nil)
 }
 
 //DownloadAudioOrVideo downloads the video for given video format and video url. It also adds metadata to it if needed. FFMPEG path and Output video file name can be customized.
-func DownloadAudioOrVideo(videoURL string, videoID string, vFormat string, userFfmpegPath string, outputFileName string, metadataFlag bool, isDashAV bool) {
+//The video is written to a part file in the temp path and moved to the output path once complete. The DASH chunks are kept in the temp
+//path when keepFragments is set. Only the given section of the video is downloaded when given. The live streams are recorded until they
+//end (or) Ctrl-C is pressed. The downloaded video is probed and compared against the format when verify is set, failing the download
+//when they differ.
+func DownloadAudioOrVideo(videoURL string, videoID string, vFormat string, userFfmpegPath string, outputFileName string, metadataFlag bool, isDashAV bool, paths Paths, keepFragments bool, section *Section, live LiveOptions, verify bool) error {
 
 	var ffmpegPath string
 
@@ -332,118 +715,117 @@ func DownloadAudioOrVideo(videoURL string, videoID string, vFormat string, userF
 		ffmpegPath = userFfmpegPath
 	} else {
 		path, err := exec.LookPath("ffmpeg")
-		if err != nil {
-			log.Fatal("Error in finding command ffmpeg. Please install one and try again. ", err)
+		//the DASH formats are merged natively, needing ffmpeg only for the fragments which can't be (or) to trim a section
+		if err != nil && !isDashAV {
+			return errors.Wrap(err, "Error in finding command ffmpeg. Please install one and try again")
+		}
+		if err != nil && section != nil {
+			return errors.Wrap(err, "Error in finding command ffmpeg, needed to trim the section. Please install one and try again")
 		}
 		ffmpegPath = path
 	}
 
-	currentDirectoryPath, err := os.Getwd()
+	paths, err := paths.resolve()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	videoFormats, videoMetadata, err := GetVideoFormats(videoURL, videoID, nil)
+	videoFormats, videoMetadata, err := waitForVideoFormats(videoURL, videoID, live.WaitForVideo)
 
 	if err != nil {
-		log.Fatal(fmt.Errorf("Error occurred : %s", err))
+		return err
 	}
+	reportProgress(ProgressEvent{Type: EventResolved, Title: videoMetadata["title"]})
 
 	if drmProtected, isDrmKeyAvailable := videoMetadata["drmProtected"]; isDrmKeyAvailable {
 		if drmProtected == "true" {
-			fmt.Println("Error: The video is DRM Protected")
-			os.Exit(-1)
+			return ErrDRMProtected
 		}
 	}
 
-	if err := os.Chmod(ffmpegPath, 0555); err != nil {
-		log.Fatal(err)
+	if ffmpegPath != "" {
+		if err := os.Chmod(ffmpegPath, 0555); err != nil {
+			return err
+		}
 	}
 
 	if isDashAV {
-		downloadDashAudioOrVideo(videoURL, videoFormats, vFormat, outputFileName, videoID, videoMetadata, currentDirectoryPath, ffmpegPath, metadataFlag)
-		fmt.Println("Downloaded DASH audio/video successfully...")
+		if err := downloadDashAudioOrVideo(videoURL, videoFormats, vFormat, outputFileName, videoID, videoMetadata, paths, keepFragments, section, live, verify, ffmpegPath, metadataFlag); err != nil {
+			return err
+		}
+		logInfo("Downloaded DASH audio/video successfully...\n")
 	} else {
-		downloadVideo(videoURL, vFormat, videoFormats, outputFileName, videoID, videoMetadata, currentDirectoryPath, ffmpegPath, metadataFlag)
-		fmt.Println("Downloaded video successfully...")
+		if err := downloadVideo(videoURL, vFormat, videoFormats, outputFileName, videoID, videoMetadata, paths, section, live, verify, ffmpegPath, metadataFlag); err != nil {
+			return err
+		}
+		logInfo("Downloaded video successfully...\n")
 	}
 
+	return nil
 }
 
-//ListOrDownloadPlaylistVideoFormats lists video formats (or) title (or) description of each video url in the list.
-func ListOrDownloadPlaylistVideoFormats(playlistID string, titleFlag bool, descriptionFlag bool, playlistStartRange string, playlistEndRange string, isDownloadSwitch bool, vFormat string, userFfmpegPath string, outputFileName string, metadataFlag bool, isDashAV bool) {
-	var result map[string]interface{}
+//GetPlaylistItems gets the metadata of every video in the playlist for the given playlist id, newest first.
+func GetPlaylistItems(playlistID string) ([]map[string]string, error) {
+	var result trayResponse
 	playlistURI := fmt.Sprintf("https://api.hotstar.com/o/v1/tray/find?uqId=%s&tas=10000", playlistID)
 
-	playlistURIContentBytes, err := MakeGetRequest(playlistURI, getRequestHeaders())
+	playlistURIContentBytes, err := makeAPIGetRequest(playlistURI, getRequestHeaders())
 
 	if err != nil {
-		panic(err)
+		return nil, classifyHTTPError(trayEndpoint, err, playlistURIContentBytes, false)
 	}
 
-	json.Unmarshal(playlistURIContentBytes, &result)
-
-	statusCode, isStatusCastOk := result["statusCodeValue"].(float64)
+	if err := decodeResponse(trayEndpoint, playlistURIContentBytes, &result); err != nil {
+		return nil, err
+	}
 
-	if isStatusCastOk {
-		if statusCode != 200 {
-			panic(errors.New("Invalid status code returned"))
-		} else {
-			body, isBodyCastOk := result["body"].(map[string]interface{})
+	//the tray api reports its refusals in the body
+	if result.StatusCodeValue != nil && *result.StatusCodeValue != 200 {
+		if apiError := newAPIError(trayEndpoint, *result.StatusCodeValue, result.StatusCode, result.Message, false); apiError != nil {
+			return nil, apiError
+		}
+	}
 
-			if isBodyCastOk {
-				results := body["results"].(map[string]interface{})
-				assets := results["assets"].(map[string]interface{})
-				items := assets["items"].([]interface{})
-				playlistItemCount := len(items)
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
 
-				if strings.Compare(playlistStartRange, "") == 0 {
-					fmt.Println("Start range not specified falling back to upper bound, 1")
-					playlistStartRange = "1"
-				}
+	items := result.Body.Results.Assets.Items
+	playlistItems := make([]map[string]string, 0, len(items))
 
-				if strings.Compare(playlistEndRange, "") == 0 {
-					fmt.Println("End range not specified falling back to lower bound,", playlistItemCount)
-					playlistEndRange = fmt.Sprintf("%d", playlistItemCount)
-				}
+	for _, item := range items {
+		playlistItems = append(playlistItems, item.MetadataMap())
+	}
 
-				fmt.Printf("\nCollected %d video id(s) from playlist\n", playlistItemCount)
+	return playlistItems, nil
+}
 
-				startRange, endRange, boundValidationErrors, isValidBounds := isValidPlaylistBounds(playlistItemCount, playlistStartRange, playlistEndRange)
+//SelectPlaylistItems selects the playlist items in the given 1 based range counting from the oldest item.
+func SelectPlaylistItems(playlistItems []map[string]string, playlistStartRange string, playlistEndRange string) ([]map[string]string, error) {
+	playlistItemCount := len(playlistItems)
 
-				if !isValidBounds {
-					fmt.Println(boundValidationErrors)
-					os.Exit(-1)
-				}
+	if strings.Compare(playlistStartRange, "") == 0 {
+		logInfo("Start range not specified falling back to upper bound, 1\n")
+		playlistStartRange = "1"
+	}
 
-				for itemIndex := (playlistItemCount - 1) - (startRange - 1); itemIndex >= (playlistItemCount-1)-(endRange-1); itemIndex-- {
-					itemValue := items[itemIndex]
-					itemInfo := itemValue.(map[string]interface{})
+	if strings.Compare(playlistEndRange, "") == 0 {
+		logInfo("End range not specified falling back to lower bound, %d\n", playlistItemCount)
+		playlistEndRange = fmt.Sprintf("%d", playlistItemCount)
+	}
 
-					var metadata = make(map[string]interface{})
-					for contentKey, contentValue := range itemInfo {
-						metadata[contentKey] = contentValue
-					}
+	logInfo("\nCollected %d video id(s) from playlist\n", playlistItemCount)
 
-					metaDataMap := make(map[string]string)
-					PopulateMetaDataMapWithMetadata(metaDataMap, metadata)
+	startRange, endRange, boundValidationErrors, isValidBounds := isValidPlaylistBounds(playlistItemCount, playlistStartRange, playlistEndRange)
 
-					fmt.Printf("\nFor video id, %s\n", metaDataMap["id"])
+	if !isValidBounds {
+		return nil, errors.New(strings.TrimSpace(boundValidationErrors))
+	}
 
-					if !isDownloadSwitch {
-						ListVideoFormats(GetPlaybackURI2(metaDataMap["id"], uuid.New().String()), metaDataMap["id"], metaDataMap, titleFlag, descriptionFlag)
-					} else {
-						DownloadAudioOrVideo(GetPlaybackURI2(metaDataMap["id"], uuid.New().String()), metaDataMap["id"], vFormat, userFfmpegPath, outputFileName, metadataFlag, isDashAV)
-					}
-				}
-			} else {
-				fmt.Println("result body to map[string]interface{} cast unsuccessful")
-				os.Exit(-1)
-			}
-		}
-	} else {
-		fmt.Println("result statusCodeValue to map[string]interface{} cast unsuccessful")
-		os.Exit(-1)
+	selectedItems := make([]map[string]string, 0)
+	for itemIndex := (playlistItemCount - 1) - (startRange - 1); itemIndex >= (playlistItemCount-1)-(endRange-1); itemIndex-- {
+		selectedItems = append(selectedItems, playlistItems[itemIndex])
 	}
 
+	return selectedItems, nil
 }
@@ -1,41 +1,33 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"sort"
 	"strconv"
 	"strings"
-
-	"github.com/google/uuid"
 )
 
-var refreshTokenRetryCount = 0
-var videoURLRetryCount = 0
-var playbackRetryCount = 0
-var playbackURIContentRetryCount = 0
-var masterPlaybackURLRetryCount = 0
-var tempVideoFormatsRetryCount = 0
+//maxAPIAttempts is the count of the attempts of each request to the Hotstar website and apis
+const maxAPIAttempts = 10
 
 func getRequestHeaders() map[string]string {
-	return map[string]string{
-		"Hotstarauth":     GenerateHotstarAuth(),
-		"X-Country-Code":  "IN",
-		"X-Platform-Code": "JIO",
-		"X-HS-Platform":   "web",
-		"X-HS-AppVersion": "6.72.2",
-		"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/74.0.3729.122 Safari/537.36",
-		"Origin":          "https://www.hotstar.com",
+	requestHeaders := map[string]string{
+		"Hotstarauth":    GenerateHotstarAuth(),
+		"Origin":         "https://www.hotstar.com",
+		"X-Country-Code": getCountryCode(),
+	}
+	for headerName, headerValue := range clientProfile.Headers {
+		requestHeaders[headerName] = headerValue
 	}
+	return requestHeaders
 }
 
-func getRefreshTokenHeaders() map[string]string {
-	return map[string]string{
-		"hotstarauth":  GenerateHotstarAuth(),
-		"userIdentity": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJ1bV9hY2Nlc3MiLCJleHAiOjE1OTAzMDE5OTYsImlhdCI6MTU4OTY5NzE5NiwiaXNzIjoiVFMiLCJzdWIiOiJ7XCJoSWRcIjpcIjU5YjkxMTBkMDM2ZjQ3M2U5OTBhNzFiNDAwMDM5MzRkXCIsXCJwSWRcIjpcImVjNmRmY2Y1ZTJhYzRhYWJhZjNjOTBlY2I0YTY5MTVlXCIsXCJuYW1lXCI6XCJHdWVzdCBVc2VyXCIsXCJpcFwiOlwiMjIzLjIyNi4yOS4yMjdcIixcImNvdW50cnlDb2RlXCI6XCJpblwiLFwiY3VzdG9tZXJUeXBlXCI6XCJudVwiLFwidHlwZVwiOlwiZGV2aWNlXCIsXCJpc0VtYWlsVmVyaWZpZWRcIjpmYWxzZSxcImlzUGhvbmVWZXJpZmllZFwiOmZhbHNlLFwiZGV2aWNlSWRcIjpcImExMTg1MTFhLTJmYjktNDhmOS04MGM5LWY1OTlkMjdlYTZmNlwiLFwicHJvZmlsZVwiOlwiQURVTFRcIixcInZlcnNpb25cIjpcInYyXCIsXCJzdWJzY3JpcHRpb25zXCI6e1wiaW5cIjp7fX0sXCJpc3N1ZWRBdFwiOjE1ODk2OTcxOTY2NzJ9IiwidmVyc2lvbiI6IjFfMCJ9.bkx7DodQSFohwmzqf8RmKOr3tuORgVFEh_qbtdqzeVA",
-		"Origin":       "https://www.hotstar.com",
-		"deviceId":     uuid.New().String(),
-	}
+//getStreamRequestHeaders returns the headers of the requests for the playlists, the segments and the keys of the streams of the given video
+func getStreamRequestHeaders(videoURL string) map[string]string {
+	requestHeaders := getRequestHeaders()
+	requestHeaders["Referer"] = videoURL
+	requestHeaders["Origin"] = "https://www.hotstar.com"
+	return requestHeaders
 }
 
 func getAggregatedFormats(videoFormatsTemp, audioDashFormatsTemp, videoDashFormatsTemp map[string][]map[string]string) map[string]map[string]string {
@@ -76,177 +68,161 @@ func getAggregatedFormats(videoFormatsTemp, audioDashFormatsTemp, videoDashForma
 	return totalFormats
 }
 
-func getRefreshToken(videoURL string) (string, error) {
-	var result map[string]interface{}
-	var refreshTokenHeaders = getRefreshTokenHeaders()
-	refreshTokenHeaders["Referer"] = videoURL
-
-	refreshTokenURL := "https://api.hotstar.com/in/aadhar/v2/web/in/user/refresh-token"
-	refreshTokenURLContentBytes, err := MakeGetRequest(refreshTokenURL, refreshTokenHeaders)
-
-	if err != nil {
-		if refreshTokenRetryCount < 10 {
-
-			//retry again for fetching JWT token
-			refreshTokenRetryCount++
-
-			//fmt.Printf("getRefreshToken: GET request to videoURL failed... Retrying count : #%d\n", refreshTokenRetryCount)
-			return getRefreshToken(videoURL)
-		}
-	}
-
-	json.Unmarshal(refreshTokenURLContentBytes, &result)
-
-	description := result["description"].(map[string]interface{})
-	userIdentity := description["userIdentity"].(string)
-
-	return userIdentity, nil
-}
-
+//getVideoURL gets the page of the video, retrying on failure
 func getVideoURL(videoURL string, requestHeaders map[string]string) (string, error) {
-	videoURLContentBytes, err := MakeGetRequest(videoURL, requestHeaders)
-
-	if err != nil {
-		if videoURLRetryCount+1 < 10 {
-			//retry again for fetching formats
-			videoURLRetryCount++
-			//fmt.Printf("GetVideoFormats: GET request to videoURL failed... Retrying count : #%d\n", videoURLRetryCount)
-			return getVideoURL(videoURL, requestHeaders)
+	var videoURLContentBytes []byte
+	var err error
+	for attempt := 1; attempt <= maxAPIAttempts; attempt++ {
+		if videoURLContentBytes, err = makeAPIGetRequest(videoURL, requestHeaders); err == nil {
+			return fmt.Sprintf("%s", videoURLContentBytes), nil
+		}
+		if attempt < maxAPIAttempts {
+			logVerbose("Retrying the video page request", "attempt", attempt, "url", videoURL, "error", err)
 		}
-		return "", err
 	}
-
-	return fmt.Sprintf("%s", videoURLContentBytes), nil
+	return "", err
 }
 
+//getPlayback resolves the playback uri and the metadata of the video, retrying on failure
 func getPlayback(videoURLContent, videoURL, videoID, uuid string) (string, map[string]string, error) {
-	playbackURI, videoMetadata, err := GetPlaybackURI(videoURLContent, videoURL, videoID, uuid)
-
-	if err != nil {
-		if playbackRetryCount+1 < 10 {
-			//retry again for fetching formats
-			playbackRetryCount++
-			//fmt.Printf("GetVideoFormats: Invalid APP_STATE json... retrying count : #%d\n", playbackRetryCount)
-			return getPlayback(videoURLContent, videoURL, videoID, uuid)
+	var err error
+	for attempt := 1; attempt <= maxAPIAttempts; attempt++ {
+		playbackURI, videoMetadata, playbackErr := GetPlaybackURI(videoURLContent, videoURL, videoID, uuid)
+		if err = playbackErr; err == nil {
+			return playbackURI, videoMetadata, nil
+		}
+		if attempt < maxAPIAttempts {
+			logVerbose("Retrying the playback uri resolution", "attempt", attempt, "error", err)
 		}
-		return "", nil, err
-		//log.Fatal(fmt.Errorf("Error occurred : %s", err))
 	}
-
-	return playbackURI, videoMetadata, nil
+	return "", nil, err
 }
 
+//getPlaybackURIContent gets the playback of the video, retrying on failure except on the refusals of the playback api
 func getPlaybackURIContent(playbackURI string, requestHeaders map[string]string) ([]byte, error) {
-	playbackURIContentBytes, err := MakeGetRequest(playbackURI, requestHeaders)
-
-	if err != nil {
-		if playbackURIContentRetryCount+1 < 10 {
-			//retry again for fetching formats
-			playbackURIContentRetryCount++
-			//fmt.Printf("GetVideoFormats: GET request to playbackURI failed... Retrying count : #%d\n", playbackURIContentRetryCount)
-			return getPlaybackURIContent(playbackURI, requestHeaders)
+	var playbackURIContentBytes []byte
+	var err error
+	for attempt := 1; attempt <= maxAPIAttempts; attempt++ {
+		if playbackURIContentBytes, err = makeAPIGetRequest(playbackURI, requestHeaders); err == nil {
+			return playbackURIContentBytes, nil
+		}
+		//the refusals of the playback api aren't retried
+		if statusCode := GetHTTPStatusCode(err); statusCode >= 400 && statusCode < 500 {
+			break
+		}
+		if attempt < maxAPIAttempts {
+			logVerbose("Retrying the playback request", "attempt", attempt, "url", playbackURI, "error", err)
 		}
-		return nil, err
 	}
-
-	return playbackURIContentBytes, nil
+	return playbackURIContentBytes, err
 }
 
-func getMasterPlaybackURL(playbackURIContentBytes []byte) ([]string, error) {
-	masterPlaybackURLs, err := GetMasterPlaybackURLs(playbackURIContentBytes)
-
-	if err != nil {
-		if masterPlaybackURLRetryCount+1 < 10 {
-			//retry again for fetching formats
-			masterPlaybackURLRetryCount++
-			//fmt.Printf("GetVideoFormats: Retriving masterPlaybackURL failed... Retrying count : #%d\n", masterPlaybackURLRetryCount)
-			return getMasterPlaybackURL(playbackURIContentBytes)
+//getMasterPlaybackContent gets the master playlist (or) manifest of the playback set, retrying on failure
+func getMasterPlaybackContent(masterPlaybackURL string, requestHeaders map[string]string) ([]byte, error) {
+	var contentBytes []byte
+	var err error
+	for attempt := 0; attempt < maxAPIAttempts; attempt++ {
+		contentBytes, err = MakeGetRequest(masterPlaybackURL, requestHeaders)
+		if err == nil {
+			return contentBytes, nil
 		}
-		return nil, err
-		//log.Fatal(fmt.Errorf("Error occurred : %s", err))
+		logVerbose("Retrying the master playlist request", "attempt", attempt+1, "url", masterPlaybackURL, "error", err)
 	}
-	return masterPlaybackURLs, nil
+	return nil, err
+}
+
+//addPlaybackSetTags keeps the tags of the playback set the format is derived from on the format
+func addPlaybackSetTags(formatInfo map[string]string, playbackSet PlaybackSet) map[string]string {
+	formatInfo["TAGS"] = playbackSet.TagsCombination
+	formatInfo["CDN"] = playbackSet.CDNType
+	return formatInfo
 }
 
-func getTempVideoFormats(masterPlaybackURLs []string, requestHeaders map[string]string) (map[string][]map[string]string, map[string][]map[string]string, map[string][]map[string]string, error) {
+func getTempVideoFormats(playbackSets []PlaybackSet, requestHeaders map[string]string) (map[string][]map[string]string, map[string][]map[string]string, map[string][]map[string]string, error) {
 	videoFormatsTemp := make(map[string][]map[string]string)
 	videoDashFormatsTemp := make(map[string][]map[string]string)
 	audioDashFormatsTemp := make(map[string][]map[string]string)
 
-	for _, masterPlaybackURL := range masterPlaybackURLs {
-
-		if masterPlaybackURL != "" {
-
-			var queryParams string
-			masterPlaybackURLQueryParam := strings.Split(masterPlaybackURL, "?")
-
-			if len(masterPlaybackURLQueryParam) > 1 {
-				queryParams = masterPlaybackURLQueryParam[1]
-			}
-
-			if strings.Contains(masterPlaybackURL, "m3u8") {
-
-				masterPlaybackPageContentsM3u8Bytes, err := MakeGetRequest(masterPlaybackURL, requestHeaders)
-
-				if err != nil {
-
-					if tempVideoFormatsRetryCount+1 < 10 {
-						//retry again for fetching formats
-						tempVideoFormatsRetryCount++
-						//fmt.Printf("GetVideoFormats: GET request to masterPlaybackURL failed... Retrying count : #%d\n", tempVideoFormatsRetryCount)
-						return getTempVideoFormats(masterPlaybackURLs, requestHeaders)
-					}
-
-					return nil, nil, nil, err
-				}
-
-				for fid, formatsList := range ParseM3u8Content(fmt.Sprintf("%s", masterPlaybackPageContentsM3u8Bytes), masterPlaybackURL, queryParams) {
-					videoFormatsTemp[fid] = append(videoFormatsTemp[fid], formatsList)
-				}
-			} else {
+	var firstError error
+	for _, playbackSet := range playbackSets {
 
-				masterPlaybackPageContentsMpdBytes, err := MakeGetRequest(masterPlaybackURL, requestHeaders)
+		masterPlaybackURL := playbackSet.PlaybackURL
+		if masterPlaybackURL == "" {
+			continue
+		}
 
-				if err != nil {
+		var queryParams string
+		masterPlaybackURLQueryParam := strings.Split(masterPlaybackURL, "?")
 
-					if tempVideoFormatsRetryCount+1 < 10 {
-						//retry again for fetching formats
-						tempVideoFormatsRetryCount++
-						//fmt.Printf("GetVideoFormats: GET request to masterPlaybackURL failed... Retrying count : #%d\n", tempVideoFormatsRetryCount)
-						return getTempVideoFormats(masterPlaybackURLs, requestHeaders)
-					}
-
-					return nil, nil, nil, err
-				}
+		if len(masterPlaybackURLQueryParam) > 1 {
+			queryParams = masterPlaybackURLQueryParam[1]
+		}
 
-				dFormats := GetDashFormats(masterPlaybackPageContentsMpdBytes, masterPlaybackURL)
+		masterPlaybackPageContentsBytes, err := getMasterPlaybackContent(masterPlaybackURL, requestHeaders)
+		if err != nil {
+			//fall back to the other playback sets
+			logInfo("Skipping playback set %s: %s\n", playbackSet.TagsCombination, err)
+			if firstError == nil {
+				firstError = err
+			}
+			continue
+		}
 
-				for avType, formatsList := range dFormats {
-					for formatCode, formatInfo := range formatsList {
-						if avType == "video" {
-							videoDashFormatsTemp[formatCode] = append(videoDashFormatsTemp[formatCode], formatInfo)
-						} else {
-							audioDashFormatsTemp[formatCode] = append(audioDashFormatsTemp[formatCode], formatInfo)
-						}
+		if strings.Contains(masterPlaybackURL, "m3u8") {
+			for fid, formatsList := range ParseM3u8Content(fmt.Sprintf("%s", masterPlaybackPageContentsBytes), masterPlaybackURL, queryParams) {
+				videoFormatsTemp[fid] = append(videoFormatsTemp[fid], addPlaybackSetTags(formatsList, playbackSet))
+			}
+		} else {
+			dFormats := GetDashFormats(masterPlaybackPageContentsBytes, masterPlaybackURL)
+
+			for avType, formatsList := range dFormats {
+				for formatCode, formatInfo := range formatsList {
+					if avType == "video" {
+						videoDashFormatsTemp[formatCode] = append(videoDashFormatsTemp[formatCode], addPlaybackSetTags(formatInfo, playbackSet))
+					} else {
+						audioDashFormatsTemp[formatCode] = append(audioDashFormatsTemp[formatCode], addPlaybackSetTags(formatInfo, playbackSet))
 					}
 				}
-
 			}
-
 		}
+	}
 
+	if len(videoFormatsTemp) == 0 && len(videoDashFormatsTemp) == 0 && len(audioDashFormatsTemp) == 0 && firstError != nil {
+		return nil, nil, nil, firstError
 	}
 
 	return videoFormatsTemp, audioDashFormatsTemp, videoDashFormatsTemp, nil
 }
 
-func raiseError(errorMsg string) {
-	fmt.Println(errorMsg)
-	os.Exit(-1)
+//formatEquivalenceKey identifies the formats carrying the same stream, which can replace each other when one fails
+func formatEquivalenceKey(formatInfo map[string]string) string {
+	streamType := "hls"
+	if mimeType, isMimeTypePresent := formatInfo["MIME-TYPE"]; isMimeTypePresent {
+		streamType = mimeType
+	}
+	return strings.Join([]string{streamType, formatInfo["RESOLUTION"], formatInfo["CODECS"], formatInfo["K-FORM"]}, "|")
 }
 
-func raiseConversionError(varname, varvalue string) {
-	raiseError(fmt.Sprintf("\nError in converting %s, %s to integer", varname, varvalue))
+//getEquivalentFormats returns the given format followed by the equivalent formats of the other playback sets (or) CDNs to fall back to
+func getEquivalentFormats(videoFormats map[string]map[string]string, vFormat string) []map[string]string {
+	format := videoFormats[vFormat]
+	equivalentFormats := []map[string]string{format}
+
+	equivalentFormatIDs := make([]string, 0)
+	for formatID, formatInfo := range videoFormats {
+		if formatID != vFormat && formatEquivalenceKey(formatInfo) == formatEquivalenceKey(format) {
+			equivalentFormatIDs = append(equivalentFormatIDs, formatID)
+		}
+	}
+	sort.Strings(equivalentFormatIDs)
+
+	for _, formatID := range equivalentFormatIDs {
+		equivalentFormats = append(equivalentFormats, videoFormats[formatID])
+	}
+	if len(equivalentFormatIDs) != 0 {
+		logVerbose("Found equivalent formats to fall back to", "format", vFormat, "fallbacks", equivalentFormatIDs)
+	}
+	return equivalentFormats
 }
 
 func isValidPlaylistBounds(playlistItemCount int, playlistStartRange, playlistEndRange string) (int, int, string, bool) {
@@ -255,11 +231,11 @@ func isValidPlaylistBounds(playlistItemCount int, playlistStartRange, playlistEn
 	isValid := false
 	startRange, startRangeError := strconv.Atoi(playlistStartRange)
 	if startRangeError != nil {
-		raiseConversionError("playlistStartRange", playlistStartRange)
+		return 0, 0, fmt.Sprintf("\nError in converting %s, %s to integer", "playlistStartRange", playlistStartRange), false
 	}
 	endRange, endRangeError := strconv.Atoi(playlistEndRange)
 	if endRangeError != nil {
-		raiseConversionError("playlistEndRange", playlistEndRange)
+		return 0, 0, fmt.Sprintf("\nError in converting %s, %s to integer", "playlistEndRange", playlistEndRange), false
 	}
 
 	validationMessage.WriteString("")
@@ -276,7 +252,7 @@ func isValidPlaylistBounds(playlistItemCount int, playlistStartRange, playlistEn
 		validationMessage.WriteString(fmt.Sprintf("\nInvalid start range %d provided. Should be <= %d", startRange, endRange))
 	} else if endRange < startRange {
 		validationMessage.WriteString(fmt.Sprintf("\nInvalid end range %d provided. Should be >= %d", endRange, startRange))
-	} else {
+	} else if validationMessage.Len() == 0 {
 		isValid = true
 	}
 
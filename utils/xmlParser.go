@@ -50,7 +50,11 @@ type AdaptationSet struct {
 
 //MPD struct contains
 type MPD struct {
+	Type                      string          `xml:"type,attr"` //dynamic for the live streams
 	MediaPresentationDuration string          `xml:"mediaPresentationDuration,attr"`
+	AvailabilityStartTime     string          `xml:"availabilityStartTime,attr"`
+	TimeShiftBufferDepth      string          `xml:"timeShiftBufferDepth,attr"`
+	MinimumUpdatePeriod       string          `xml:"minimumUpdatePeriod,attr"`
 	MinBufferTime             string          `xml:"minBufferTime,attr"`
 	Profiles                  string          `xml:"profiles,attr"`
 	Xmlns                     string          `xml:"xmlns,attr"`
@@ -70,24 +74,52 @@ func getParsedTimeUnit(unit string) (float64, error) {
 	return strconv.ParseFloat(unit, 64)
 }
 
-//GetDashFormats gives the dash formats for any given dash URL
+var mpdDurationRegex = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+//parseMPDDuration parses an ISO 8601 duration of the MPD like PT21M7.800S into seconds
+func parseMPDDuration(duration string) (float64, bool) {
+	matches := mpdDurationRegex.FindStringSubmatch(strings.TrimSpace(duration))
+	if matches == nil || duration == "P" {
+		return 0, false
+	}
+
+	days, _ := getParsedTimeUnit(matches[1])
+	hours, _ := getParsedTimeUnit(matches[2])
+	minutes, _ := getParsedTimeUnit(matches[3])
+	seconds, _ := getParsedTimeUnit(matches[4])
+
+	return (days * 24 * 60 * 60) + (hours * 60 * 60) + (minutes * 60) + seconds, true
+}
+
+//addLiveTiming adds the timing of the segments of the live stream of the dynamic MPD to the format, from which the segments available
+//at any time are found
+func addLiveTiming(format map[string]string, mpd MPD, segmentTemplate SegmentTemplate) {
+	startNumber := segmentTemplate.StartNumber
+	if startNumber == "" {
+		startNumber = "1"
+	}
+	timeShiftBufferDepth, _ := parseMPDDuration(mpd.TimeShiftBufferDepth)
+	minimumUpdatePeriod, _ := parseMPDDuration(mpd.MinimumUpdatePeriod)
+
+	format["LIVE"] = "true"
+	format["STREAM"] += ", live"
+	format["AVAILABILITY-START-TIME"] = mpd.AvailabilityStartTime
+	format["TIME-SHIFT-BUFFER-DEPTH"] = strconv.FormatFloat(timeShiftBufferDepth, 'f', -1, 64)
+	format["MINIMUM-UPDATE-PERIOD"] = strconv.FormatFloat(minimumUpdatePeriod, 'f', -1, 64)
+	format["START-NUMBER"] = startNumber
+}
+
+//GetDashFormats gives the dash formats for any given dash URL. The formats of the live streams of the dynamic MPDs are marked LIVE, with
+//the timing of their segments instead of their count.
 func GetDashFormats(data []byte, masterPlaybackURL string) map[string]map[string]map[string]string {
 	var mpd MPD
-	var totalSeconds float64
 	var totalSegments int
 	var audioOrVideo = make(map[string]map[string]map[string]string)
 	xml.Unmarshal(data, &mpd)
-	mediaPresentationDurationRegex := regexp.MustCompile(`PT((\d+)H)?((\d+)M)?((\d+)\.(\d+)S)?`)
-	matches := mediaPresentationDurationRegex.FindAllStringSubmatch(mpd.MediaPresentationDuration, -1)
-
-	if len(matches) > 0 {
-
-		hours, _ := getParsedTimeUnit(matches[0][2])
-		minutes, _ := getParsedTimeUnit(matches[0][4])
-		seconds, _ := getParsedTimeUnit(matches[0][6])
-		milliSeconds, _ := getParsedTimeUnit(matches[0][7])
+	isLive := mpd.Type == "dynamic"
+	totalSeconds, hasDuration := parseMPDDuration(mpd.MediaPresentationDuration)
 
-		totalSeconds = (hours * 60 * 60) + (minutes * 60) + seconds + milliSeconds/1000
+	if hasDuration || isLive {
 
 		for _, adaptationSet := range mpd.Period {
 			switch adaptationSet.MimeType {
@@ -111,9 +143,13 @@ func GetDashFormats(data []byte, masterPlaybackURL string) map[string]map[string
 					format["MIME-TYPE"] = adaptationSet.MimeType
 					format["STREAM"] = "video only"
 					format["TOTAL-SEGMENTS"] = fmt.Sprintf("%d", totalSegments)
+					format["SEGMENT-DURATION"] = strconv.FormatFloat(segmentScale, 'f', -1, 64)
 					format["INIT-URL"] = getURL(initializationURL, "$RepresentationID$", representation.ID)
 					format["STREAM-URL"] = getURL(mediaURL, "$RepresentationID$", representation.ID)
 					format["PLAYBACK-URL"] = masterPlaybackURL
+					if isLive {
+						addLiveTiming(format, mpd, adaptationSet.SegTemplate)
+					}
 					audioOrVideo["video"][fmt.Sprintf("%dk", bandwidth/1000)] = format
 				}
 			case "audio/mp4":
@@ -134,14 +170,18 @@ func GetDashFormats(data []byte, masterPlaybackURL string) map[string]map[string
 					format["MIME-TYPE"] = adaptationSet.MimeType
 					format["STREAM"] = "audio only"
 					format["TOTAL-SEGMENTS"] = fmt.Sprintf("%d", totalSegments)
+					format["SEGMENT-DURATION"] = strconv.FormatFloat(segmentScale, 'f', -1, 64)
 					format["SAMPLING-RATE"] = fmt.Sprintf("(%s Hz)", representation.AudioSamplingRate)
 					format["INIT-URL"] = getURL(initializationURL, "$RepresentationID$", representation.ID)
 					format["STREAM-URL"] = getURL(mediaURL, "$RepresentationID$", representation.ID)
 					format["PLAYBACK-URL"] = masterPlaybackURL
+					if isLive {
+						addLiveTiming(format, mpd, adaptationSet.SegTemplate)
+					}
 					audioOrVideo["audio"][fmt.Sprintf("%dk", bandwidth/1000)] = format
 				}
 			default:
-				fmt.Println("Unsupported format")
+				logInfo("Unsupported format\n")
 			}
 		}
 